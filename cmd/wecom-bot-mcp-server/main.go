@@ -1,6 +1,7 @@
 package main
 
 import (
+	"errors"
 	"os"
 
 	"github.com/rs/zerolog"
@@ -25,6 +26,11 @@ func main() {
 	})
 
 	if err := command.Execute(); err != nil {
+		// Commands returning an ExitError have already reported the error
+		var exitErr *cmd.ExitError
+		if errors.As(err, &exitErr) {
+			os.Exit(exitErr.Code)
+		}
 		log.Fatal().Err(err).Msg("Failed to execute command")
 	}
 }
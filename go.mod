@@ -3,16 +3,22 @@ module github.com/futuretea/wecom-bot-mcp-server
 go 1.25.5
 
 require (
+	github.com/alecthomas/chroma/v2 v2.20.0
 	github.com/futuretea/go-wecom-bot v0.0.1
 	github.com/mark3labs/mcp-go v0.41.1
+	github.com/robfig/cron/v3 v3.0.1
 	github.com/rs/zerolog v1.33.0
 	github.com/spf13/cobra v1.10.1
 	github.com/spf13/viper v1.18.0
+	github.com/yuin/goldmark v1.7.13
+	golang.org/x/image v0.18.0
+	gopkg.in/yaml.v3 v3.0.1
 )
 
 require (
 	github.com/bahlo/generic-list-go v0.2.0 // indirect
 	github.com/buger/jsonparser v1.1.1 // indirect
+	github.com/dlclark/regexp2 v1.11.5 // indirect
 	github.com/fsnotify/fsnotify v1.7.0 // indirect
 	github.com/futuretea/go-http-client v0.0.2 // indirect
 	github.com/google/uuid v1.6.0 // indirect
@@ -38,7 +44,6 @@ require (
 	go.uber.org/multierr v1.9.0 // indirect
 	golang.org/x/exp v0.0.0-20230905200255-921286631fa9 // indirect
 	golang.org/x/sys v0.15.0 // indirect
-	golang.org/x/text v0.14.0 // indirect
+	golang.org/x/text v0.16.0 // indirect
 	gopkg.in/ini.v1 v1.67.0 // indirect
-	gopkg.in/yaml.v3 v3.0.1 // indirect
 )
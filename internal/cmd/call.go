@@ -0,0 +1,100 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	wecomToolset "github.com/futuretea/wecom-bot-mcp-server/pkg/toolset/wecom"
+)
+
+// newCallCommand creates the call command
+func newCallCommand(cfgFile *string, streams IOStreams) *cobra.Command {
+	var argsJSON, argsFile string
+	var yes bool
+
+	cmd := &cobra.Command{
+		Use:   "call <tool>",
+		Short: "Invoke a tool with JSON arguments and print its result",
+		Long: `Invoke any enabled tool exactly as an MCP client would, without an MCP client.
+
+The tool is dispatched through the same handler as MCP requests, honoring
+enabled_tools and disabled_tools, and the CallToolResult is printed as JSON.
+Arguments are given with --args or --args-file ("-" reads standard input).
+
+When require_confirmation is enabled, send tools are refused unless --yes
+approves the message, since a held message would be lost when the command exits.
+For the same reason, the scheduling tools and confirm_send are refused: the
+command keeps no scheduled jobs or held messages between runs.
+
+The exit code is 0 when the tool succeeds, 1 when it returns an error result,
+and 2 for invalid input, configuration or an unknown tool.`,
+		Example: `  wecom-bot-mcp-server call send_text --args '{"content": "hello"}'
+  wecom-bot-mcp-server call list_media
+  cat args.json | wecom-bot-mcp-server call send_news --args-file -`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runCall(cmd, *cfgFile, streams, args[0], argsJSON, argsFile, yes)
+		},
+	}
+
+	cmd.Flags().StringVar(&argsJSON, "args", "", "Tool arguments as a JSON object (\"-\" reads standard input)")
+	cmd.Flags().StringVar(&argsFile, "args-file", "", "File containing the tool arguments as a JSON object (\"-\" reads standard input)")
+	cmd.Flags().BoolVarP(&yes, "yes", "y", false, "Approve the message when require_confirmation is enabled")
+	return cmd
+}
+
+// oneShotStatefulTools are the tools that act on scheduled jobs or held
+// messages, which a one-shot command does not keep between runs
+var oneShotStatefulTools = map[string]bool{
+	"schedule_message": true,
+	"list_scheduled":   true,
+	"cancel_scheduled": true,
+	"confirm_send":     true,
+}
+
+// runCall invokes a tool and prints its CallToolResult
+func runCall(cmd *cobra.Command, cfgFile string, streams IOStreams, tool, argsJSON, argsFile string, yes bool) error {
+	usageError := func(err error) error {
+		return reportResult(streams.Out, tool, "", err, ExitCodeUsage)
+	}
+
+	if oneShotStatefulTools[tool] {
+		return usageError(fmt.Errorf("%s needs the scheduled jobs and held messages of a running server and cannot be called directly", tool))
+	}
+
+	params, err := readJSONArgs(argsJSON, argsFile, streams.In)
+	if err != nil {
+		return usageError(err)
+	}
+
+	cfg, err := loadOneShotConfig(cmd, cfgFile, streams)
+	if err != nil {
+		return usageError(err)
+	}
+	if cfg.RequireConfirmation && wecomToolset.IsSendTool(tool) {
+		if !yes {
+			return usageError(fmt.Errorf("require_confirmation is enabled: review the message and pass --yes to send it"))
+		}
+		cfg.RequireConfirmation = false
+	}
+
+	server, err := newOneShotServer(cfg)
+	if err != nil {
+		return usageError(err)
+	}
+	defer server.Close()
+
+	result, err := server.InvokeTool(context.Background(), tool, params)
+	if err != nil {
+		return usageError(err)
+	}
+	if err := writeJSON(streams.Out, result); err != nil {
+		return err
+	}
+	if result.IsError {
+		return &ExitError{Code: ExitCodeFailure, Err: fmt.Errorf("tool %s returned an error", tool)}
+	}
+	return nil
+}
@@ -0,0 +1,88 @@
+package cmd
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/spf13/viper"
+
+	"github.com/futuretea/wecom-bot-mcp-server/pkg/fakewecom"
+)
+
+func TestCallCommand_RequireConfirmation(t *testing.T) {
+	const key = "693a91f6-7abc-4bc4-97a0-0ec2cafe5aaa"
+	fake := fakewecom.New(fakewecom.Options{Keys: []string{key}})
+	server := httptest.NewServer(fake)
+	defer server.Close()
+	t.Setenv("WECOM_MCP_WECOM_BOT_KEY", key)
+	t.Setenv("WECOM_MCP_WECOM_API_BASE_URL", server.URL)
+	t.Setenv("WECOM_MCP_REQUIRE_CONFIRMATION", "true")
+
+	tests := []struct {
+		name     string
+		args     []string
+		wantCode int
+		wantOut  string
+		wantSent int
+	}{
+		{
+			name:     "send tool without --yes",
+			args:     []string{"call", "send_text", "--args", `{"content":"hello"}`},
+			wantCode: ExitCodeUsage,
+			wantOut:  "pass --yes to send it",
+		},
+		{
+			name:     "send tool with --yes",
+			args:     []string{"call", "send_text", "--args", `{"content":"hello"}`, "--yes"},
+			wantOut:  "Text message sent successfully",
+			wantSent: 1,
+		},
+		{
+			name:    "other tool without --yes",
+			args:    []string{"call", "list_media"},
+			wantOut: "No uploaded media",
+		},
+		{
+			name:     "schedule tool",
+			args:     []string{"call", "schedule_message", "--args", `{"tool":"send_text","arguments":{"content":"hello"},"at":"2030-01-01T00:00:00Z"}`},
+			wantCode: ExitCodeUsage,
+			wantOut:  "cannot be called directly",
+		},
+		{
+			name:     "confirm_send",
+			args:     []string{"call", "confirm_send", "--args", `{"pending_id":"p1"}`},
+			wantCode: ExitCodeUsage,
+			wantOut:  "cannot be called directly",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			viper.Reset()
+			t.Cleanup(viper.Reset)
+			fake.Reset()
+
+			var out bytes.Buffer
+			cmd := NewMCPServer(IOStreams{In: strings.NewReader(""), Out: &out, ErrOut: io.Discard})
+			cmd.SetArgs(tt.args)
+			err := cmd.Execute()
+
+			var exitErr *ExitError
+			switch {
+			case tt.wantCode == 0 && err != nil:
+				t.Fatalf("unexpected error: %v\n%s", err, out.String())
+			case tt.wantCode != 0 && (!errors.As(err, &exitErr) || exitErr.Code != tt.wantCode):
+				t.Fatalf("expected exit code %d, got %v", tt.wantCode, err)
+			}
+			if !strings.Contains(out.String(), tt.wantOut) {
+				t.Fatalf("expected output to contain %q, got %s", tt.wantOut, out.String())
+			}
+			if got := len(fake.Messages()); got != tt.wantSent {
+				t.Fatalf("expected %d message(s) sent, got %d", tt.wantSent, got)
+			}
+		})
+	}
+}
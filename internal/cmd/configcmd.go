@@ -0,0 +1,274 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"slices"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+
+	"github.com/futuretea/wecom-bot-mcp-server/pkg/callback"
+	"github.com/futuretea/wecom-bot-mcp-server/pkg/core/config"
+	"github.com/futuretea/wecom-bot-mcp-server/pkg/hooks"
+	wecomToolset "github.com/futuretea/wecom-bot-mcp-server/pkg/toolset/wecom"
+)
+
+// Config print formats
+const formatTable = "table"
+
+// newConfigCommand creates the config command and its subcommands
+func newConfigCommand(cfgFile *string, streams IOStreams) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "config",
+		Short: "Validate and inspect the effective configuration",
+		Long: `Validate and inspect the configuration merged from command-line flags,
+WECOM_MCP_* environment variables, the config file and defaults.`,
+	}
+
+	cmd.AddCommand(newConfigValidateCommand(cfgFile, streams), newConfigPrintCommand(cfgFile, streams))
+	return cmd
+}
+
+// newConfigValidateCommand creates the config validate command
+func newConfigValidateCommand(cfgFile *string, streams IOStreams) *cobra.Command {
+	return &cobra.Command{
+		Use:   "validate",
+		Short: "Check the configuration for errors",
+		Long: `Check the configuration for errors. In addition to the checks run at startup,
+this reports unknown keys in the config file, unknown WECOM_MCP_* environment
+variables, unknown tool names in enabled_tools and disabled_tools, and files
+(user directory, holidays, schedule) that cannot be loaded.
+
+The exit code is 0 when the configuration is valid and 1 otherwise.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			problems := validateConfig(*cfgFile)
+			if len(problems) == 0 {
+				fmt.Fprintln(streams.Out, "Configuration is valid")
+				return nil
+			}
+
+			fmt.Fprintf(streams.Out, "Found %d problem(s):\n", len(problems))
+			for _, problem := range problems {
+				fmt.Fprintf(streams.Out, "- %s\n", problem)
+			}
+			return &ExitError{Code: ExitCodeFailure, Err: fmt.Errorf("configuration is invalid")}
+		},
+	}
+}
+
+// validateConfig runs every configuration check and returns the problems found
+func validateConfig(cfgFile string) []string {
+	cfg, err := config.ReadConfig(cfgFile)
+	if err != nil {
+		return []string{err.Error()}
+	}
+
+	var problems []string
+	if err := cfg.Validate(); err != nil {
+		problems = append(problems, err.Error())
+	}
+
+	if cfgFile != "" {
+		unknown, err := config.UnknownFileKeys(cfgFile)
+		if err != nil {
+			problems = append(problems, err.Error())
+		}
+		for _, key := range unknown {
+			problems = append(problems, fmt.Sprintf("unknown key %q in %s", key, cfgFile))
+		}
+	}
+	for _, env := range config.UnknownEnvVars() {
+		problems = append(problems, fmt.Sprintf("unknown environment variable %s", env))
+	}
+
+	var toolNames []string
+	for _, tool := range (&wecomToolset.Toolset{}).GetTools(nil) {
+		toolNames = append(toolNames, tool.Tool.Name)
+	}
+	for _, list := range []struct {
+		key   string
+		names []string
+	}{
+		{key: "enabled_tools", names: cfg.EnabledTools},
+		{key: "disabled_tools", names: cfg.DisabledTools},
+	} {
+		for _, name := range list.names {
+			if !slices.Contains(toolNames, name) {
+				problems = append(problems, fmt.Sprintf("%s: unknown tool %q", list.key, name))
+			}
+		}
+	}
+
+	// Build the components that are otherwise only checked when the server starts.
+	// Validate already reports content policy errors, so the filter is only
+	// built once the rules are known to be valid.
+	if cfg.ContentPolicy.Validate() == nil {
+		if _, err := wecomToolset.NewContentFilter(cfg.ContentPolicy, cfg.WeComAPI.BaseURLOrDefault(), cfg.WeComBotKey); err != nil {
+			problems = append(problems, fmt.Sprintf("content_policy: %v", err))
+		}
+	}
+	if cfg.QuietHours.Enabled() && cfg.QuietHours.Validate() == nil {
+		if _, err := wecomToolset.NewQuietHours(cfg.QuietHours); err != nil {
+			problems = append(problems, err.Error())
+		}
+	}
+	if cfg.DirectoryFile != "" {
+		if _, err := wecomToolset.LoadDirectory(cfg.DirectoryFile); err != nil {
+			problems = append(problems, fmt.Sprintf("directory_file: %v", err))
+		}
+	}
+	if cfg.ScheduleFile != "" {
+		if _, err := wecomToolset.NewScheduler(cfg.ScheduleFile, nil); err != nil {
+			problems = append(problems, fmt.Sprintf("schedule_file: %v", err))
+		}
+	}
+	if cfg.MediaFile != "" {
+		if _, err := wecomToolset.NewMediaRegistry(cfg.MediaFile); err != nil {
+			problems = append(problems, fmt.Sprintf("media_file: %v", err))
+		}
+	}
+	if cfg.History.File != "" {
+		if _, err := wecomToolset.NewHistory(wecomToolset.DefaultBotName, cfg.History.Size, cfg.History.File); err != nil {
+			problems = append(problems, fmt.Sprintf("history.file: %v", err))
+		}
+	}
+	if cfg.Hooks.Alertmanager.Enabled && cfg.Hooks.Alertmanager.Validate() == nil {
+		if _, err := hooks.NewAlertmanagerHandler(cfg.Hooks.Alertmanager, nil); err != nil {
+			problems = append(problems, err.Error())
+		}
+	}
+	if cfg.Hooks.Validate() == nil {
+		if cfg.Hooks.GitHub.Enabled {
+			if _, err := hooks.NewGitHubHandler(cfg.Hooks.GitHub, nil); err != nil {
+				problems = append(problems, err.Error())
+			}
+		}
+		if cfg.Hooks.GitLab.Enabled {
+			if _, err := hooks.NewGitLabHandler(cfg.Hooks.GitLab, nil); err != nil {
+				problems = append(problems, err.Error())
+			}
+		}
+		for _, endpoint := range cfg.Hooks.Endpoints {
+			if _, err := hooks.NewGenericHandler(endpoint, nil); err != nil {
+				problems = append(problems, err.Error())
+			}
+		}
+	}
+	if cfg.Callback.Enabled && cfg.Callback.Validate() == nil {
+		if _, err := callback.NewHandler(cfg.Callback, nil); err != nil {
+			problems = append(problems, err.Error())
+		}
+	}
+	return problems
+}
+
+// configValue is one row of config print output
+type configValue struct {
+	Key    string `json:"key"`
+	Value  any    `json:"value"`
+	Source string `json:"source"`
+}
+
+// newConfigPrintCommand creates the config print command
+func newConfigPrintCommand(cfgFile *string, streams IOStreams) *cobra.Command {
+	var format string
+
+	cmd := &cobra.Command{
+		Use:   "print",
+		Short: "Print the effective configuration and where each value comes from",
+		Long: `Print every configuration key with its effective value and its source: a
+command-line flag, an environment variable, the config file or the default.
+Secrets such as the bot key are masked.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if format != formatTable && format != formatJSON {
+				return fmt.Errorf("--format must be %s or %s, got %q", formatTable, formatJSON, format)
+			}
+
+			cfg, err := config.ReadConfig(*cfgFile)
+			if err != nil {
+				return err
+			}
+
+			var values []configValue
+			for _, field := range cfg.Fields() {
+				value := field.Value
+				if s, ok := value.(string); ok && field.Secret {
+					value = maskSecret(s)
+				}
+				values = append(values, configValue{Key: field.Key, Value: value, Source: valueSource(cmd, field.Key)})
+			}
+
+			if format == formatJSON {
+				return writeJSON(streams.Out, values)
+			}
+			w := tabwriter.NewWriter(streams.Out, 0, 4, 2, ' ', 0)
+			fmt.Fprintln(w, "KEY\tVALUE\tSOURCE")
+			for _, v := range values {
+				fmt.Fprintf(w, "%s\t%s\t%s\n", v.Key, formatConfigValue(v.Value), v.Source)
+			}
+			return w.Flush()
+		},
+	}
+
+	cmd.Flags().StringVar(&format, "format", formatTable, "Output format: table or json")
+	return cmd
+}
+
+// valueSource describes where the effective value of key comes from, following
+// the configuration precedence: flag, environment variable, config file, default
+func valueSource(cmd *cobra.Command, key string) string {
+	if flag, ok := flagBindings[key]; ok && cmd.Flags().Changed(flag) {
+		return "flag --" + flag
+	}
+	if env := config.EnvVar(key); isEnvSet(env) {
+		return "env " + env
+	}
+	if viper.InConfig(key) {
+		return "file " + viper.ConfigFileUsed()
+	}
+	return "default"
+}
+
+// isEnvSet reports whether an environment variable is set, even if empty
+func isEnvSet(name string) bool {
+	_, ok := os.LookupEnv(name)
+	return ok
+}
+
+// maskSecret hides all but the first few characters of a secret
+func maskSecret(secret string) string {
+	const visible = 4
+	if secret == "" {
+		return ""
+	}
+	if len(secret) <= 2*visible {
+		return "********"
+	}
+	return secret[:visible] + "********"
+}
+
+// formatConfigValue renders a value for the table output
+func formatConfigValue(value any) string {
+	switch v := value.(type) {
+	case string:
+		if v == "" {
+			return `""`
+		}
+		return v
+	case nil:
+		return "[]"
+	case []any, map[string]any:
+		b, err := json.Marshal(v)
+		if err != nil {
+			return fmt.Sprint(v)
+		}
+		return string(b)
+	default:
+		return fmt.Sprint(v)
+	}
+}
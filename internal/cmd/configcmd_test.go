@@ -0,0 +1,64 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/spf13/viper"
+)
+
+func TestMaskSecret(t *testing.T) {
+	tests := map[string]string{
+		"":                                     "",
+		"short":                                "********",
+		"693a91f6-7xxx-4bc4-97a0-0ec2sifa5aaa": "693a********",
+	}
+	for secret, want := range tests {
+		if got := maskSecret(secret); got != want {
+			t.Errorf("maskSecret(%q) = %q, want %q", secret, got, want)
+		}
+	}
+}
+
+func TestFormatConfigValue(t *testing.T) {
+	tests := []struct {
+		value any
+		want  string
+	}{
+		{value: "", want: `""`},
+		{value: 8080, want: "8080"},
+		{value: nil, want: "[]"},
+		{value: []any{"send_text", "send_markdown"}, want: `["send_text","send_markdown"]`},
+	}
+	for _, tt := range tests {
+		if got := formatConfigValue(tt.value); got != tt.want {
+			t.Errorf("formatConfigValue(%v) = %q, want %q", tt.value, got, tt.want)
+		}
+	}
+}
+
+func TestValidateConfig(t *testing.T) {
+	t.Cleanup(viper.Reset)
+
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	content := `wecom_bot_key: test-key
+log_levle: 3
+enabled_tools: [send_text, send_txt]
+directory_file: /nonexistent/directory.yaml
+`
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	problems := strings.Join(validateConfig(path), "\n")
+	for _, want := range []string{`unknown key "log_levle"`, `enabled_tools: unknown tool "send_txt"`, "directory_file:"} {
+		if !strings.Contains(problems, want) {
+			t.Errorf("expected problem %q, got:\n%s", want, problems)
+		}
+	}
+	if strings.Contains(problems, `"send_text"`) {
+		t.Errorf("send_text should be a known tool, got:\n%s", problems)
+	}
+}
@@ -0,0 +1,192 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+
+	"github.com/futuretea/wecom-bot-mcp-server/pkg/core/config"
+	"github.com/futuretea/wecom-bot-mcp-server/pkg/wecomapi"
+)
+
+// defaultTestMessage is the text sent by doctor --send-test
+const defaultTestMessage = "wecom-bot-mcp-server doctor: test message"
+
+// doctorOptions holds the doctor command flags
+type doctorOptions struct {
+	sendTest bool
+	message  string
+	format   string
+}
+
+// keyReport is the doctor result for one bot key
+type keyReport struct {
+	Name   string        `json:"name"`
+	Key    string        `json:"key"`
+	Checks []checkResult `json:"checks"`
+}
+
+// checkResult is the outcome of a single doctor check
+type checkResult struct {
+	Check   string `json:"check"`
+	OK      bool   `json:"ok"`
+	ErrCode *int   `json:"errcode,omitempty"`
+	Detail  string `json:"detail"`
+}
+
+// doctorReport is the JSON document printed by doctor --format json
+type doctorReport struct {
+	BaseURL string      `json:"base_url"`
+	OK      bool        `json:"ok"`
+	Keys    []keyReport `json:"keys"`
+}
+
+// newDoctorCommand creates the doctor command
+func newDoctorCommand(cfgFile *string, streams IOStreams) *cobra.Command {
+	opts := doctorOptions{}
+
+	cmd := &cobra.Command{
+		Use:   "doctor",
+		Short: "Check that the configured bot keys are accepted by WeCom",
+		Long: `Check every configured bot key before the first real message fails.
+
+Each key is checked for format and then probed with an empty payload, which
+WeCom rejects with errcode 40008 (invalid message type) for a valid key and
+93000 for an invalid one, so nothing is posted to the group. With --send-test a
+real test message is sent instead.
+
+Requests use the wecom_api settings (base URL, proxy, timeout and CA bundle),
+so the checks also verify the network path. The base URL can be changed with
+--wecom-base-url, e.g. to run against a local fake server.
+
+The exit code is 0 when every check passes and 1 otherwise.`,
+		Example: `  wecom-bot-mcp-server doctor
+  wecom-bot-mcp-server doctor --send-test --message "Hello from staging"
+  wecom-bot-mcp-server doctor --wecom-base-url http://localhost:8081 --format json`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runDoctor(*cfgFile, streams, opts)
+		},
+	}
+
+	cmd.Flags().BoolVar(&opts.sendTest, "send-test", false, "Send a real test message instead of the empty-payload probe")
+	cmd.Flags().StringVar(&opts.message, "message", defaultTestMessage, "Text of the test message sent with --send-test")
+	cmd.Flags().StringVar(&opts.format, "format", formatTable, "Output format: table or json")
+	return cmd
+}
+
+// runDoctor checks the configured bot keys and prints a report
+func runDoctor(cfgFile string, streams IOStreams, opts doctorOptions) error {
+	if opts.format != formatTable && opts.format != formatJSON {
+		return fmt.Errorf("--format must be %s or %s, got %q", formatTable, formatJSON, opts.format)
+	}
+
+	cfg, err := config.ReadConfig(cfgFile)
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+	if err := cfg.WeComAPI.Validate(); err != nil {
+		return err
+	}
+
+	client, err := wecomapi.New(cfg.WeComAPI)
+	if err != nil {
+		return err
+	}
+	report := doctorReport{BaseURL: client.BaseURL(), OK: true}
+	for _, bot := range []struct{ name, key string }{
+		{name: "wecom_bot_key", key: cfg.WeComBotKey},
+	} {
+		result := checkKey(client, bot.name, bot.key, opts)
+		for _, check := range result.Checks {
+			report.OK = report.OK && check.OK
+		}
+		report.Keys = append(report.Keys, result)
+	}
+
+	if opts.format == formatJSON {
+		err = writeJSON(streams.Out, report)
+	} else {
+		err = writeDoctorTable(streams.Out, report)
+	}
+	if err != nil {
+		return err
+	}
+	if !report.OK {
+		return &ExitError{Code: ExitCodeFailure, Err: errors.New("doctor found problems")}
+	}
+	return nil
+}
+
+// checkKey checks the format of a bot key and then probes it, or sends a
+// test message with it, through the WeCom API
+func checkKey(client *wecomapi.Client, name, key string, opts doctorOptions) keyReport {
+	report := keyReport{Name: name, Key: maskSecret(key)}
+
+	if err := wecomapi.CheckKeyFormat(key); err != nil {
+		report.Checks = append(report.Checks,
+			checkResult{Check: "format", Detail: err.Error()},
+			checkResult{Check: "probe", Detail: "skipped because the key format is invalid"},
+		)
+		return report
+	}
+	report.Checks = append(report.Checks, checkResult{Check: "format", OK: true, Detail: "ok"})
+
+	ctx := context.Background()
+	if opts.sendTest {
+		payload := map[string]any{"msgtype": "text", "text": map[string]any{"content": opts.message}}
+		report.Checks = append(report.Checks, callCheck(ctx, client, "test_message", key, payload, wecomapi.ErrCodeOK))
+	} else {
+		report.Checks = append(report.Checks, callCheck(ctx, client, "probe", key, map[string]any{}, wecomapi.ErrCodeInvalidMessageType))
+	}
+	return report
+}
+
+// callCheck posts payload with key and compares the WeCom error code with the
+// code expected for a valid key. A rate-limited key also counts as valid.
+func callCheck(ctx context.Context, client *wecomapi.Client, check, key string, payload any, expected int) checkResult {
+	resp, err := client.Send(ctx, key, payload)
+	if err != nil {
+		return checkResult{Check: check, Detail: err.Error()}
+	}
+
+	result := checkResult{Check: check, ErrCode: &resp.ErrCode}
+	detail := fmt.Sprintf("errcode %d (%s)", resp.ErrCode, wecomapi.DescribeErrCode(resp.ErrCode))
+	switch resp.ErrCode {
+	case expected:
+		result.OK = true
+		result.Detail = detail + ": key accepted"
+	case wecomapi.ErrCodeRateLimited:
+		result.OK = true
+		result.Detail = detail + ": key accepted but currently rate limited"
+	default:
+		result.Detail = detail + ": " + resp.ErrMsg
+	}
+	return result
+}
+
+// writeDoctorTable prints the doctor report as a table
+func writeDoctorTable(out io.Writer, report doctorReport) error {
+	fmt.Fprintf(out, "WeCom API: %s\n\n", report.BaseURL)
+
+	w := tabwriter.NewWriter(out, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "KEY\tCHECK\tSTATUS\tDETAIL")
+	for _, key := range report.Keys {
+		label := key.Name
+		if key.Key != "" {
+			label += " (" + key.Key + ")"
+		}
+		for _, check := range key.Checks {
+			status := "FAIL"
+			if check.OK {
+				status = "OK"
+			}
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", label, check.Check, status, check.Detail)
+		}
+	}
+	return w.Flush()
+}
@@ -0,0 +1,78 @@
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/futuretea/wecom-bot-mcp-server/pkg/fakewecom"
+	"github.com/futuretea/wecom-bot-mcp-server/pkg/wecomapi"
+)
+
+const doctorTestKey = "693a91f6-7abc-4bc4-97a0-0ec2cafe5aaa"
+
+// newDoctorTestClient starts a fake WeCom server that only accepts doctorTestKey
+func newDoctorTestClient(t *testing.T) *wecomapi.Client {
+	t.Helper()
+	server := httptest.NewServer(fakewecom.New(fakewecom.Options{Keys: []string{doctorTestKey}}))
+	t.Cleanup(server.Close)
+	return wecomapi.NewClient(server.URL, server.Client())
+}
+
+func TestCheckKey(t *testing.T) {
+	client := newDoctorTestClient(t)
+	opts := doctorOptions{message: defaultTestMessage}
+
+	tests := []struct {
+		name     string
+		key      string
+		sendTest bool
+		want     string
+	}{
+		{name: "valid key probe", key: doctorTestKey, want: "format:true probe:true"},
+		{name: "valid key test message", key: doctorTestKey, sendTest: true, want: "format:true test_message:true"},
+		{name: "unknown key", key: "00000000-0000-0000-0000-000000000000", want: "format:true probe:false"},
+		{name: "malformed key", key: "not-a-key", want: "format:false probe:false"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts.sendTest = tt.sendTest
+			report := checkKey(client, "wecom_bot_key", tt.key, opts)
+
+			var got []string
+			for _, check := range report.Checks {
+				got = append(got, fmt.Sprintf("%s:%t", check.Check, check.OK))
+			}
+			if strings.Join(got, " ") != tt.want {
+				t.Fatalf("unexpected checks %v: %+v", got, report.Checks)
+			}
+		})
+	}
+}
+
+func TestWriteDoctorTable(t *testing.T) {
+	code := 93000
+	report := doctorReport{
+		BaseURL: "http://localhost:8081",
+		Keys: []keyReport{{
+			Name: "wecom_bot_key",
+			Key:  maskSecret(doctorTestKey),
+			Checks: []checkResult{
+				{Check: "format", OK: true, Detail: "ok"},
+				{Check: "probe", ErrCode: &code, Detail: "errcode 93000 (invalid webhook key): invalid webhook url"},
+			},
+		}},
+	}
+
+	var out bytes.Buffer
+	if err := writeDoctorTable(&out, report); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for _, want := range []string{"WeCom API: http://localhost:8081", "wecom_bot_key (693a********)", "FAIL", "errcode 93000"} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("expected %q in output:\n%s", want, out.String())
+		}
+	}
+}
@@ -0,0 +1,97 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/futuretea/wecom-bot-mcp-server/pkg/core/logging"
+	"github.com/futuretea/wecom-bot-mcp-server/pkg/fakewecom"
+	internalhttp "github.com/futuretea/wecom-bot-mcp-server/pkg/server/http"
+)
+
+// newFakeWeComCommand creates the fake-wecom command
+func newFakeWeComCommand(streams IOStreams) *cobra.Command {
+	var listen string
+	opts := fakewecom.Options{}
+
+	cmd := &cobra.Command{
+		Use:   "fake-wecom",
+		Short: "Run a fake WeCom webhook server for tests and local development",
+		Long: `Run an in-memory stand-in for the WeCom group bot webhook API.
+
+The server implements /cgi-bin/webhook/send and /cgi-bin/webhook/upload_media,
+validates requests like WeCom and answers with the same error codes, including
+93000 for an unknown key and 45009 when the rate limit is exceeded. Latency and
+random failures can be injected.
+
+Accepted messages are logged and listed as JSON at GET /messages; uploads at
+GET /uploads. DELETE /messages resets the server.
+
+Point the MCP server at it with --wecom-base-url.`,
+		Example: `  wecom-bot-mcp-server fake-wecom --listen :8081
+  wecom-bot-mcp-server --wecom-base-url http://localhost:8081 --wecom-bot-key 693a91f6-7abc-4bc4-97a0-0ec2cafe5aaa
+  wecom-bot-mcp-server fake-wecom --latency 500ms --failure-rate 0.1 --failure-code 45009`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			level, _ := cmd.Flags().GetInt("log-level")
+			logging.Initialize(level, streams.ErrOut)
+			return runFakeWeCom(cmd.Context(), listen, opts, streams)
+		},
+	}
+
+	cmd.Flags().StringVar(&listen, "listen", ":8081", "Address to listen on")
+	cmd.Flags().StringSliceVar(&opts.Keys, "key", nil, "Accepted webhook keys (default: any well-formed key)")
+	cmd.Flags().IntVar(&opts.RateLimit, "rate-limit", fakewecom.DefaultRateLimit, "Messages accepted per key per minute (negative disables the limit)")
+	cmd.Flags().DurationVar(&opts.Latency, "latency", 0, "Delay before every API response")
+	cmd.Flags().Float64Var(&opts.FailureRate, "failure-rate", 0, "Probability (0-1) that an API call fails with --failure-code")
+	cmd.Flags().IntVar(&opts.FailureCode, "failure-code", -1, "Error code returned by injected failures")
+	return cmd
+}
+
+// runFakeWeCom serves a fake WeCom server until ctx is cancelled or the
+// process receives SIGINT or SIGTERM
+func runFakeWeCom(ctx context.Context, listen string, opts fakewecom.Options, streams IOStreams) error {
+	if opts.FailureRate < 0 || opts.FailureRate > 1 {
+		return fmt.Errorf("--failure-rate must be between 0 and 1, got %v", opts.FailureRate)
+	}
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	ctx, stop := signal.NotifyContext(ctx, syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	listener, err := net.Listen("tcp", listen)
+	if err != nil {
+		return fmt.Errorf("failed to listen on %s: %w", listen, err)
+	}
+	httpServer := &http.Server{
+		Handler:           internalhttp.RequestMiddleware(fakewecom.New(opts)),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	fmt.Fprintf(streams.ErrOut, "Fake WeCom server listening on http://%s\n", listener.Addr())
+	serverErr := make(chan error, 1)
+	go func() {
+		if err := httpServer.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			serverErr <- err
+		}
+	}()
+
+	select {
+	case <-ctx.Done():
+	case err := <-serverErr:
+		return err
+	}
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	return httpServer.Shutdown(shutdownCtx)
+}
@@ -0,0 +1,109 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+
+	"github.com/futuretea/wecom-bot-mcp-server/pkg/core/config"
+	"github.com/futuretea/wecom-bot-mcp-server/pkg/core/logging"
+	"github.com/futuretea/wecom-bot-mcp-server/pkg/server/mcp"
+)
+
+// Exit codes used by one-shot commands
+const (
+	// ExitCodeFailure means the tool ran and reported an error
+	ExitCodeFailure = 1
+	// ExitCodeUsage means the command could not run because of invalid input or configuration
+	ExitCodeUsage = 2
+)
+
+// ExitError is returned by commands that have already reported the error on
+// their output and only need the process to exit with Code.
+type ExitError struct {
+	Code int
+	Err  error
+}
+
+func (e *ExitError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *ExitError) Unwrap() error {
+	return e.Err
+}
+
+// commandResult is the JSON document printed by one-shot commands
+type commandResult struct {
+	OK     bool   `json:"ok"`
+	Tool   string `json:"tool,omitempty"`
+	Result string `json:"result,omitempty"`
+	Error  string `json:"error,omitempty"`
+}
+
+// writeJSON prints v as indented JSON
+func writeJSON(out io.Writer, v any) error {
+	encoder := json.NewEncoder(out)
+	encoder.SetEscapeHTML(false)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(v)
+}
+
+// reportResult prints the outcome of a tool call and converts a failure into
+// an ExitError with the given code
+func reportResult(out io.Writer, tool, result string, err error, code int) error {
+	if err != nil {
+		_ = writeJSON(out, commandResult{OK: false, Tool: tool, Error: err.Error()})
+		return &ExitError{Code: code, Err: err}
+	}
+	return writeJSON(out, commandResult{OK: true, Tool: tool, Result: result})
+}
+
+// loadOneShotConfig loads the configuration for a command that runs a single
+// action and exits. Features that need a long-running process are adjusted:
+// scheduled jobs are not persisted or run, quiet hours reject instead of
+// deferring, and digest mode is disabled.
+func loadOneShotConfig(cmd *cobra.Command, cfgFile string, streams IOStreams) (*config.StaticConfig, error) {
+	cfg, err := config.LoadConfig(cfgFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load config: %w", err)
+	}
+
+	// Keep script output quiet: only warnings and errors are logged unless a
+	// log level was set explicitly
+	level := cfg.LogLevel
+	if !explicitLogLevel(cmd) {
+		level = 3
+	}
+	logging.Initialize(level, streams.ErrOut)
+
+	cfg.ScheduleFile = ""
+	if cfg.QuietHours.Enabled() {
+		cfg.QuietHours.Action = config.QuietHoursActionReject
+	}
+	cfg.Digest.Window = 0
+	return cfg, nil
+}
+
+// explicitLogLevel reports whether log_level was set by a flag, environment
+// variable or config file rather than by the flag default
+func explicitLogLevel(cmd *cobra.Command) bool {
+	if cmd.Flags().Changed("log-level") || viper.InConfig("log_level") {
+		return true
+	}
+	_, ok := os.LookupEnv("WECOM_MCP_LOG_LEVEL")
+	return ok
+}
+
+// newOneShotServer creates an MCP server for a one-shot command
+func newOneShotServer(cfg *config.StaticConfig) (*mcp.Server, error) {
+	server, err := mcp.NewServer(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create MCP server: %w", err)
+	}
+	return server, nil
+}
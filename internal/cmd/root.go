@@ -22,21 +22,32 @@ type IOStreams struct {
 	ErrOut io.Writer
 }
 
+// flagBindings maps viper config keys to the flags that set them
+var flagBindings = map[string]string{
+	// Server configuration
+	"port":         "port",
+	"sse_base_url": "sse-base-url",
+	"log_level":    "log-level",
+	// WeCom Bot configuration
+	"wecom_bot_key":        "wecom-bot-key",
+	"wecom_api.base_url":   "wecom-base-url",
+	"wecom_api.proxy":      "wecom-proxy",
+	"wecom_api.timeout":    "wecom-timeout",
+	"wecom_api.ca_file":    "wecom-ca-file",
+	"require_confirmation": "require-confirmation",
+	"directory_file":       "directory-file",
+	"schedule_file":        "schedule-file",
+	"media_file":           "media-file",
+	"digest.window":        "digest-window",
+	"history.size":         "history-size",
+	"history.file":         "history-file",
+	// Tool configuration
+	"enabled_tools":  "enabled-tools",
+	"disabled_tools": "disabled-tools",
+}
+
 // bindFlags binds command-line flags to viper configuration keys
 func bindFlags(cmd *cobra.Command) {
-	// Map of viper config key to flag name
-	flagBindings := map[string]string{
-		// Server configuration
-		"port":         "port",
-		"sse_base_url": "sse-base-url",
-		"log_level":    "log-level",
-		// WeCom Bot configuration
-		"wecom_bot_key": "wecom-bot-key",
-		// Tool configuration
-		"enabled_tools":  "enabled-tools",
-		"disabled_tools": "disabled-tools",
-	}
-
 	for key, flag := range flagBindings {
 		_ = viper.BindPFlag(key, cmd.Flags().Lookup(flag))
 	}
@@ -73,19 +84,36 @@ messages.`,
 	cmd.PersistentFlags().StringVar(&cfgFile, "config", "", "config file path (supports YAML)")
 
 	// Server configuration flags
-	cmd.Flags().Int("port", 0, "Port to listen on for HTTP/SSE mode (0 for stdio mode)")
-	cmd.Flags().String("sse-base-url", "", "SSE public base URL to use when sending the endpoint message (e.g. https://example.com)")
-	cmd.Flags().Int("log-level", 5, "Log level (0-9)")
+	cmd.PersistentFlags().Int("port", 0, "Port to listen on for HTTP/SSE mode (0 for stdio mode)")
+	cmd.PersistentFlags().String("sse-base-url", "", "SSE public base URL to use when sending the endpoint message (e.g. https://example.com)")
+	cmd.PersistentFlags().Int("log-level", 5, "Log level (0-9)")
 
 	// WeCom Bot configuration flags
-	cmd.Flags().String("wecom-bot-key", "", "WeCom bot webhook key")
+	cmd.PersistentFlags().String("wecom-bot-key", "", "WeCom bot webhook key")
+	cmd.PersistentFlags().String("wecom-base-url", "", "WeCom API base URL, e.g. a local fake server (default "+config.DefaultWeComBaseURL+")")
+	cmd.PersistentFlags().String("wecom-proxy", "", "Proxy URL for WeCom API requests (default from HTTP_PROXY/HTTPS_PROXY)")
+	cmd.PersistentFlags().Duration("wecom-timeout", 0, "Timeout for each WeCom API request (0 uses 30s)")
+	cmd.PersistentFlags().String("wecom-ca-file", "", "PEM bundle of additional CA certificates for WeCom API requests")
+	cmd.PersistentFlags().Bool("require-confirmation", false, "Require human approval before any message is sent")
+	cmd.PersistentFlags().String("directory-file", "", "YAML or CSV user directory for resolving @mentions by name")
+	cmd.PersistentFlags().String("schedule-file", "", "File to persist scheduled messages across restarts (empty keeps them in memory)")
+	cmd.PersistentFlags().String("media-file", "", "File to persist uploaded media_ids until they expire (empty keeps them in memory)")
+	cmd.PersistentFlags().Duration("digest-window", 0, "Buffer text and markdown messages for this long and send them as one digest (0 disables)")
+	cmd.PersistentFlags().Int("history-size", 0, "Number of sent messages kept in the history (0 keeps the last 200)")
+	cmd.PersistentFlags().String("history-file", "", "File to persist the sent-message history across restarts (empty keeps it in memory)")
 
 	// Tool configuration flags
-	cmd.Flags().StringSlice("enabled-tools", []string{}, "Comma-separated list of tools to enable")
-	cmd.Flags().StringSlice("disabled-tools", []string{}, "Comma-separated list of tools to disable")
+	cmd.PersistentFlags().StringSlice("enabled-tools", []string{}, "Comma-separated list of tools to enable")
+	cmd.PersistentFlags().StringSlice("disabled-tools", []string{}, "Comma-separated list of tools to disable")
 
-	// Add version command
+	// Add subcommands
 	cmd.AddCommand(newVersionCommand(streams))
+	cmd.AddCommand(newSendCommand(&cfgFile, streams))
+	cmd.AddCommand(newCallCommand(&cfgFile, streams))
+	cmd.AddCommand(newToolsCommand(&cfgFile, streams))
+	cmd.AddCommand(newConfigCommand(&cfgFile, streams))
+	cmd.AddCommand(newDoctorCommand(&cfgFile, streams))
+	cmd.AddCommand(newFakeWeComCommand(streams))
 
 	return cmd
 }
@@ -0,0 +1,363 @@
+package cmd
+
+import (
+	"crypto/md5"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+// stdinArg is the flag value that reads from standard input
+const stdinArg = "-"
+
+// sendOptions holds the flags shared by all send subcommands
+type sendOptions struct {
+	json     string
+	jsonFile string
+	priority string
+	yes      bool
+}
+
+// paramBuilder adds flag values to the tool arguments
+type paramBuilder func(cmd *cobra.Command, params map[string]any, in io.Reader) error
+
+// newSendCommand creates the send command and its subcommands
+func newSendCommand(cfgFile *string, streams IOStreams) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "send",
+		Short: "Send a message from the command line",
+		Long: `Send a message through the configured WeCom bot, for use in scripts and cron jobs.
+
+Messages go through the same tools, validation and policies as MCP tool calls.
+Arguments can be given as flags, as a JSON object with --json or --json-file
+("-" reads standard input), or both; flags override JSON fields.
+
+The result is printed as JSON. The exit code is 0 on success, 1 if the message
+could not be sent, and 2 for invalid input or configuration.`,
+	}
+
+	cmd.AddCommand(
+		newSendTextCommand(cfgFile, streams),
+		newSendMarkdownCommand(cfgFile, streams),
+		newSendImageCommand(cfgFile, streams),
+		newSendNewsCommand(cfgFile, streams),
+		newSendFileCommand(cfgFile, streams),
+		newSendCardCommand(cfgFile, streams),
+	)
+	return cmd
+}
+
+// newSendSubcommand creates a send subcommand that calls tool with the
+// arguments assembled by build
+func newSendSubcommand(cfgFile *string, streams IOStreams, use, short, tool string, build paramBuilder) *cobra.Command {
+	opts := &sendOptions{}
+	cmd := &cobra.Command{
+		Use:   use,
+		Short: short,
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runSend(cmd, *cfgFile, streams, tool, opts, build)
+		},
+	}
+	addSendFlags(cmd, opts)
+	return cmd
+}
+
+// addSendFlags adds the flags shared by all send subcommands
+func addSendFlags(cmd *cobra.Command, opts *sendOptions) {
+	cmd.Flags().StringVar(&opts.json, "json", "", "Tool arguments as a JSON object (\"-\" reads standard input)")
+	cmd.Flags().StringVar(&opts.jsonFile, "json-file", "", "File containing the tool arguments as a JSON object")
+	cmd.Flags().StringVar(&opts.priority, "priority", "", "Message priority: normal or urgent (urgent bypasses quiet hours)")
+	cmd.Flags().BoolVarP(&opts.yes, "yes", "y", false, "Approve the message when require_confirmation is enabled")
+}
+
+// runSend assembles the tool arguments, sends the message and reports the result
+func runSend(cmd *cobra.Command, cfgFile string, streams IOStreams, tool string, opts *sendOptions, build paramBuilder) error {
+	usageError := func(err error) error {
+		return reportResult(streams.Out, tool, "", err, ExitCodeUsage)
+	}
+
+	if content, _ := cmd.Flags().GetString("content"); content == stdinArg && (opts.json == stdinArg || opts.jsonFile == stdinArg) {
+		return usageError(fmt.Errorf("only one of --content and --json can read standard input"))
+	}
+
+	params, err := readJSONArgs(opts.json, opts.jsonFile, streams.In)
+	if err != nil {
+		return usageError(err)
+	}
+	if err := build(cmd, params, streams.In); err != nil {
+		return usageError(err)
+	}
+	if opts.priority != "" {
+		params["priority"] = opts.priority
+	}
+
+	cfg, err := loadOneShotConfig(cmd, cfgFile, streams)
+	if err != nil {
+		return usageError(err)
+	}
+	if cfg.RequireConfirmation {
+		if !opts.yes {
+			return usageError(fmt.Errorf("require_confirmation is enabled: review the message and pass --yes to send it"))
+		}
+		cfg.RequireConfirmation = false
+	}
+
+	server, err := newOneShotServer(cfg)
+	if err != nil {
+		return usageError(err)
+	}
+	defer server.Close()
+
+	if !server.HasTool(tool) {
+		return usageError(fmt.Errorf("tool %q is disabled by configuration", tool))
+	}
+
+	result, err := server.CallTool(tool, params)
+	return reportResult(streams.Out, tool, result, err, ExitCodeFailure)
+}
+
+// readJSONArgs reads the tool arguments given with --json or --json-file
+func readJSONArgs(inline, file string, in io.Reader) (map[string]any, error) {
+	params := make(map[string]any)
+	if inline != "" && file != "" {
+		return nil, fmt.Errorf("--json and --json-file are mutually exclusive")
+	}
+
+	var data []byte
+	switch {
+	case inline == stdinArg || file == stdinArg:
+		b, err := io.ReadAll(in)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read standard input: %w", err)
+		}
+		data = b
+	case file != "":
+		b, err := os.ReadFile(file)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read JSON file: %w", err)
+		}
+		data = b
+	case inline != "":
+		data = []byte(inline)
+	default:
+		return params, nil
+	}
+
+	if err := json.Unmarshal(data, &params); err != nil {
+		return nil, fmt.Errorf("invalid JSON arguments: %w", err)
+	}
+	if params == nil {
+		return nil, fmt.Errorf("invalid JSON arguments: expected an object")
+	}
+	return params, nil
+}
+
+// readContent returns value, or standard input when value is "-"
+func readContent(value string, in io.Reader) (string, error) {
+	if value != stdinArg {
+		return value, nil
+	}
+	b, err := io.ReadAll(in)
+	if err != nil {
+		return "", fmt.Errorf("failed to read standard input: %w", err)
+	}
+	return strings.TrimRight(string(b), "\n"), nil
+}
+
+// setStringFlag copies a string flag into params when it was set
+func setStringFlag(cmd *cobra.Command, params map[string]any, flag, key string) {
+	if cmd.Flags().Changed(flag) {
+		value, _ := cmd.Flags().GetString(flag)
+		params[key] = value
+	}
+}
+
+// setStringSliceFlag copies a string slice flag into params when it was set
+func setStringSliceFlag(cmd *cobra.Command, params map[string]any, flag, key string) {
+	if cmd.Flags().Changed(flag) {
+		values, _ := cmd.Flags().GetStringSlice(flag)
+		list := make([]any, len(values))
+		for i, v := range values {
+			list[i] = v
+		}
+		params[key] = list
+	}
+}
+
+// setContentFlag copies --content into params, reading standard input for "-"
+func setContentFlag(cmd *cobra.Command, params map[string]any, in io.Reader) error {
+	if !cmd.Flags().Changed("content") {
+		return nil
+	}
+	value, _ := cmd.Flags().GetString("content")
+	content, err := readContent(value, in)
+	if err != nil {
+		return err
+	}
+	params["content"] = content
+	return nil
+}
+
+// newSendTextCommand creates the send text command
+func newSendTextCommand(cfgFile *string, streams IOStreams) *cobra.Command {
+	cmd := newSendSubcommand(cfgFile, streams, "text", "Send a text message", "send_text", buildTextParams)
+	cmd.Flags().String("content", "", "Text content (\"-\" reads standard input)")
+	cmd.Flags().StringSlice("mention", nil, "Users to @mention: user IDs, or names and @teams with a user directory")
+	cmd.Flags().StringSlice("mention-mobile", nil, "Mobile numbers to @mention")
+	return cmd
+}
+
+// buildTextParams builds send_text arguments from flags
+func buildTextParams(cmd *cobra.Command, params map[string]any, in io.Reader) error {
+	setStringSliceFlag(cmd, params, "mention", "mentioned_list")
+	setStringSliceFlag(cmd, params, "mention-mobile", "mentioned_mobile_list")
+	return setContentFlag(cmd, params, in)
+}
+
+// newSendMarkdownCommand creates the send markdown command
+func newSendMarkdownCommand(cfgFile *string, streams IOStreams) *cobra.Command {
+	cmd := newSendSubcommand(cfgFile, streams, "markdown", "Send a markdown message", "send_markdown", buildMarkdownParams)
+	cmd.Flags().String("content", "", "Markdown content (\"-\" reads standard input)")
+	cmd.Flags().StringSlice("mention", nil, "Users to @mention: user IDs, or names and @teams with a user directory")
+	return cmd
+}
+
+// buildMarkdownParams builds send_markdown arguments from flags
+func buildMarkdownParams(cmd *cobra.Command, params map[string]any, in io.Reader) error {
+	setStringSliceFlag(cmd, params, "mention", "mentioned_list")
+	return setContentFlag(cmd, params, in)
+}
+
+// newSendImageCommand creates the send image command
+func newSendImageCommand(cfgFile *string, streams IOStreams) *cobra.Command {
+	cmd := newSendSubcommand(cfgFile, streams, "image", "Send a JPG or PNG image", "send_image", buildImageParams)
+	cmd.Flags().String("file", "", "Image file to send; base64 and md5 are computed from it")
+	return cmd
+}
+
+// buildImageParams builds send_image arguments from an image file
+func buildImageParams(cmd *cobra.Command, params map[string]any, _ io.Reader) error {
+	path, _ := cmd.Flags().GetString("file")
+	if path == "" {
+		return nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read image: %w", err)
+	}
+	sum := md5.Sum(data)
+	params["base64"] = base64.StdEncoding.EncodeToString(data)
+	params["md5"] = hex.EncodeToString(sum[:])
+	return nil
+}
+
+// newSendNewsCommand creates the send news command
+func newSendNewsCommand(cfgFile *string, streams IOStreams) *cobra.Command {
+	cmd := newSendSubcommand(cfgFile, streams, "news", "Send a news message with one or more articles", "send_news", buildNewsParams)
+	cmd.Flags().String("title", "", "Article title (use --json for several articles)")
+	cmd.Flags().String("description", "", "Article description")
+	cmd.Flags().String("url", "", "Article link URL")
+	cmd.Flags().String("picurl", "", "Article cover image URL")
+	return cmd
+}
+
+// buildNewsParams builds a single-article send_news message from flags
+func buildNewsParams(cmd *cobra.Command, params map[string]any, _ io.Reader) error {
+	if !cmd.Flags().Changed("title") {
+		return nil
+	}
+	article := make(map[string]any)
+	setStringFlag(cmd, article, "title", "title")
+	setStringFlag(cmd, article, "description", "description")
+	setStringFlag(cmd, article, "url", "url")
+	setStringFlag(cmd, article, "picurl", "picurl")
+	params["articles"] = []any{article}
+	return nil
+}
+
+// newSendFileCommand creates the send file command
+func newSendFileCommand(cfgFile *string, streams IOStreams) *cobra.Command {
+	cmd := newSendSubcommand(cfgFile, streams, "file", "Upload a file and send it as a file message", "send_file", buildFileParams)
+	cmd.Flags().String("file", "", "File to upload and send")
+	cmd.Flags().String("filename", "", "Name to upload the file as (defaults to the file's base name)")
+	cmd.Flags().String("media-id", "", "media_id of an uploaded file to send instead of --file")
+	return cmd
+}
+
+// buildFileParams builds send_file arguments from a file or media_id
+func buildFileParams(cmd *cobra.Command, params map[string]any, _ io.Reader) error {
+	setStringFlag(cmd, params, "media-id", "media_id")
+	setStringFlag(cmd, params, "filename", "filename")
+	path, _ := cmd.Flags().GetString("file")
+	if path == "" {
+		return nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read file: %w", err)
+	}
+	params["base64_data"] = base64.StdEncoding.EncodeToString(data)
+	if _, ok := params["filename"]; !ok {
+		params["filename"] = filepath.Base(path)
+	}
+	return nil
+}
+
+// newSendCardCommand creates the send card command
+func newSendCardCommand(cfgFile *string, streams IOStreams) *cobra.Command {
+	var cardType string
+	opts := &sendOptions{}
+	cmd := &cobra.Command{
+		Use:   "card",
+		Short: "Send a text notice or news notice template card",
+		Long: `Send a template card. Simple cards can be built with flags; use --json for
+the full set of card fields accepted by the send_text_notice_card and
+send_news_notice_card tools.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			var tool string
+			switch cardType {
+			case "text":
+				tool = "send_text_notice_card"
+			case "news":
+				tool = "send_news_notice_card"
+			default:
+				err := fmt.Errorf("--type must be text or news, got %q", cardType)
+				return reportResult(streams.Out, "", "", err, ExitCodeUsage)
+			}
+			return runSend(cmd, *cfgFile, streams, tool, opts, buildCardParams)
+		},
+	}
+	addSendFlags(cmd, opts)
+	cmd.Flags().StringVar(&cardType, "type", "text", "Card type: text or news")
+	cmd.Flags().String("title", "", "Card main title")
+	cmd.Flags().String("desc", "", "Card main title description")
+	cmd.Flags().String("url", "", "URL opened when the card is clicked")
+	cmd.Flags().String("image-url", "", "Card image URL (news cards)")
+	return cmd
+}
+
+// buildCardParams builds template card arguments from flags, merging the
+// click URL into any card_action given as JSON
+func buildCardParams(cmd *cobra.Command, params map[string]any, _ io.Reader) error {
+	setStringFlag(cmd, params, "title", "main_title")
+	setStringFlag(cmd, params, "desc", "main_title_desc")
+	setStringFlag(cmd, params, "image-url", "card_image_url")
+	if cmd.Flags().Changed("url") {
+		action, _ := params["card_action"].(map[string]any)
+		if action == nil {
+			action = make(map[string]any)
+		}
+		setStringFlag(cmd, action, "url", "url")
+		params["card_action"] = action
+	}
+	return nil
+}
@@ -0,0 +1,273 @@
+package cmd
+
+import (
+	"bytes"
+	"crypto/md5"
+	"encoding/hex"
+	"io"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+
+	"github.com/futuretea/wecom-bot-mcp-server/pkg/fakewecom"
+)
+
+func TestReadJSONArgs(t *testing.T) {
+	params, err := readJSONArgs(`{"content":"hi"}`, "", nil)
+	if err != nil || params["content"] != "hi" {
+		t.Fatalf("expected inline JSON to be parsed, got %v, %v", params, err)
+	}
+
+	params, err = readJSONArgs(stdinArg, "", strings.NewReader(`{"content":"from stdin"}`))
+	if err != nil || params["content"] != "from stdin" {
+		t.Fatalf("expected stdin JSON to be parsed, got %v, %v", params, err)
+	}
+
+	path := filepath.Join(t.TempDir(), "args.json")
+	if err := os.WriteFile(path, []byte(`{"content":"from file"}`), 0o600); err != nil {
+		t.Fatalf("failed to write args file: %v", err)
+	}
+	params, err = readJSONArgs("", path, nil)
+	if err != nil || params["content"] != "from file" {
+		t.Fatalf("expected JSON file to be parsed, got %v, %v", params, err)
+	}
+
+	params, err = readJSONArgs("", "", nil)
+	if err != nil || len(params) != 0 {
+		t.Fatalf("expected empty arguments, got %v, %v", params, err)
+	}
+
+	for _, bad := range []string{`[1,2]`, `null`, `{`} {
+		if _, err := readJSONArgs(bad, "", nil); err == nil {
+			t.Fatalf("expected error for %s", bad)
+		}
+	}
+	if _, err := readJSONArgs("{}", path, nil); err == nil {
+		t.Fatal("expected error when both --json and --json-file are set")
+	}
+}
+
+// buildParams parses args with cmd's flags and runs build without loading
+// configuration or sending anything.
+func buildParams(t *testing.T, cmd *cobra.Command, build paramBuilder, stdin string, args ...string) map[string]any {
+	t.Helper()
+	if err := cmd.ParseFlags(args); err != nil {
+		t.Fatalf("failed to parse flags: %v", err)
+	}
+	params := make(map[string]any)
+	if err := build(cmd, params, strings.NewReader(stdin)); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	return params
+}
+
+func TestSendParamBuilders(t *testing.T) {
+	streams := IOStreams{In: strings.NewReader(""), Out: &bytes.Buffer{}, ErrOut: &bytes.Buffer{}}
+	cfgFile := ""
+
+	params := buildParams(t, newSendTextCommand(&cfgFile, streams), buildTextParams, "piped text\n",
+		"--content", "-", "--mention", "zhangsan,lisi")
+	want := map[string]any{"content": "piped text", "mentioned_list": []any{"zhangsan", "lisi"}}
+	if !reflect.DeepEqual(params, want) {
+		t.Fatalf("unexpected text params: %v", params)
+	}
+
+	imagePath := filepath.Join(t.TempDir(), "chart.png")
+	if err := os.WriteFile(imagePath, []byte("png"), 0o600); err != nil {
+		t.Fatalf("failed to write image: %v", err)
+	}
+	params = buildParams(t, newSendImageCommand(&cfgFile, streams), buildImageParams, "", "--file", imagePath)
+	sum := md5.Sum([]byte("png"))
+	if params["base64"] != "cG5n" || params["md5"] != hex.EncodeToString(sum[:]) {
+		t.Fatalf("unexpected image params: %v", params)
+	}
+
+	params = buildParams(t, newSendFileCommand(&cfgFile, streams), buildFileParams, "", "--file", imagePath)
+	if params["filename"] != "chart.png" || params["base64_data"] != "cG5n" {
+		t.Fatalf("unexpected file params: %v", params)
+	}
+
+	params = buildParams(t, newSendNewsCommand(&cfgFile, streams), buildNewsParams, "",
+		"--title", "Release", "--url", "https://example.com")
+	articles, _ := params["articles"].([]any)
+	if len(articles) != 1 || !reflect.DeepEqual(articles[0], map[string]any{"title": "Release", "url": "https://example.com"}) {
+		t.Fatalf("unexpected news params: %v", params)
+	}
+
+	params = map[string]any{"card_action": map[string]any{"appid": "x"}}
+	cardCmd := newSendCardCommand(&cfgFile, streams)
+	if err := cardCmd.ParseFlags([]string{"--title", "Deploy", "--url", "https://example.com"}); err != nil {
+		t.Fatalf("failed to parse flags: %v", err)
+	}
+	if err := buildCardParams(cardCmd, params, nil); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	wantAction := map[string]any{"appid": "x", "url": "https://example.com"}
+	if params["main_title"] != "Deploy" || !reflect.DeepEqual(params["card_action"], wantAction) {
+		t.Fatalf("unexpected card params: %v", params)
+	}
+}
+
+func TestSendCommand_FakeWeCom(t *testing.T) {
+	viper.Reset()
+	t.Cleanup(viper.Reset)
+
+	const key = "693a91f6-7abc-4bc4-97a0-0ec2cafe5aaa"
+	fake := fakewecom.New(fakewecom.Options{Keys: []string{key}})
+	server := httptest.NewServer(fake)
+	defer server.Close()
+	t.Setenv("WECOM_MCP_WECOM_BOT_KEY", key)
+	t.Setenv("WECOM_MCP_WECOM_API_BASE_URL", server.URL)
+
+	var out bytes.Buffer
+	cmd := NewMCPServer(IOStreams{In: strings.NewReader(""), Out: &out, ErrOut: io.Discard})
+	cmd.SetArgs([]string{"send", "text", "--content", "Backup finished", "--mention", "zhangsan"})
+	if err := cmd.Execute(); err != nil {
+		t.Fatalf("unexpected error: %v\n%s", err, out.String())
+	}
+	if !strings.Contains(out.String(), `"ok": true`) {
+		t.Fatalf("unexpected output: %s", out.String())
+	}
+
+	messages := fake.Messages()
+	if len(messages) != 1 {
+		t.Fatalf("expected 1 message, got %+v", messages)
+	}
+	text, _ := messages[0].Payload["text"].(map[string]any)
+	if text["content"] != "Backup finished" || !reflect.DeepEqual(text["mentioned_list"], []any{"zhangsan"}) {
+		t.Fatalf("unexpected message: %+v", messages[0].Payload)
+	}
+}
+
+func TestSendSubcommands_Dispatch(t *testing.T) {
+	const key = "693a91f6-7abc-4bc4-97a0-0ec2cafe5aaa"
+	fake := fakewecom.New(fakewecom.Options{Keys: []string{key}})
+	server := httptest.NewServer(fake)
+	defer server.Close()
+	t.Setenv("WECOM_MCP_WECOM_BOT_KEY", key)
+	t.Setenv("WECOM_MCP_WECOM_API_BASE_URL", server.URL)
+
+	dir := t.TempDir()
+	imagePath := filepath.Join(dir, "chart.png")
+	if err := os.WriteFile(imagePath, []byte("\x89PNG\r\n\x1a\nfake image"), 0o600); err != nil {
+		t.Fatalf("failed to write image: %v", err)
+	}
+	reportPath := filepath.Join(dir, "report.csv")
+	if err := os.WriteFile(reportPath, []byte("name,value\nuptime,99.9\n"), 0o600); err != nil {
+		t.Fatalf("failed to write file: %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		args     []string
+		wantTool string
+		// check inspects the msgtype and body of the message WeCom received
+		check func(t *testing.T, msgType string, body map[string]any)
+	}{
+		{
+			name:     "text",
+			args:     []string{"text", "--content", "Backup finished", "--mention", "zhangsan"},
+			wantTool: "send_text",
+			check: func(t *testing.T, msgType string, body map[string]any) {
+				if msgType != "text" || body["content"] != "Backup finished" || !reflect.DeepEqual(body["mentioned_list"], []any{"zhangsan"}) {
+					t.Fatalf("unexpected %s message: %v", msgType, body)
+				}
+			},
+		},
+		{
+			name:     "markdown",
+			args:     []string{"markdown", "--json", `{"content":"**disk** full"}`, "--priority", "urgent"},
+			wantTool: "send_markdown",
+			check: func(t *testing.T, msgType string, body map[string]any) {
+				if msgType != "markdown" || body["content"] != "**disk** full" {
+					t.Fatalf("unexpected %s message: %v", msgType, body)
+				}
+			},
+		},
+		{
+			name:     "image",
+			args:     []string{"image", "--file", imagePath},
+			wantTool: "send_image",
+			check: func(t *testing.T, msgType string, body map[string]any) {
+				if msgType != "image" || body["md5"] == "" {
+					t.Fatalf("unexpected %s message: %v", msgType, body)
+				}
+			},
+		},
+		{
+			name:     "news",
+			args:     []string{"news", "--title", "Release v1.2", "--url", "https://example.com/releases/v1.2"},
+			wantTool: "send_news",
+			check: func(t *testing.T, msgType string, body map[string]any) {
+				articles, _ := body["articles"].([]any)
+				if msgType != "news" || len(articles) != 1 {
+					t.Fatalf("unexpected %s message: %v", msgType, body)
+				}
+			},
+		},
+		{
+			name:     "file",
+			args:     []string{"file", "--file", reportPath},
+			wantTool: "send_file",
+			check: func(t *testing.T, msgType string, body map[string]any) {
+				uploads := fake.Uploads()
+				if len(uploads) != 1 || uploads[0].Filename != "report.csv" {
+					t.Fatalf("expected the file to be uploaded, got %+v", uploads)
+				}
+				if msgType != "file" || body["media_id"] != uploads[0].MediaID {
+					t.Fatalf("unexpected %s message: %v", msgType, body)
+				}
+			},
+		},
+		{
+			name:     "text card",
+			args:     []string{"card", "--title", "Deploy done", "--url", "https://ci.example.com/42"},
+			wantTool: "send_text_notice_card",
+			check: func(t *testing.T, msgType string, body map[string]any) {
+				if msgType != "template_card" || body["card_type"] != "text_notice" {
+					t.Fatalf("unexpected %s message: %v", msgType, body)
+				}
+			},
+		},
+		{
+			name:     "news card",
+			args:     []string{"card", "--type", "news", "--title", "Release", "--image-url", "https://example.com/a.png", "--url", "https://example.com"},
+			wantTool: "send_news_notice_card",
+			check: func(t *testing.T, msgType string, body map[string]any) {
+				if msgType != "template_card" || body["card_type"] != "news_notice" {
+					t.Fatalf("unexpected %s message: %v", msgType, body)
+				}
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			viper.Reset()
+			t.Cleanup(viper.Reset)
+			fake.Reset()
+
+			var out bytes.Buffer
+			cmd := NewMCPServer(IOStreams{In: strings.NewReader(""), Out: &out, ErrOut: io.Discard})
+			cmd.SetArgs(append([]string{"send"}, tt.args...))
+			if err := cmd.Execute(); err != nil {
+				t.Fatalf("unexpected error: %v\n%s", err, out.String())
+			}
+			if !strings.Contains(out.String(), `"tool": "`+tt.wantTool+`"`) || !strings.Contains(out.String(), `"ok": true`) {
+				t.Fatalf("expected %s to succeed, got %s", tt.wantTool, out.String())
+			}
+
+			messages := fake.Messages()
+			if len(messages) != 1 {
+				t.Fatalf("expected 1 message, got %+v", messages)
+			}
+			body, _ := messages[0].Payload[messages[0].MsgType].(map[string]any)
+			tt.check(t, messages[0].MsgType, body)
+		})
+	}
+}
@@ -0,0 +1,228 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"slices"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/spf13/cobra"
+
+	"github.com/futuretea/wecom-bot-mcp-server/pkg/core/config"
+	mcpserver "github.com/futuretea/wecom-bot-mcp-server/pkg/server/mcp"
+)
+
+// Schema output formats
+const (
+	formatJSON     = "json"
+	formatMarkdown = "markdown"
+)
+
+// newToolsCommand creates the tools command and its subcommands
+func newToolsCommand(cfgFile *string, streams IOStreams) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "tools",
+		Short: "List tools and export their schemas",
+		Long: `List the tools the server exposes and export their JSON schemas.
+
+Only tools enabled by enabled_tools and disabled_tools are included. A bot key
+is not required.`,
+	}
+
+	cmd.AddCommand(newToolsListCommand(cfgFile, streams), newToolsSchemaCommand(cfgFile, streams))
+	return cmd
+}
+
+// newToolsListCommand creates the tools list command
+func newToolsListCommand(cfgFile *string, streams IOStreams) *cobra.Command {
+	return &cobra.Command{
+		Use:   "list",
+		Short: "List enabled tools with their descriptions",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			tools, err := loadTools(*cfgFile)
+			if err != nil {
+				return err
+			}
+
+			w := tabwriter.NewWriter(streams.Out, 0, 4, 2, ' ', 0)
+			fmt.Fprintln(w, "NAME\tDESCRIPTION")
+			for _, tool := range tools {
+				fmt.Fprintf(w, "%s\t%s\n", tool.Name, tool.Description)
+			}
+			return w.Flush()
+		},
+	}
+}
+
+// newToolsSchemaCommand creates the tools schema command
+func newToolsSchemaCommand(cfgFile *string, streams IOStreams) *cobra.Command {
+	var format string
+
+	cmd := &cobra.Command{
+		Use:   "schema",
+		Short: "Print the input and output schemas of enabled tools",
+		Long: `Print the name, description and input/output schemas of every enabled tool.
+
+The json format matches the tools/list response of the MCP server. The markdown
+format renders the same <details> blocks and parameter tables as the README.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if format != formatJSON && format != formatMarkdown {
+				return fmt.Errorf("--format must be %s or %s, got %q", formatJSON, formatMarkdown, format)
+			}
+
+			tools, err := loadTools(*cfgFile)
+			if err != nil {
+				return err
+			}
+			if format == formatJSON {
+				return writeJSON(streams.Out, map[string]any{"tools": tools})
+			}
+			return writeToolsMarkdown(streams.Out, tools)
+		},
+	}
+
+	cmd.Flags().StringVar(&format, "format", formatJSON, "Output format: json or markdown")
+	return cmd
+}
+
+// loadTools returns the tools enabled by the configuration
+func loadTools(cfgFile string) ([]mcp.Tool, error) {
+	cfg, err := config.ReadConfig(cfgFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load config: %w", err)
+	}
+
+	var tools []mcp.Tool
+	for _, tool := range mcpserver.AvailableTools(cfg) {
+		tools = append(tools, tool.Tool)
+	}
+	return tools, nil
+}
+
+// schemaRow is one row of a markdown parameter table
+type schemaRow struct {
+	name        string
+	typ         string
+	required    bool
+	description string
+}
+
+// writeToolsMarkdown renders tools as README-style <details> blocks
+func writeToolsMarkdown(out io.Writer, tools []mcp.Tool) error {
+	var sb strings.Builder
+	for i, tool := range tools {
+		if i > 0 {
+			sb.WriteString("\n")
+		}
+		fmt.Fprintf(&sb, "<details>\n<summary>%s</summary>\n\n", tool.Name)
+
+		inputRows := schemaRows("", tool.InputSchema.Properties, tool.InputSchema.Required)
+		sb.WriteString(tool.Description)
+		if len(inputRows) == 0 {
+			sb.WriteString(" Takes no parameters.")
+		}
+		sb.WriteString("\n")
+		writeSchemaTable(&sb, "Parameter", inputRows)
+
+		if outputRows := schemaRows("", tool.OutputSchema.Properties, tool.OutputSchema.Required); len(outputRows) > 0 {
+			sb.WriteString("\n**Output:**\n")
+			writeSchemaTable(&sb, "Field", outputRows)
+		}
+		sb.WriteString("\n</details>\n")
+	}
+	_, err := io.WriteString(out, sb.String())
+	return err
+}
+
+// writeSchemaTable writes a markdown table of schema rows
+func writeSchemaTable(sb *strings.Builder, heading string, rows []schemaRow) {
+	if len(rows) == 0 {
+		return
+	}
+	fmt.Fprintf(sb, "\n| %s | Type | Required | Description |\n", heading)
+	fmt.Fprintf(sb, "|%s|------|----------|-------------|\n", strings.Repeat("-", len(heading)+2))
+	for _, row := range rows {
+		required := "No"
+		if row.required {
+			required = "Yes"
+		}
+		description := strings.ReplaceAll(row.description, "|", "\\|")
+		fmt.Fprintf(sb, "| `%s` | %s | %s | %s |\n", row.name, row.typ, required, description)
+	}
+}
+
+// schemaRows flattens JSON schema properties into table rows. Nested object
+// fields are named "parent.field" and array item fields "parent[].field".
+// Required properties come first, in declaration order, followed by optional
+// properties sorted by name, since property maps do not keep their order.
+func schemaRows(prefix string, properties map[string]any, required []string) []schemaRow {
+	var names []string
+	for _, name := range required {
+		if _, ok := properties[name]; ok {
+			names = append(names, name)
+		}
+	}
+	var optional []string
+	for name := range properties {
+		if !slices.Contains(required, name) {
+			optional = append(optional, name)
+		}
+	}
+	sort.Strings(optional)
+	names = append(names, optional...)
+
+	var rows []schemaRow
+	for _, name := range names {
+		prop, _ := properties[name].(map[string]any)
+		typ, _ := prop["type"].(string)
+		description, _ := prop["description"].(string)
+		row := schemaRow{
+			name:        prefix + name,
+			typ:         typ,
+			required:    slices.Contains(required, name),
+			description: description,
+		}
+
+		var nested []schemaRow
+		switch typ {
+		case "array":
+			items, _ := prop["items"].(map[string]any)
+			itemType, _ := items["type"].(string)
+			if itemType != "" {
+				row.typ = itemType + "[]"
+			}
+			if itemProps, ok := items["properties"].(map[string]any); ok {
+				nested = schemaRows(row.name+"[].", itemProps, toStrings(items["required"]))
+			}
+		case "object":
+			if objectProps, ok := prop["properties"].(map[string]any); ok {
+				nested = schemaRows(row.name+".", objectProps, toStrings(prop["required"]))
+			}
+		}
+		rows = append(rows, row)
+		rows = append(rows, nested...)
+	}
+	return rows
+}
+
+// toStrings converts a JSON schema "required" value to a string slice
+func toStrings(value any) []string {
+	switch v := value.(type) {
+	case []string:
+		return v
+	case []any:
+		var result []string
+		for _, item := range v {
+			if s, ok := item.(string); ok {
+				result = append(result, s)
+			}
+		}
+		return result
+	}
+	return nil
+}
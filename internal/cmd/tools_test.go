@@ -0,0 +1,88 @@
+package cmd
+
+import (
+	"bytes"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+
+	wecomToolset "github.com/futuretea/wecom-bot-mcp-server/pkg/toolset/wecom"
+)
+
+func TestSchemaRows(t *testing.T) {
+	tool := mcp.NewTool("send_example",
+		mcp.WithString("zeta", mcp.Description("Optional | piped")),
+		mcp.WithString("content", mcp.Required(), mcp.Description("Content")),
+		mcp.WithArray("items",
+			mcp.Items(map[string]any{
+				"type": "object",
+				"properties": map[string]any{
+					"url":   map[string]any{"type": "string"},
+					"title": map[string]any{"type": "string"},
+				},
+				"required": []string{"title"},
+			}),
+		),
+		mcp.WithObject("action", mcp.Properties(map[string]any{
+			"url": map[string]any{"type": "string"},
+		})),
+	)
+
+	rows := schemaRows("", tool.InputSchema.Properties, tool.InputSchema.Required)
+	var got []string
+	for _, row := range rows {
+		got = append(got, row.name+":"+row.typ)
+	}
+	want := "content:string action:object action.url:string items:object[] items[].title:string items[].url:string zeta:string"
+	if strings.Join(got, " ") != want {
+		t.Fatalf("unexpected rows:\n got: %s\nwant: %s", strings.Join(got, " "), want)
+	}
+	if !rows[0].required || rows[1].required || !rows[4].required {
+		t.Fatalf("unexpected required flags: %+v", rows)
+	}
+}
+
+func TestWriteToolsMarkdown(t *testing.T) {
+	tools := []mcp.Tool{
+		mcp.NewTool("send_text", mcp.WithDescription("Send text."),
+			mcp.WithString("content", mcp.Required(), mcp.Description("Text | content"))),
+		mcp.NewTool("list_scheduled", mcp.WithDescription("List jobs.")),
+	}
+
+	var out bytes.Buffer
+	if err := writeToolsMarkdown(&out, tools); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	for _, want := range []string{
+		"<details>\n<summary>send_text</summary>\n\nSend text.\n\n| Parameter | Type | Required | Description |\n",
+		"| `content` | string | Yes | Text \\| content |\n\n</details>\n",
+		"<summary>list_scheduled</summary>\n\nList jobs. Takes no parameters.\n\n</details>\n",
+	} {
+		if !strings.Contains(out.String(), want) {
+			t.Fatalf("expected output to contain %q, got:\n%s", want, out.String())
+		}
+	}
+}
+
+// TestREADMEToolsMatchSchema keeps the tool sections of the README in sync
+// with tools schema --format markdown. Notes and examples may follow the
+// generated description and tables.
+func TestREADMEToolsMatchSchema(t *testing.T) {
+	readme, err := os.ReadFile("../../README.md")
+	if err != nil {
+		t.Fatalf("failed to read README: %v", err)
+	}
+	for _, serverTool := range (&wecomToolset.Toolset{}).GetTools(nil) {
+		tool := serverTool.Tool
+		var out bytes.Buffer
+		if err := writeToolsMarkdown(&out, []mcp.Tool{tool}); err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		section := strings.TrimSuffix(out.String(), "\n</details>\n")
+		if !strings.Contains(string(readme), section) {
+			t.Errorf("README section of %s is out of date; regenerate it with tools schema --format markdown:\n%s", tool.Name, section)
+		}
+	}
+}
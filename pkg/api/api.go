@@ -0,0 +1,245 @@
+// Package api serves a versioned REST API for callers that do not speak MCP.
+// Requests are dispatched to the same tool handlers as MCP tool calls, so the
+// send policies and message history apply, and responses carry the tool's
+// CallToolResult as JSON.
+package api
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+
+	"github.com/futuretea/wecom-bot-mcp-server/pkg/core/config"
+	"github.com/futuretea/wecom-bot-mcp-server/pkg/core/logging"
+	wecomToolset "github.com/futuretea/wecom-bot-mcp-server/pkg/toolset/wecom"
+)
+
+// Endpoints of the REST API
+const (
+	messagesEndpoint = "/api/v1/messages"
+	mediaEndpoint    = "/api/v1/media"
+	botsEndpoint     = "/api/v1/bots"
+	openAPIEndpoint  = "/api/v1/openapi.json"
+)
+
+// sendToolPrefix is the prefix of the tools that send a message type
+const sendToolPrefix = "send_"
+
+// uploadTool is the tool behind the media endpoint
+const uploadTool = "upload_file"
+
+// Request body limits. A media upload holds a file of up to 20MB, which is
+// larger once base64-encoded.
+const (
+	maxMessageBytes = 4 << 20
+	maxMediaBytes   = 32 << 20
+)
+
+// Server runs the tools behind the REST API.
+type Server interface {
+	// GetEnabledTools returns the names of the enabled tools, in registration order.
+	GetEnabledTools() []string
+
+	// ToolDefinition returns the definition of an enabled tool.
+	ToolDefinition(name string) (mcp.Tool, bool)
+
+	// InvokeTool runs a tool through the same handler as MCP requests.
+	InvokeTool(ctx context.Context, name string, args map[string]any) (*mcp.CallToolResult, error)
+}
+
+// MessageRequest is the body of POST /api/v1/messages
+type MessageRequest struct {
+	// Type is the message type, the name of its send tool without "send_"
+	Type string `json:"type"`
+	// Bot is the bot to send with. Empty uses the default bot.
+	Bot string `json:"bot,omitempty"`
+	// Params are the arguments of the send tool
+	Params map[string]any `json:"params"`
+}
+
+// Bot describes a bot in GET /api/v1/bots
+type Bot struct {
+	Name         string   `json:"name"`
+	MessageTypes []string `json:"message_types"`
+	MediaUpload  bool     `json:"media_upload"`
+}
+
+// errorResponse is the body of a request that did not reach a tool
+type errorResponse struct {
+	Error string `json:"error"`
+}
+
+// Handler serves the REST API.
+type Handler struct {
+	cfg     config.API
+	server  Server
+	openAPI []byte
+}
+
+// Register adds the REST API to mux when it is enabled.
+func Register(mux *http.ServeMux, cfg config.API, server Server) error {
+	if !cfg.Enabled {
+		return nil
+	}
+	h, err := NewHandler(cfg, server)
+	if err != nil {
+		return err
+	}
+	mux.HandleFunc("POST "+messagesEndpoint, h.authorize(h.sendMessage))
+	mux.HandleFunc("POST "+mediaEndpoint, h.authorize(h.uploadMedia))
+	mux.HandleFunc("GET "+botsEndpoint, h.authorize(h.listBots))
+	mux.HandleFunc("GET "+openAPIEndpoint, h.serveOpenAPI)
+	if cfg.Token == "" {
+		logging.Warn("REST API enabled at /api/v1 without a token; any caller can send messages")
+	} else {
+		logging.Info("REST API enabled at /api/v1")
+	}
+	return nil
+}
+
+// NewHandler creates the REST API handler and generates its OpenAPI document
+// from the tool schemas.
+func NewHandler(cfg config.API, server Server) (*Handler, error) {
+	h := &Handler{cfg: cfg, server: server}
+	document, err := json.Marshal(h.openAPIDocument())
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate OpenAPI document: %w", err)
+	}
+	h.openAPI = document
+	return h, nil
+}
+
+// messageTypes returns the message types of the enabled send tools
+func (h *Handler) messageTypes() []string {
+	var types []string
+	for _, name := range h.server.GetEnabledTools() {
+		if messageType, ok := strings.CutPrefix(name, sendToolPrefix); ok {
+			types = append(types, messageType)
+		}
+	}
+	return types
+}
+
+// authorize rejects requests without the configured bearer token
+func (h *Handler) authorize(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if h.cfg.Token != "" {
+			presented, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+			if !ok || subtle.ConstantTimeCompare([]byte(presented), []byte(h.cfg.Token)) != 1 {
+				logging.Warn("API request %s %s from %s rejected: missing or invalid bearer token", r.Method, r.URL.Path, r.RemoteAddr)
+				writeError(w, http.StatusUnauthorized, "missing or invalid bearer token")
+				return
+			}
+		}
+		next(w, r)
+	}
+}
+
+// sendMessage handles POST /api/v1/messages
+func (h *Handler) sendMessage(w http.ResponseWriter, r *http.Request) {
+	var req MessageRequest
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxMessageBytes)).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Sprintf("invalid request body: %v", err))
+		return
+	}
+	if req.Bot != "" && req.Bot != wecomToolset.DefaultBotName {
+		writeError(w, http.StatusNotFound, fmt.Sprintf("unknown bot %q; the only bot is %q", req.Bot, wecomToolset.DefaultBotName))
+		return
+	}
+	tool := sendToolPrefix + req.Type
+	if _, ok := h.server.ToolDefinition(tool); !ok {
+		writeError(w, http.StatusBadRequest, fmt.Sprintf("unknown message type %q; available types: %s", req.Type, strings.Join(h.messageTypes(), ", ")))
+		return
+	}
+	if req.Params == nil {
+		req.Params = map[string]any{}
+	}
+	h.invoke(w, r, tool, req.Params)
+}
+
+// uploadMedia handles POST /api/v1/media. The file is sent either as the
+// "file" part of a multipart form or as upload_file arguments in JSON.
+func (h *Handler) uploadMedia(w http.ResponseWriter, r *http.Request) {
+	if _, ok := h.server.ToolDefinition(uploadTool); !ok {
+		writeError(w, http.StatusNotFound, fmt.Sprintf("media upload is disabled because the %s tool is disabled", uploadTool))
+		return
+	}
+	r.Body = http.MaxBytesReader(w, r.Body, maxMediaBytes)
+
+	var params map[string]any
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if mediaType == "multipart/form-data" {
+		file, header, err := r.FormFile("file")
+		if err != nil {
+			writeError(w, http.StatusBadRequest, fmt.Sprintf("invalid multipart upload: %v", err))
+			return
+		}
+		defer file.Close()
+		data, err := io.ReadAll(file)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, fmt.Sprintf("failed to read file: %v", err))
+			return
+		}
+		filename := r.FormValue("filename")
+		if filename == "" {
+			filename = header.Filename
+		}
+		params = map[string]any{"filename": filename, "base64_data": base64.StdEncoding.EncodeToString(data)}
+	} else if err := json.NewDecoder(r.Body).Decode(&params); err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Sprintf("invalid request body: %v", err))
+		return
+	}
+	h.invoke(w, r, uploadTool, params)
+}
+
+// listBots handles GET /api/v1/bots
+func (h *Handler) listBots(w http.ResponseWriter, r *http.Request) {
+	_, upload := h.server.ToolDefinition(uploadTool)
+	bots := []Bot{{Name: wecomToolset.DefaultBotName, MessageTypes: h.messageTypes(), MediaUpload: upload}}
+	writeJSON(w, http.StatusOK, map[string]any{"bots": bots})
+}
+
+// serveOpenAPI handles GET /api/v1/openapi.json
+func (h *Handler) serveOpenAPI(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	_, _ = w.Write(h.openAPI)
+}
+
+// invoke runs a tool and writes its CallToolResult: status 200 when the tool
+// succeeds and 422 when it returns an error result
+func (h *Handler) invoke(w http.ResponseWriter, r *http.Request, tool string, params map[string]any) {
+	result, err := h.server.InvokeTool(r.Context(), tool, params)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	status := http.StatusOK
+	outcome := "succeeded"
+	if result.IsError {
+		status = http.StatusUnprocessableEntity
+		outcome = "failed"
+	}
+	logging.Info("API call to %s from %s %s", tool, r.RemoteAddr, outcome)
+	writeJSON(w, status, result)
+}
+
+// writeError writes a request error
+func writeError(w http.ResponseWriter, status int, message string) {
+	writeJSON(w, status, errorResponse{Error: message})
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	encoder := json.NewEncoder(w)
+	encoder.SetEscapeHTML(false)
+	_ = encoder.Encode(v)
+}
@@ -0,0 +1,260 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+
+	"github.com/futuretea/wecom-bot-mcp-server/pkg/core/config"
+	wecomToolset "github.com/futuretea/wecom-bot-mcp-server/pkg/toolset/wecom"
+)
+
+// fakeServer serves the real tool definitions and records tool calls
+type fakeServer struct {
+	tools  map[string]mcp.Tool
+	names  []string
+	calls  []toolCall
+	failed bool
+}
+
+type toolCall struct {
+	tool string
+	args map[string]any
+}
+
+// newFakeServer enables every tool except the disabled ones
+func newFakeServer(disabled ...string) *fakeServer {
+	s := &fakeServer{tools: make(map[string]mcp.Tool)}
+	for _, tool := range (&wecomToolset.Toolset{}).GetTools(nil) {
+		if !contains(disabled, tool.Tool.Name) {
+			s.tools[tool.Tool.Name] = tool.Tool
+			s.names = append(s.names, tool.Tool.Name)
+		}
+	}
+	return s
+}
+
+func contains(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *fakeServer) GetEnabledTools() []string { return s.names }
+
+func (s *fakeServer) ToolDefinition(name string) (mcp.Tool, bool) {
+	tool, ok := s.tools[name]
+	return tool, ok
+}
+
+func (s *fakeServer) InvokeTool(_ context.Context, name string, args map[string]any) (*mcp.CallToolResult, error) {
+	if _, ok := s.tools[name]; !ok {
+		return nil, fmt.Errorf("tool %q is not available", name)
+	}
+	s.calls = append(s.calls, toolCall{tool: name, args: args})
+	if s.failed {
+		return &mcp.CallToolResult{IsError: true, Content: []mcp.Content{mcp.NewTextContent("content is required")}}, nil
+	}
+	return &mcp.CallToolResult{Content: []mcp.Content{mcp.NewTextContent("sent")}}, nil
+}
+
+// serve registers the API and sends a request
+func serve(t *testing.T, cfg config.API, server Server, req *http.Request) *httptest.ResponseRecorder {
+	t.Helper()
+	mux := http.NewServeMux()
+	if err := Register(mux, cfg, server); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, req)
+	return rec
+}
+
+func jsonRequest(method, path, token, body string) *http.Request {
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	return req
+}
+
+func TestSendMessage(t *testing.T) {
+	server := newFakeServer()
+	cfg := config.API{Enabled: true, Token: "s3cret"}
+	rec := serve(t, cfg, server, jsonRequest(http.MethodPost, "/api/v1/messages", "s3cret",
+		`{"type": "chart", "bot": "default", "params": {"type": "bar", "title": "Latency"}}`))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rec.Code, rec.Body)
+	}
+	if len(server.calls) != 1 || server.calls[0].tool != "send_chart" || server.calls[0].args["type"] != "bar" {
+		t.Fatalf("expected the chart arguments to reach send_chart, got %+v", server.calls)
+	}
+
+	var result struct {
+		Content []mcp.TextContent `json:"content"`
+		IsError bool              `json:"isError"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &result); err != nil {
+		t.Fatalf("expected a CallToolResult, got %s", rec.Body)
+	}
+	if result.IsError || len(result.Content) != 1 || result.Content[0].Text != "sent" {
+		t.Fatalf("unexpected result %+v", result)
+	}
+}
+
+func TestSendMessage_Errors(t *testing.T) {
+	cfg := config.API{Enabled: true, Token: "s3cret"}
+	tests := []struct {
+		name       string
+		token      string
+		body       string
+		failed     bool
+		wantStatus int
+		wantCall   bool
+	}{
+		{name: "tool error", token: "s3cret", body: `{"type": "text", "params": {}}`, failed: true, wantStatus: http.StatusUnprocessableEntity, wantCall: true},
+		{name: "missing token", body: `{"type": "text", "params": {"content": "hi"}}`, wantStatus: http.StatusUnauthorized},
+		{name: "wrong token", token: "guess", body: `{"type": "text", "params": {"content": "hi"}}`, wantStatus: http.StatusUnauthorized},
+		{name: "invalid body", token: "s3cret", body: `{"type": `, wantStatus: http.StatusBadRequest},
+		{name: "unknown type", token: "s3cret", body: `{"type": "voice", "params": {}}`, wantStatus: http.StatusBadRequest},
+		{name: "missing type", token: "s3cret", body: `{"params": {}}`, wantStatus: http.StatusBadRequest},
+		{name: "not a send tool", token: "s3cret", body: `{"type": "../list_media"}`, wantStatus: http.StatusBadRequest},
+		{name: "unknown bot", token: "s3cret", body: `{"type": "text", "bot": "ops", "params": {"content": "hi"}}`, wantStatus: http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := newFakeServer()
+			server.failed = tt.failed
+			rec := serve(t, cfg, server, jsonRequest(http.MethodPost, "/api/v1/messages", tt.token, tt.body))
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("expected %d, got %d: %s", tt.wantStatus, rec.Code, rec.Body)
+			}
+			if (len(server.calls) == 1) != tt.wantCall {
+				t.Fatalf("unexpected calls %+v", server.calls)
+			}
+		})
+	}
+}
+
+func TestSendMessage_DisabledTool(t *testing.T) {
+	server := newFakeServer("send_markdown")
+	rec := serve(t, config.API{Enabled: true}, server, jsonRequest(http.MethodPost, "/api/v1/messages", "", `{"type": "markdown", "params": {"content": "hi"}}`))
+	if rec.Code != http.StatusBadRequest || !strings.Contains(rec.Body.String(), "available types: text, image") {
+		t.Fatalf("expected disabled types to be rejected, got %d: %s", rec.Code, rec.Body)
+	}
+}
+
+func TestUploadMedia(t *testing.T) {
+	var body bytes.Buffer
+	form := multipart.NewWriter(&body)
+	part, err := form.CreateFormFile("file", "report.pdf")
+	if err != nil {
+		t.Fatal(err)
+	}
+	part.Write([]byte("%PDF-1.4"))
+	form.Close()
+
+	server := newFakeServer()
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/media", &body)
+	req.Header.Set("Content-Type", form.FormDataContentType())
+	rec := serve(t, config.API{Enabled: true}, server, req)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rec.Code, rec.Body)
+	}
+	want := map[string]any{"filename": "report.pdf", "base64_data": base64.StdEncoding.EncodeToString([]byte("%PDF-1.4"))}
+	if len(server.calls) != 1 || server.calls[0].tool != "upload_file" || fmt.Sprint(server.calls[0].args) != fmt.Sprint(want) {
+		t.Fatalf("expected upload_file with %v, got %+v", want, server.calls)
+	}
+
+	server = newFakeServer()
+	rec = serve(t, config.API{Enabled: true}, server, jsonRequest(http.MethodPost, "/api/v1/media", "", `{"filename": "a.txt", "base64_data": "aGk="}`))
+	if rec.Code != http.StatusOK || len(server.calls) != 1 || server.calls[0].args["filename"] != "a.txt" {
+		t.Fatalf("expected a JSON upload, got %d %s and calls %+v", rec.Code, rec.Body, server.calls)
+	}
+
+	rec = serve(t, config.API{Enabled: true}, newFakeServer("upload_file"), jsonRequest(http.MethodPost, "/api/v1/media", "", `{}`))
+	if rec.Code != http.StatusNotFound {
+		t.Fatalf("expected 404 with upload_file disabled, got %d", rec.Code)
+	}
+}
+
+func TestListBots(t *testing.T) {
+	rec := serve(t, config.API{Enabled: true}, newFakeServer("send_news", "upload_file"), jsonRequest(http.MethodGet, "/api/v1/bots", "", ""))
+	var body struct {
+		Bots []Bot `json:"bots"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil || len(body.Bots) != 1 {
+		t.Fatalf("expected one bot, got %s", rec.Body)
+	}
+	bot := body.Bots[0]
+	if bot.Name != "default" || bot.MediaUpload || contains(bot.MessageTypes, "news") || !contains(bot.MessageTypes, "text_notice_card") {
+		t.Fatalf("unexpected bot %+v", bot)
+	}
+}
+
+func TestOpenAPI(t *testing.T) {
+	cfg := config.API{Enabled: true, Token: "s3cret"}
+	rec := serve(t, cfg, newFakeServer("send_image"), jsonRequest(http.MethodGet, "/api/v1/openapi.json", "", ""))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected the document without a token, got %d", rec.Code)
+	}
+
+	var document struct {
+		OpenAPI    string                    `json:"openapi"`
+		Paths      map[string]map[string]any `json:"paths"`
+		Security   []map[string]any          `json:"security"`
+		Components struct {
+			Schemas map[string]struct {
+				Properties map[string]struct {
+					Const      string         `json:"const"`
+					Properties map[string]any `json:"properties"`
+					Required   []string       `json:"required"`
+				} `json:"properties"`
+			} `json:"schemas"`
+		} `json:"components"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &document); err != nil {
+		t.Fatalf("invalid document: %v", err)
+	}
+	if document.OpenAPI != "3.1.0" || len(document.Security) != 1 {
+		t.Fatalf("unexpected document header %q %v", document.OpenAPI, document.Security)
+	}
+	for _, path := range []string{"/api/v1/messages", "/api/v1/media", "/api/v1/bots"} {
+		if _, ok := document.Paths[path]; !ok {
+			t.Fatalf("expected path %s", path)
+		}
+	}
+
+	card, ok := document.Components.Schemas["TextNoticeCardMessage"]
+	if !ok || card.Properties["type"].Const != "text_notice_card" {
+		t.Fatalf("expected a text_notice_card schema, got %+v", card)
+	}
+	params := card.Properties["params"]
+	if _, ok := params.Properties["card_action"]; !ok || !contains(params.Required, "main_title") {
+		t.Fatalf("expected the tool input schema as params, got %+v", params)
+	}
+	if _, ok := document.Components.Schemas["ImageMessage"]; ok {
+		t.Fatal("expected disabled tools to be left out")
+	}
+}
+
+func TestSchemaName(t *testing.T) {
+	for messageType, want := range map[string]string{"text": "TextMessage", "news_notice_card": "NewsNoticeCardMessage"} {
+		if got := schemaName(messageType); got != want {
+			t.Errorf("schemaName(%q) = %q, want %q", messageType, got, want)
+		}
+	}
+}
@@ -0,0 +1,193 @@
+package api
+
+import (
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+
+	"github.com/futuretea/wecom-bot-mcp-server/pkg/core/version"
+	wecomToolset "github.com/futuretea/wecom-bot-mcp-server/pkg/toolset/wecom"
+)
+
+// schemaRef returns a reference to a component schema
+func schemaRef(name string) map[string]any {
+	return map[string]any{"$ref": "#/components/schemas/" + name}
+}
+
+// jsonContent returns the content of a JSON request or response body
+func jsonContent(schema any) map[string]any {
+	return map[string]any{"application/json": map[string]any{"schema": schema}}
+}
+
+// schemaName returns the component name of a message type, e.g.
+// "TextNoticeCardMessage" for text_notice_card
+func schemaName(messageType string) string {
+	var b strings.Builder
+	for _, part := range strings.Split(messageType, "_") {
+		if part != "" {
+			b.WriteString(strings.ToUpper(part[:1]) + part[1:])
+		}
+	}
+	b.WriteString("Message")
+	return b.String()
+}
+
+// toolSchema returns the JSON schema of a tool's arguments
+func toolSchema(tool mcp.Tool) map[string]any {
+	schema := map[string]any{"type": "object", "properties": tool.InputSchema.Properties}
+	if tool.InputSchema.Properties == nil {
+		schema["properties"] = map[string]any{}
+	}
+	if len(tool.InputSchema.Required) > 0 {
+		schema["required"] = tool.InputSchema.Required
+	}
+	return schema
+}
+
+// openAPIDocument generates the OpenAPI 3.1 document of the REST API. Each
+// enabled send tool becomes a message schema whose params are the tool's
+// input schema.
+func (h *Handler) openAPIDocument() map[string]any {
+	schemas := map[string]any{
+		"ToolResult": map[string]any{
+			"type":        "object",
+			"description": "The CallToolResult of the tool, as an MCP client receives it.",
+			"properties": map[string]any{
+				"content": map[string]any{
+					"type": "array",
+					"items": map[string]any{
+						"type": "object",
+						"properties": map[string]any{
+							"type": map[string]any{"type": "string"},
+							"text": map[string]any{"type": "string"},
+						},
+					},
+				},
+				"isError": map[string]any{"type": "boolean"},
+			},
+			"required": []string{"content"},
+		},
+		"Error": map[string]any{
+			"type":       "object",
+			"properties": map[string]any{"error": map[string]any{"type": "string"}},
+			"required":   []string{"error"},
+		},
+		"Bots": map[string]any{
+			"type": "object",
+			"properties": map[string]any{
+				"bots": map[string]any{
+					"type": "array",
+					"items": map[string]any{
+						"type": "object",
+						"properties": map[string]any{
+							"name":          map[string]any{"type": "string"},
+							"message_types": map[string]any{"type": "array", "items": map[string]any{"type": "string"}},
+							"media_upload":  map[string]any{"type": "boolean"},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	var messages []any
+	mapping := map[string]any{}
+	for _, messageType := range h.messageTypes() {
+		tool, _ := h.server.ToolDefinition(sendToolPrefix + messageType)
+		name := schemaName(messageType)
+		schemas[name] = map[string]any{
+			"type":        "object",
+			"description": tool.Description,
+			"properties": map[string]any{
+				"type":   map[string]any{"type": "string", "const": messageType},
+				"bot":    map[string]any{"type": "string", "description": "Bot to send with. Only the default bot exists.", "default": wecomToolset.DefaultBotName},
+				"params": toolSchema(tool),
+			},
+			"required": []string{"type", "params"},
+		}
+		messages = append(messages, schemaRef(name))
+		mapping[messageType] = "#/components/schemas/" + name
+	}
+
+	errorResponses := func(responses map[string]any) map[string]any {
+		responses["400"] = map[string]any{"description": "Invalid request", "content": jsonContent(schemaRef("Error"))}
+		responses["401"] = map[string]any{"description": "Missing or invalid bearer token", "content": jsonContent(schemaRef("Error"))}
+		return responses
+	}
+	toolResponses := func() map[string]any {
+		return errorResponses(map[string]any{
+			"200": map[string]any{"description": "The tool succeeded", "content": jsonContent(schemaRef("ToolResult"))},
+			"422": map[string]any{"description": "The tool returned an error result", "content": jsonContent(schemaRef("ToolResult"))},
+		})
+	}
+
+	paths := map[string]any{
+		messagesEndpoint: map[string]any{
+			"post": map[string]any{
+				"operationId": "sendMessage",
+				"summary":     "Send a message",
+				"requestBody": map[string]any{
+					"required": true,
+					"content": jsonContent(map[string]any{
+						"oneOf":         messages,
+						"discriminator": map[string]any{"propertyName": "type", "mapping": mapping},
+					}),
+				},
+				"responses": toolResponses(),
+			},
+		},
+		botsEndpoint: map[string]any{
+			"get": map[string]any{
+				"operationId": "listBots",
+				"summary":     "List the bots and the message types they can send",
+				"responses": errorResponses(map[string]any{
+					"200": map[string]any{"description": "The bots", "content": jsonContent(schemaRef("Bots"))},
+				}),
+			},
+		},
+	}
+	if tool, ok := h.server.ToolDefinition(uploadTool); ok {
+		paths[mediaEndpoint] = map[string]any{
+			"post": map[string]any{
+				"operationId": "uploadMedia",
+				"summary":     "Upload a file and get its media_id",
+				"description": tool.Description,
+				"requestBody": map[string]any{
+					"required": true,
+					"content": map[string]any{
+						"multipart/form-data": map[string]any{
+							"schema": map[string]any{
+								"type": "object",
+								"properties": map[string]any{
+									"file":     map[string]any{"type": "string", "format": "binary"},
+									"filename": map[string]any{"type": "string", "description": "Overrides the name of the uploaded file."},
+								},
+								"required": []string{"file"},
+							},
+						},
+						"application/json": map[string]any{"schema": toolSchema(tool)},
+					},
+				},
+				"responses": toolResponses(),
+			},
+		}
+	}
+
+	document := map[string]any{
+		"openapi": "3.1.0",
+		"info": map[string]any{
+			"title":       version.BinaryName + " REST API",
+			"version":     version.Version,
+			"description": "Send messages through the same governed server as MCP clients. Operations run the MCP tools and return their results.",
+		},
+		"paths":      paths,
+		"components": map[string]any{"schemas": schemas},
+	}
+	if h.cfg.Token != "" {
+		document["components"].(map[string]any)["securitySchemes"] = map[string]any{
+			"bearerAuth": map[string]any{"type": "http", "scheme": "bearer"},
+		}
+		document["security"] = []any{map[string]any{"bearerAuth": []string{}}}
+	}
+	return document
+}
@@ -0,0 +1,204 @@
+// Package callback serves the WeCom smart bot callback, which receives the
+// messages users send to the bot by @-mentioning it in a group or chatting
+// with it directly. Messages are verified, decrypted and stored in the inbox
+// read by the get_incoming_messages tool.
+package callback
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/futuretea/wecom-bot-mcp-server/pkg/core/config"
+	"github.com/futuretea/wecom-bot-mcp-server/pkg/core/logging"
+	wecomToolset "github.com/futuretea/wecom-bot-mcp-server/pkg/toolset/wecom"
+)
+
+// maxRequestBytes limits the size of a callback request body
+const maxRequestBytes = 1 << 20
+
+// callbackPathPrefix is followed by the bot name in the callback URL
+const callbackPathPrefix = "/callback/"
+
+// encryptedRequest is the body of a callback request
+type encryptedRequest struct {
+	Encrypt string `json:"encrypt"`
+}
+
+// botMessage is a decrypted smart bot callback message
+type botMessage struct {
+	MsgID    string `json:"msgid"`
+	ChatID   string `json:"chatid"`
+	ChatType string `json:"chattype"`
+	From     struct {
+		UserID string `json:"userid"`
+	} `json:"from"`
+	messageItem
+	Mixed *struct {
+		Items []messageItem `json:"msg_item"`
+	} `json:"mixed"`
+}
+
+// messageItem is the content of a message, or of one part of a mixed message
+type messageItem struct {
+	MsgType string `json:"msgtype"`
+	Text    *struct {
+		Content string `json:"content"`
+	} `json:"text"`
+	Voice *struct {
+		Content string `json:"content"`
+	} `json:"voice"`
+	Image *struct {
+		URL string `json:"url"`
+	} `json:"image"`
+	File *struct {
+		URL string `json:"url"`
+	} `json:"file"`
+}
+
+// content returns the text of an item. Images and files are given by URL,
+// and voice messages by their transcription.
+func (m messageItem) content() string {
+	switch {
+	case m.MsgType == "text" && m.Text != nil:
+		return m.Text.Content
+	case m.MsgType == "voice" && m.Voice != nil:
+		return m.Voice.Content
+	case m.MsgType == "image" && m.Image != nil:
+		return "[image] " + m.Image.URL
+	case m.MsgType == "file" && m.File != nil:
+		return "[file] " + m.File.URL
+	}
+	return ""
+}
+
+// content returns the text of a message, joining the parts of a mixed message
+func (m botMessage) content() string {
+	if m.MsgType != "mixed" || m.Mixed == nil {
+		return m.messageItem.content()
+	}
+	var parts []string
+	for _, item := range m.Mixed.Items {
+		if content := item.content(); content != "" {
+			parts = append(parts, content)
+		}
+	}
+	return strings.Join(parts, "\n")
+}
+
+// Handler serves the callback URL of a smart bot.
+type Handler struct {
+	crypto *Crypto
+	inbox  *wecomToolset.Inbox
+}
+
+// Register adds the callback endpoint to mux when it is enabled.
+func Register(mux *http.ServeMux, cfg config.Callback, inbox *wecomToolset.Inbox) error {
+	if !cfg.Enabled {
+		return nil
+	}
+	if inbox == nil {
+		return fmt.Errorf("callback is enabled but incoming messages are not stored")
+	}
+	h, err := NewHandler(cfg, inbox)
+	if err != nil {
+		return err
+	}
+	path := callbackPathPrefix + inbox.Bot()
+	mux.HandleFunc("GET "+path, h.verifyURL)
+	mux.HandleFunc("POST "+path, h.receive)
+	logging.Info("Smart bot callback enabled at %s", path)
+	return nil
+}
+
+// NewHandler creates the callback handler. A nil inbox only checks the
+// configuration.
+func NewHandler(cfg config.Callback, inbox *wecomToolset.Inbox) (*Handler, error) {
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	crypto, err := NewCrypto(cfg.Token, cfg.EncodingAESKey, cfg.ReceiveID)
+	if err != nil {
+		return nil, err
+	}
+	return &Handler{crypto: crypto, inbox: inbox}, nil
+}
+
+// verifyURL answers the handshake WeCom makes when the callback URL is saved:
+// it decrypts echostr and returns it in plain text.
+func (h *Handler) verifyURL(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	echo := query.Get("echostr")
+	if !h.crypto.Verify(query.Get("msg_signature"), query.Get("timestamp"), query.Get("nonce"), echo) {
+		logging.Warn("Callback URL verification from %s rejected: invalid signature", r.RemoteAddr)
+		http.Error(w, "invalid signature", http.StatusUnauthorized)
+		return
+	}
+	plain, err := h.crypto.Decrypt(echo)
+	if err != nil {
+		logging.Warn("Callback URL verification from %s failed: %v", r.RemoteAddr, err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	logging.Info("Callback URL verified")
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	_, _ = w.Write(plain)
+}
+
+// receive stores a message pushed by WeCom. The response is empty, so the bot
+// does not reply by itself; agents reply with the send tools.
+func (h *Handler) receive(w http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxRequestBytes))
+	if err != nil {
+		http.Error(w, fmt.Sprintf("failed to read request body: %v", err), http.StatusBadRequest)
+		return
+	}
+	var req encryptedRequest
+	if err := json.Unmarshal(body, &req); err != nil || req.Encrypt == "" {
+		http.Error(w, "request body must be a JSON object with an encrypt field", http.StatusBadRequest)
+		return
+	}
+	query := r.URL.Query()
+	if !h.crypto.Verify(query.Get("msg_signature"), query.Get("timestamp"), query.Get("nonce"), req.Encrypt) {
+		logging.Warn("Callback message from %s rejected: invalid signature", r.RemoteAddr)
+		http.Error(w, "invalid signature", http.StatusUnauthorized)
+		return
+	}
+	plain, err := h.crypto.Decrypt(req.Encrypt)
+	if err != nil {
+		logging.Warn("Failed to decrypt callback message: %v", err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	var msg botMessage
+	if err := json.Unmarshal(plain, &msg); err != nil {
+		logging.Warn("Failed to parse callback message: %v", err)
+		http.Error(w, fmt.Sprintf("invalid message: %v", err), http.StatusBadRequest)
+		return
+	}
+	content := msg.content()
+	if content == "" {
+		// Stream refreshes, events and unsupported message types
+		logging.Debug("Ignoring callback message %s of type %s", msg.MsgID, msg.MsgType)
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+
+	stored, added := h.inbox.Add(wecomToolset.IncomingMessage{
+		MsgID:    msg.MsgID,
+		ChatID:   msg.ChatID,
+		ChatType: msg.ChatType,
+		From:     msg.From.UserID,
+		MsgType:  msg.MsgType,
+		Content:  content,
+	})
+	if added {
+		logging.Info("Received %s message %d from %s", msg.MsgType, stored.ID, msg.From.UserID)
+	} else {
+		logging.Debug("Ignoring redelivered callback message %s", msg.MsgID)
+	}
+	w.WriteHeader(http.StatusOK)
+}
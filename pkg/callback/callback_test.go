@@ -0,0 +1,186 @@
+package callback
+
+import (
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/futuretea/wecom-bot-mcp-server/pkg/core/config"
+	wecomToolset "github.com/futuretea/wecom-bot-mcp-server/pkg/toolset/wecom"
+)
+
+// testKey is a valid EncodingAESKey
+var testKey = strings.TrimSuffix(base64.StdEncoding.EncodeToString([]byte("0123456789abcdef0123456789abcdef")), "=")
+
+func newTestCrypto(t *testing.T, receiveID string) *Crypto {
+	t.Helper()
+	crypto, err := NewCrypto("token", testKey, receiveID)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	return crypto
+}
+
+func TestCrypto_RoundTrip(t *testing.T) {
+	crypto := newTestCrypto(t, "")
+	for _, message := range []string{"", "hello", strings.Repeat("x", 32), `{"msgtype":"text"}`} {
+		encrypted, err := crypto.Encrypt([]byte(message))
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		plain, err := crypto.Decrypt(encrypted)
+		if err != nil || string(plain) != message {
+			t.Fatalf("expected %q, got %q, %v", message, plain, err)
+		}
+	}
+
+	signature := crypto.Signature("1700000000", "nonce", "data")
+	if !crypto.Verify(strings.ToUpper(signature), "1700000000", "nonce", "data") || crypto.Verify(signature, "1700000001", "nonce", "data") {
+		t.Fatal("expected the signature to cover the timestamp")
+	}
+}
+
+func TestCrypto_ReceiveID(t *testing.T) {
+	encrypted, err := newTestCrypto(t, "corp-a").Encrypt([]byte("hi"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := newTestCrypto(t, "corp-b").Decrypt(encrypted); err == nil {
+		t.Fatal("expected a message for another receiver to be rejected")
+	}
+	if _, err := newTestCrypto(t, "").Decrypt(encrypted); err != nil {
+		t.Fatalf("expected the receiver to be ignored when unset, got %v", err)
+	}
+}
+
+func TestNewCrypto_InvalidKey(t *testing.T) {
+	if _, err := NewCrypto("token", strings.Repeat("!", 43), ""); err == nil {
+		t.Fatal("expected an invalid key to be rejected")
+	}
+}
+
+// serve registers the callback and sends a signed request
+func serve(t *testing.T, inbox *wecomToolset.Inbox, method, encrypted, body string, tamper bool) *httptest.ResponseRecorder {
+	t.Helper()
+	cfg := config.Callback{Enabled: true, Token: "token", EncodingAESKey: testKey}
+	mux := http.NewServeMux()
+	if err := Register(mux, cfg, inbox); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	signature := newTestCrypto(t, "").Signature("1700000000", "n1", encrypted)
+	if tamper {
+		signature = strings.Repeat("0", len(signature))
+	}
+	query := url.Values{"msg_signature": {signature}, "timestamp": {"1700000000"}, "nonce": {"n1"}}
+	if method == http.MethodGet {
+		query.Set("echostr", encrypted)
+	}
+	req := httptest.NewRequest(method, "/callback/default?"+query.Encode(), strings.NewReader(body))
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, req)
+	return rec
+}
+
+func encrypt(t *testing.T, message string) string {
+	t.Helper()
+	encrypted, err := newTestCrypto(t, "").Encrypt([]byte(message))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return encrypted
+}
+
+func TestVerifyURL(t *testing.T) {
+	inbox := wecomToolset.NewInbox(wecomToolset.DefaultBotName, 0)
+	echo := encrypt(t, "1234567890")
+	rec := serve(t, inbox, http.MethodGet, echo, "", false)
+	if rec.Code != http.StatusOK || rec.Body.String() != "1234567890" {
+		t.Fatalf("expected the decrypted echostr, got %d %q", rec.Code, rec.Body)
+	}
+	if rec := serve(t, inbox, http.MethodGet, echo, "", true); rec.Code != http.StatusUnauthorized {
+		t.Fatalf("expected 401 for a bad signature, got %d", rec.Code)
+	}
+}
+
+func TestReceive(t *testing.T) {
+	tests := []struct {
+		name        string
+		message     string
+		tamper      bool
+		wantStatus  int
+		wantContent string
+	}{
+		{
+			name:        "text",
+			message:     `{"msgid":"m1","aibotid":"b1","chatid":"wrc1","chattype":"group","from":{"userid":"alice"},"msgtype":"text","text":{"content":"@bot deploy api"}}`,
+			wantStatus:  http.StatusOK,
+			wantContent: "@bot deploy api",
+		},
+		{
+			name:        "mixed",
+			message:     `{"msgid":"m2","chattype":"single","from":{"userid":"bob"},"msgtype":"mixed","mixed":{"msg_item":[{"msgtype":"text","text":{"content":"see"}},{"msgtype":"image","image":{"url":"https://example.com/a.png"}}]}}`,
+			wantStatus:  http.StatusOK,
+			wantContent: "see\n[image] https://example.com/a.png",
+		},
+		{
+			name:       "event ignored",
+			message:    `{"msgid":"m3","msgtype":"event","event":{"eventtype":"enter_chat"}}`,
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:       "bad signature",
+			message:    `{"msgid":"m4","msgtype":"text","text":{"content":"hi"}}`,
+			tamper:     true,
+			wantStatus: http.StatusUnauthorized,
+		},
+		{
+			name:       "invalid message",
+			message:    `not json`,
+			wantStatus: http.StatusBadRequest,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			inbox := wecomToolset.NewInbox(wecomToolset.DefaultBotName, 0)
+			encrypted := encrypt(t, tt.message)
+			rec := serve(t, inbox, http.MethodPost, encrypted, fmt.Sprintf(`{"encrypt":%q}`, encrypted), tt.tamper)
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("expected %d, got %d: %s", tt.wantStatus, rec.Code, rec.Body)
+			}
+			messages, _ := inbox.Since(0, "", 0)
+			if tt.wantContent == "" {
+				if len(messages) != 0 {
+					t.Fatalf("expected nothing stored, got %+v", messages)
+				}
+				return
+			}
+			if len(messages) != 1 || messages[0].Content != tt.wantContent {
+				t.Fatalf("expected %q to be stored, got %+v", tt.wantContent, messages)
+			}
+		})
+	}
+}
+
+func TestReceive_StoresSender(t *testing.T) {
+	inbox := wecomToolset.NewInbox(wecomToolset.DefaultBotName, 0)
+	encrypted := encrypt(t, `{"msgid":"m1","chatid":"wrc1","chattype":"group","from":{"userid":"alice"},"msgtype":"voice","voice":{"content":"status please"}}`)
+	body := fmt.Sprintf(`{"encrypt":%q}`, encrypted)
+	for i := 0; i < 2; i++ {
+		if rec := serve(t, inbox, http.MethodPost, encrypted, body, false); rec.Code != http.StatusOK {
+			t.Fatalf("expected 200, got %d", rec.Code)
+		}
+	}
+	messages, _ := inbox.Since(0, "", 0)
+	if len(messages) != 1 {
+		t.Fatalf("expected the redelivered message to be stored once, got %+v", messages)
+	}
+	msg := messages[0]
+	if msg.From != "alice" || msg.ChatID != "wrc1" || msg.ChatType != "group" || msg.MsgType != "voice" || msg.Content != "status please" {
+		t.Fatalf("unexpected message %+v", msg)
+	}
+}
@@ -0,0 +1,131 @@
+package callback
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// WeCom pads messages to a multiple of 32 bytes before encrypting them
+const paddingBlockSize = 32
+
+// Sizes of the fields around the message in a decrypted payload
+const (
+	randomPrefixBytes = 16
+	lengthBytes       = 4
+)
+
+// Crypto signs, encrypts and decrypts WeCom callback messages with the
+// callback token and EncodingAESKey.
+type Crypto struct {
+	token     string
+	key       []byte
+	block     cipher.Block
+	receiveID string
+}
+
+// NewCrypto creates the callback crypto. A non-empty receiveID must match the
+// receiver of every decrypted message.
+func NewCrypto(token, encodingAESKey, receiveID string) (*Crypto, error) {
+	key, err := base64.StdEncoding.DecodeString(encodingAESKey + "=")
+	if err != nil || len(key) != 32 {
+		return nil, fmt.Errorf("callback.encoding_aes_key is not a valid EncodingAESKey: it must be 43 base64 characters encoding 32 bytes")
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create AES cipher: %w", err)
+	}
+	return &Crypto{token: token, key: key, block: block, receiveID: receiveID}, nil
+}
+
+// Signature returns the msg_signature of an encrypted message: the SHA-1 of
+// the token, timestamp, nonce and message, sorted and concatenated.
+func (c *Crypto) Signature(timestamp, nonce, encrypted string) string {
+	parts := []string{c.token, timestamp, nonce, encrypted}
+	sort.Strings(parts)
+	sum := sha1.Sum([]byte(strings.Join(parts, "")))
+	return hex.EncodeToString(sum[:])
+}
+
+// Verify reports whether signature is the msg_signature of an encrypted message.
+func (c *Crypto) Verify(signature, timestamp, nonce, encrypted string) bool {
+	expected := c.Signature(timestamp, nonce, encrypted)
+	return subtle.ConstantTimeCompare([]byte(strings.ToLower(signature)), []byte(expected)) == 1
+}
+
+// Decrypt returns the message of a base64 encrypted payload. The payload is
+// AES-256-CBC encrypted with the first 16 bytes of the key as IV, and holds
+// 16 random bytes, the big-endian message length, the message and the
+// receiver ID.
+func (c *Crypto) Decrypt(encrypted string) ([]byte, error) {
+	data, err := base64.StdEncoding.DecodeString(encrypted)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode encrypted message: %w", err)
+	}
+	if len(data) == 0 || len(data)%aes.BlockSize != 0 {
+		return nil, fmt.Errorf("encrypted message is not a whole number of AES blocks")
+	}
+	plain := make([]byte, len(data))
+	cipher.NewCBCDecrypter(c.block, c.key[:aes.BlockSize]).CryptBlocks(plain, data)
+
+	plain, err = unpad(plain)
+	if err != nil {
+		return nil, err
+	}
+	if len(plain) < randomPrefixBytes+lengthBytes {
+		return nil, fmt.Errorf("decrypted message is too short")
+	}
+	content := plain[randomPrefixBytes+lengthBytes:]
+	length := binary.BigEndian.Uint32(plain[randomPrefixBytes:])
+	if uint64(length) > uint64(len(content)) {
+		return nil, fmt.Errorf("decrypted message length %d exceeds the payload", length)
+	}
+	message, receiveID := content[:length], string(content[length:])
+	if c.receiveID != "" && receiveID != c.receiveID {
+		return nil, fmt.Errorf("message is addressed to %q, not %q", receiveID, c.receiveID)
+	}
+	return message, nil
+}
+
+// Encrypt returns the base64 encrypted payload of a message, as WeCom sends it.
+func (c *Crypto) Encrypt(message []byte) (string, error) {
+	var buf bytes.Buffer
+	random := make([]byte, randomPrefixBytes)
+	if _, err := rand.Read(random); err != nil {
+		return "", fmt.Errorf("failed to generate random prefix: %w", err)
+	}
+	buf.Write(random)
+	_ = binary.Write(&buf, binary.BigEndian, uint32(len(message)))
+	buf.Write(message)
+	buf.WriteString(c.receiveID)
+
+	plain := pad(buf.Bytes())
+	data := make([]byte, len(plain))
+	cipher.NewCBCEncrypter(c.block, c.key[:aes.BlockSize]).CryptBlocks(data, plain)
+	return base64.StdEncoding.EncodeToString(data), nil
+}
+
+// pad applies PKCS#7 padding to a multiple of paddingBlockSize
+func pad(data []byte) []byte {
+	n := paddingBlockSize - len(data)%paddingBlockSize
+	return append(data, bytes.Repeat([]byte{byte(n)}, n)...)
+}
+
+// unpad removes PKCS#7 padding
+func unpad(data []byte) ([]byte, error) {
+	n := int(data[len(data)-1])
+	if n < 1 || n > paddingBlockSize || n > len(data) {
+		return nil, errors.New("decrypted message has invalid padding")
+	}
+	return data[:len(data)-n], nil
+}
@@ -0,0 +1,173 @@
+// Package chart renders simple line, bar and pie charts and tables to PNG
+// images. Rendering is pure Go and needs no fonts or network access; text is
+// drawn with a built-in ASCII bitmap font.
+package chart
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/png"
+	"math"
+	"strings"
+)
+
+// Chart types
+const (
+	TypeLine  = "line"
+	TypeBar   = "bar"
+	TypePie   = "pie"
+	TypeTable = "table"
+)
+
+// Size limits of a chart spec
+const (
+	DefaultWidth  = 800
+	DefaultHeight = 480
+	MinSize       = 200
+	MaxSize       = 2000
+
+	MaxLabels     = 100
+	MaxSeries     = 8
+	MaxColumns    = 12
+	MaxRows       = 50
+	maxTitleRunes = 100
+)
+
+// Spec describes a chart or table to render
+type Spec struct {
+	// Type is line, bar, pie or table
+	Type string `json:"type"`
+
+	// Title is drawn above the chart. Optional.
+	Title string `json:"title,omitempty"`
+
+	// Labels are the x-axis categories of line and bar charts and the slice
+	// names of pie charts
+	Labels []string `json:"labels,omitempty"`
+
+	// Series are the data of line, bar and pie charts, one value per label.
+	// Pie charts take exactly one series.
+	Series []Series `json:"series,omitempty"`
+
+	// Columns and Rows are the header and cells of a table
+	Columns []string   `json:"columns,omitempty"`
+	Rows    [][]string `json:"rows,omitempty"`
+
+	// Width and Height are the image size in pixels. Zero uses the defaults;
+	// tables are sized to fit their content.
+	Width  int `json:"width,omitempty"`
+	Height int `json:"height,omitempty"`
+}
+
+// Series is a named list of values
+type Series struct {
+	Name   string    `json:"name,omitempty"`
+	Values []float64 `json:"values"`
+}
+
+// Validate checks the spec for the chart type
+func (s *Spec) Validate() error {
+	if len([]rune(s.Title)) > maxTitleRunes {
+		return fmt.Errorf("title must be at most %d characters", maxTitleRunes)
+	}
+	for _, size := range []struct {
+		name  string
+		value int
+	}{{"width", s.Width}, {"height", s.Height}} {
+		if size.value != 0 && (size.value < MinSize || size.value > MaxSize) {
+			return fmt.Errorf("%s must be between %d and %d pixels, got %d", size.name, MinSize, MaxSize, size.value)
+		}
+	}
+
+	switch s.Type {
+	case TypeLine, TypeBar:
+		return s.validateSeries(MaxSeries)
+	case TypePie:
+		if err := s.validateSeries(1); err != nil {
+			return err
+		}
+		var total float64
+		for _, v := range s.Series[0].Values {
+			if v < 0 {
+				return fmt.Errorf("pie chart values must not be negative, got %v", v)
+			}
+			total += v
+		}
+		if total == 0 {
+			return fmt.Errorf("pie chart values must not all be zero")
+		}
+		return nil
+	case TypeTable:
+		if len(s.Columns) == 0 || len(s.Columns) > MaxColumns {
+			return fmt.Errorf("table must have 1 to %d columns, got %d", MaxColumns, len(s.Columns))
+		}
+		if len(s.Rows) == 0 || len(s.Rows) > MaxRows {
+			return fmt.Errorf("table must have 1 to %d rows, got %d", MaxRows, len(s.Rows))
+		}
+		for i, row := range s.Rows {
+			if len(row) > len(s.Columns) {
+				return fmt.Errorf("rows[%d] has %d cells but the table has %d columns", i, len(row), len(s.Columns))
+			}
+		}
+		return nil
+	default:
+		return fmt.Errorf("type must be one of %s, got %q", strings.Join([]string{TypeLine, TypeBar, TypePie, TypeTable}, ", "), s.Type)
+	}
+}
+
+// validateSeries checks the labels and series of a line, bar or pie chart
+func (s *Spec) validateSeries(maxSeries int) error {
+	if len(s.Labels) == 0 || len(s.Labels) > MaxLabels {
+		return fmt.Errorf("%s chart must have 1 to %d labels, got %d", s.Type, MaxLabels, len(s.Labels))
+	}
+	if len(s.Series) == 0 || len(s.Series) > maxSeries {
+		return fmt.Errorf("%s chart must have 1 to %d series, got %d", s.Type, maxSeries, len(s.Series))
+	}
+	for i, series := range s.Series {
+		if len(series.Values) != len(s.Labels) {
+			return fmt.Errorf("series[%d] has %d values but there are %d labels", i, len(series.Values), len(s.Labels))
+		}
+		for _, v := range series.Values {
+			if math.IsNaN(v) || math.IsInf(v, 0) {
+				return fmt.Errorf("series[%d] contains a value that is not a finite number", i)
+			}
+		}
+	}
+	return nil
+}
+
+// Render validates the spec and renders it as a PNG image
+func Render(spec Spec) ([]byte, error) {
+	if err := spec.Validate(); err != nil {
+		return nil, err
+	}
+
+	var img *image.RGBA
+	switch spec.Type {
+	case TypeLine, TypeBar:
+		img = renderXY(spec)
+	case TypePie:
+		img = renderPie(spec)
+	case TypeTable:
+		img = renderTable(spec)
+	}
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return nil, fmt.Errorf("failed to encode chart: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// size returns the configured image size or the defaults
+func (s *Spec) size() (int, int) {
+	width, height := s.Width, s.Height
+	if width == 0 {
+		width = DefaultWidth
+	}
+	if height == 0 {
+		height = DefaultHeight
+	}
+	return width, height
+}
@@ -0,0 +1,149 @@
+package chart
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/png"
+	"math"
+	"strings"
+	"testing"
+)
+
+func decode(t *testing.T, data []byte) image.Image {
+	t.Helper()
+	img, err := png.Decode(bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("expected a PNG image, got %v", err)
+	}
+	return img
+}
+
+// hasColor reports whether any pixel of img has the color want
+func hasColor(img image.Image, want color.Color) bool {
+	wr, wg, wb, _ := want.RGBA()
+	b := img.Bounds()
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		for x := b.Min.X; x < b.Max.X; x++ {
+			r, g, bl, _ := img.At(x, y).RGBA()
+			if r == wr && g == wg && bl == wb {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func TestRender(t *testing.T) {
+	labels := []string{"Mon", "Tue", "Wed"}
+	tests := []struct {
+		name       string
+		spec       Spec
+		wantWidth  int
+		wantHeight int
+		wantColors int
+	}{
+		{
+			name:       "line with two series",
+			spec:       Spec{Type: TypeLine, Title: "Latency", Labels: labels, Series: []Series{{Name: "p50", Values: []float64{1, 2, 3}}, {Name: "p99", Values: []float64{4, 8, 6}}}},
+			wantWidth:  DefaultWidth,
+			wantHeight: DefaultHeight,
+			wantColors: 2,
+		},
+		{
+			name:       "bar with negative values",
+			spec:       Spec{Type: TypeBar, Labels: labels, Series: []Series{{Values: []float64{-1.5, 0, 2.25}}}, Width: 400, Height: 300},
+			wantWidth:  400,
+			wantHeight: 300,
+			wantColors: 1,
+		},
+		{
+			name:       "pie",
+			spec:       Spec{Type: TypePie, Labels: labels, Series: []Series{{Values: []float64{1, 2, 3}}}},
+			wantWidth:  DefaultWidth,
+			wantHeight: DefaultHeight,
+			wantColors: 3,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, err := Render(tt.spec)
+			if err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+			img := decode(t, data)
+			if img.Bounds().Dx() != tt.wantWidth || img.Bounds().Dy() != tt.wantHeight {
+				t.Fatalf("expected %dx%d image, got %v", tt.wantWidth, tt.wantHeight, img.Bounds())
+			}
+			for i := 0; i < tt.wantColors; i++ {
+				if !hasColor(img, seriesColor(i)) {
+					t.Fatalf("expected series %d to be drawn", i)
+				}
+			}
+		})
+	}
+}
+
+func TestRender_Table(t *testing.T) {
+	data, err := Render(Spec{
+		Type:    TypeTable,
+		Title:   "Deployments",
+		Columns: []string{"Service", "Version", "Status"},
+		Rows:    [][]string{{"api", "v1.2.3", "ok"}, {"web", "v2.0.0"}},
+	})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	img := decode(t, data)
+	if img.Bounds().Dx() < MinSize || img.Bounds().Dx() >= DefaultWidth || img.Bounds().Dy() < 3*rowHeight {
+		t.Fatalf("expected a table sized to its content, got %v", img.Bounds())
+	}
+	if !hasColor(img, headerFill) {
+		t.Fatal("expected a header row")
+	}
+}
+
+func TestValidate(t *testing.T) {
+	labels := []string{"a", "b"}
+	tests := []struct {
+		name    string
+		spec    Spec
+		wantErr string
+	}{
+		{name: "unknown type", spec: Spec{Type: "scatter"}, wantErr: "type must be one of"},
+		{name: "no labels", spec: Spec{Type: TypeLine, Series: []Series{{Values: []float64{1}}}}, wantErr: "1 to 100 labels"},
+		{name: "no series", spec: Spec{Type: TypeBar, Labels: labels}, wantErr: "1 to 8 series"},
+		{name: "length mismatch", spec: Spec{Type: TypeBar, Labels: labels, Series: []Series{{Values: []float64{1}}}}, wantErr: "series[0] has 1 values but there are 2 labels"},
+		{name: "not finite", spec: Spec{Type: TypeLine, Labels: labels, Series: []Series{{Values: []float64{1, math.Inf(1)}}}}, wantErr: "not a finite number"},
+		{name: "pie with two series", spec: Spec{Type: TypePie, Labels: labels, Series: []Series{{Values: []float64{1, 2}}, {Values: []float64{1, 2}}}}, wantErr: "1 to 1 series"},
+		{name: "negative pie value", spec: Spec{Type: TypePie, Labels: labels, Series: []Series{{Values: []float64{1, -2}}}}, wantErr: "must not be negative"},
+		{name: "zero pie", spec: Spec{Type: TypePie, Labels: labels, Series: []Series{{Values: []float64{0, 0}}}}, wantErr: "must not all be zero"},
+		{name: "table without rows", spec: Spec{Type: TypeTable, Columns: []string{"a"}}, wantErr: "1 to 50 rows"},
+		{name: "row too wide", spec: Spec{Type: TypeTable, Columns: []string{"a"}, Rows: [][]string{{"1", "2"}}}, wantErr: "rows[0] has 2 cells"},
+		{name: "too small", spec: Spec{Type: TypeBar, Labels: labels, Series: []Series{{Values: []float64{1, 2}}}, Width: 100}, wantErr: "width must be between"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.spec.Validate(); err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("expected error containing %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestNiceScale(t *testing.T) {
+	tests := []struct {
+		lo, hi             float64
+		wantLo, wantHi, st float64
+	}{
+		{lo: 0, hi: 97, wantLo: 0, wantHi: 100, st: 20},
+		{lo: -3, hi: 7, wantLo: -4, wantHi: 8, st: 2},
+		{lo: 0, hi: 0, wantLo: 0, wantHi: 1, st: 0.2},
+	}
+	for _, tt := range tests {
+		lo, hi, step := niceScale(tt.lo, tt.hi, 5)
+		if lo != tt.wantLo || hi != tt.wantHi || step != tt.st {
+			t.Fatalf("niceScale(%v, %v) = %v, %v, %v; want %v, %v, %v", tt.lo, tt.hi, lo, hi, step, tt.wantLo, tt.wantHi, tt.st)
+		}
+	}
+}
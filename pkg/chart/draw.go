@@ -0,0 +1,254 @@
+package chart
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+	"math"
+
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/basicfont"
+	"golang.org/x/image/math/fixed"
+)
+
+// Colors used by all charts
+var (
+	background = color.RGBA{0xff, 0xff, 0xff, 0xff}
+	foreground = color.RGBA{0x33, 0x33, 0x33, 0xff}
+	gridColor  = color.RGBA{0xe0, 0xe0, 0xe0, 0xff}
+	headerFill = color.RGBA{0xf0, 0xf0, 0xf0, 0xff}
+
+	// palette holds the series colors, repeated when there are more series
+	palette = []color.RGBA{
+		{0x41, 0x7e, 0xe0, 0xff},
+		{0xf0, 0x8c, 0x2e, 0xff},
+		{0x3d, 0xa8, 0x5a, 0xff},
+		{0xd6, 0x45, 0x45, 0xff},
+		{0x8e, 0x6b, 0xc8, 0xff},
+		{0x2d, 0xb4, 0xb4, 0xff},
+		{0xc8, 0xa0, 0x28, 0xff},
+		{0x8c, 0x8c, 0x8c, 0xff},
+	}
+)
+
+// face is the bitmap font used for all text
+var face = basicfont.Face7x13
+
+// Text metrics of face, in pixels
+const (
+	charWidth  = 7
+	lineHeight = 13
+	padding    = 12
+)
+
+// canvas is an image with drawing helpers
+type canvas struct {
+	*image.RGBA
+}
+
+// newCanvas creates a canvas filled with the background color
+func newCanvas(width, height int) canvas {
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	draw.Draw(img, img.Bounds(), image.NewUniform(background), image.Point{}, draw.Src)
+	return canvas{img}
+}
+
+// fillRect fills the rectangle from (x0, y0) to (x1, y1), in any corner order
+func (c canvas) fillRect(x0, y0, x1, y1 int, col color.Color) {
+	r := image.Rect(x0, y0, x1, y1).Canon().Intersect(c.Bounds())
+	draw.Draw(c.RGBA, r, image.NewUniform(col), image.Point{}, draw.Src)
+}
+
+// hline draws a horizontal line from x0 to x1 inclusive
+func (c canvas) hline(x0, x1, y int, col color.Color) {
+	c.fillRect(min(x0, x1), y, max(x0, x1)+1, y+1, col)
+}
+
+// vline draws a vertical line from y0 to y1 inclusive
+func (c canvas) vline(x, y0, y1 int, col color.Color) {
+	c.fillRect(x, min(y0, y1), x+1, max(y0, y1)+1, col)
+}
+
+// line draws a line of the given thickness between two points
+func (c canvas) line(x0, y0, x1, y1, thickness int, col color.Color) {
+	dx, dy := abs(x1-x0), -abs(y1-y0)
+	sx, sy := sign(x1-x0), sign(y1-y0)
+	err := dx + dy
+	half := thickness / 2
+	for {
+		c.fillRect(x0-half, y0-half, x0-half+thickness, y0-half+thickness, col)
+		if x0 == x1 && y0 == y1 {
+			return
+		}
+		e2 := 2 * err
+		if e2 >= dy {
+			err += dy
+			x0 += sx
+		}
+		if e2 <= dx {
+			err += dx
+			y0 += sy
+		}
+	}
+}
+
+// text draws s with its top-left corner at (x, y)
+func (c canvas) text(x, y int, s string, col color.Color) {
+	d := font.Drawer{
+		Dst:  c.RGBA,
+		Src:  image.NewUniform(col),
+		Face: face,
+		Dot:  fixed.P(x, y+face.Ascent),
+	}
+	d.DrawString(s)
+}
+
+// textRight draws s right-aligned to x
+func (c canvas) textRight(x, y int, s string, col color.Color) {
+	c.text(x-textWidth(s), y, s, col)
+}
+
+// textCenter draws s centered on x
+func (c canvas) textCenter(x, y int, s string, col color.Color) {
+	c.text(x-textWidth(s)/2, y, s, col)
+}
+
+// textWidth returns the width of s in pixels
+func textWidth(s string) int {
+	return len([]rune(s)) * charWidth
+}
+
+// truncate shortens s to at most n runes, marking the cut with "..."
+func truncate(s string, n int) string {
+	runes := []rune(s)
+	if len(runes) <= n {
+		return s
+	}
+	if n <= 3 {
+		return string(runes[:n])
+	}
+	return string(runes[:n-3]) + "..."
+}
+
+// seriesColor returns the color of the i-th series or slice
+func seriesColor(i int) color.RGBA {
+	return palette[i%len(palette)]
+}
+
+// drawTitle draws the title centered at the top and returns the y coordinate
+// below it
+func (c canvas) drawTitle(title string) int {
+	if title == "" {
+		return padding
+	}
+	width := c.Bounds().Dx()
+	c.textCenter(width/2, padding, truncate(title, (width-2*padding)/charWidth), foreground)
+	return padding + lineHeight + padding
+}
+
+// legendItem is a colored entry of a legend
+type legendItem struct {
+	label string
+	color color.RGBA
+}
+
+// legendHeight returns the height of a legend laid out in rows across width
+func legendHeight(items []legendItem, width int) int {
+	if len(items) == 0 {
+		return 0
+	}
+	rows := len(layoutLegend(items, width))
+	return rows*(lineHeight+4) + padding
+}
+
+// layoutLegend splits legend items into rows that fit in width
+func layoutLegend(items []legendItem, width int) [][]legendItem {
+	var rows [][]legendItem
+	var row []legendItem
+	used := 0
+	for _, item := range items {
+		w := legendItemWidth(item)
+		if len(row) > 0 && used+w > width {
+			rows = append(rows, row)
+			row, used = nil, 0
+		}
+		row = append(row, item)
+		used += w
+	}
+	return append(rows, row)
+}
+
+// legendItemWidth returns the width of a legend entry including its swatch
+func legendItemWidth(item legendItem) int {
+	return lineHeight + 4 + textWidth(item.label) + 16
+}
+
+// drawLegend draws a legend centered horizontally with its top at y
+func (c canvas) drawLegend(items []legendItem, y int) {
+	width := c.Bounds().Dx()
+	for _, row := range layoutLegend(items, width-2*padding) {
+		rowWidth := 0
+		for _, item := range row {
+			rowWidth += legendItemWidth(item)
+		}
+		x := (width - rowWidth) / 2
+		for _, item := range row {
+			c.fillRect(x, y+1, x+lineHeight-2, y+lineHeight-1, item.color)
+			c.text(x+lineHeight+2, y, item.label, foreground)
+			x += legendItemWidth(item)
+		}
+		y += lineHeight + 4
+	}
+}
+
+// niceScale returns rounded axis bounds and a tick step covering lo to hi
+// with about n ticks
+func niceScale(lo, hi float64, n int) (float64, float64, float64) {
+	if lo == hi {
+		if lo == 0 {
+			hi = 1
+		} else {
+			lo, hi = math.Min(0, lo), math.Max(0, hi)
+		}
+	}
+	step := niceNumber((hi - lo) / float64(n))
+	return math.Floor(lo/step) * step, math.Ceil(hi/step) * step, step
+}
+
+// niceNumber rounds x to 1, 2, 2.5 or 5 times a power of ten
+func niceNumber(x float64) float64 {
+	exp := math.Floor(math.Log10(x))
+	frac := x / math.Pow(10, exp)
+	var nice float64
+	switch {
+	case frac <= 1:
+		nice = 1
+	case frac <= 2:
+		nice = 2
+	case frac <= 2.5:
+		nice = 2.5
+	case frac <= 5:
+		nice = 5
+	default:
+		nice = 10
+	}
+	return nice * math.Pow(10, exp)
+}
+
+func abs(x int) int {
+	if x < 0 {
+		return -x
+	}
+	return x
+}
+
+func sign(x int) int {
+	switch {
+	case x > 0:
+		return 1
+	case x < 0:
+		return -1
+	default:
+		return 0
+	}
+}
@@ -0,0 +1,247 @@
+package chart
+
+import (
+	"fmt"
+	"image"
+	"math"
+	"strconv"
+)
+
+// Table layout limits
+const (
+	maxCellRunes = 40
+	rowHeight    = lineHeight + 8
+)
+
+// renderXY renders a line or bar chart
+func renderXY(spec Spec) *image.RGBA {
+	width, height := spec.size()
+	c := newCanvas(width, height)
+	top := c.drawTitle(spec.Title)
+
+	legend := seriesLegend(spec.Series)
+	legendTop := height - legendHeight(legend, width-2*padding)
+	c.drawLegend(legend, legendTop)
+
+	lo, hi := 0.0, 0.0
+	for _, series := range spec.Series {
+		for _, v := range series.Values {
+			lo, hi = math.Min(lo, v), math.Max(hi, v)
+		}
+	}
+	lo, hi, step := niceScale(lo, hi, 5)
+	decimals := max(0, int(-math.Floor(math.Log10(step)+1e-9)))
+	if step != math.Trunc(step) && decimals == 0 {
+		decimals = 1
+	}
+
+	tickLabels := make([]string, 0)
+	labelWidth := 0
+	for v := lo; v <= hi+step/2; v += step {
+		label := strconv.FormatFloat(v, 'f', decimals, 64)
+		tickLabels = append(tickLabels, label)
+		labelWidth = max(labelWidth, textWidth(label))
+	}
+
+	left := padding + labelWidth + 6
+	right := width - padding - charWidth
+	bottom := legendTop - lineHeight - 8
+	if bottom-top < lineHeight*2 || right-left < charWidth*4 {
+		return c.RGBA
+	}
+	plotHeight := float64(bottom - top)
+	yOf := func(v float64) int {
+		return bottom - int(math.Round((v-lo)/(hi-lo)*plotHeight))
+	}
+
+	// Grid lines and y-axis labels
+	for i, label := range tickLabels {
+		y := yOf(lo + float64(i)*step)
+		c.hline(left, right, y, gridColor)
+		c.textRight(left-6, y-lineHeight/2, label, foreground)
+	}
+	c.vline(left, top, bottom, foreground)
+	c.hline(left, right, yOf(math.Max(lo, math.Min(0, hi))), foreground)
+
+	// Categories are centered in equal slots, which keeps the labels of the
+	// first and last points inside the image
+	n := len(spec.Labels)
+	slot := float64(right-left) / float64(n)
+	xOf := func(i int) int {
+		return left + int(math.Round((float64(i)+0.5)*slot))
+	}
+	maxLabel := max(1, int(slot)/charWidth)
+	every := 1
+	if maxLabel < 4 {
+		// Too narrow for every label: show every few, at least 8 characters wide
+		maxLabel = 8
+		every = int(math.Ceil(float64(maxLabel*charWidth+charWidth) / slot))
+	}
+	for i, label := range spec.Labels {
+		if i%every == 0 {
+			c.textCenter(xOf(i), bottom+4, truncate(label, maxLabel), foreground)
+		}
+	}
+
+	zero := yOf(math.Max(lo, math.Min(0, hi)))
+	switch spec.Type {
+	case TypeBar:
+		groupWidth := slot * 0.8
+		barWidth := max(1, int(groupWidth/float64(len(spec.Series))))
+		for s, series := range spec.Series {
+			for i, v := range series.Values {
+				x := xOf(i) - int(groupWidth/2) + s*barWidth
+				c.fillRect(x, zero, x+max(1, barWidth-1), yOf(v), seriesColor(s))
+			}
+		}
+	case TypeLine:
+		for s, series := range spec.Series {
+			col := seriesColor(s)
+			for i, v := range series.Values {
+				x, y := xOf(i), yOf(v)
+				if i > 0 {
+					c.line(xOf(i-1), yOf(series.Values[i-1]), x, y, 2, col)
+				}
+				c.fillRect(x-2, y-2, x+3, y+3, col)
+			}
+		}
+	}
+	return c.RGBA
+}
+
+// seriesLegend returns the legend entries of named series. A single unnamed
+// series has no legend.
+func seriesLegend(series []Series) []legendItem {
+	if len(series) == 1 && series[0].Name == "" {
+		return nil
+	}
+	items := make([]legendItem, 0, len(series))
+	for i, s := range series {
+		name := s.Name
+		if name == "" {
+			name = fmt.Sprintf("Series %d", i+1)
+		}
+		items = append(items, legendItem{label: truncate(name, 30), color: seriesColor(i)})
+	}
+	return items
+}
+
+// renderPie renders a pie chart with a legend of labels and percentages
+func renderPie(spec Spec) *image.RGBA {
+	width, height := spec.size()
+	c := newCanvas(width, height)
+	top := c.drawTitle(spec.Title)
+
+	values := spec.Series[0].Values
+	var total float64
+	for _, v := range values {
+		total += v
+	}
+
+	legend := make([]legendItem, 0, len(values))
+	for i, label := range spec.Labels {
+		percent := values[i] / total * 100
+		legend = append(legend, legendItem{
+			label: fmt.Sprintf("%s (%s%%)", truncate(label, 30), strconv.FormatFloat(percent, 'f', 1, 64)),
+			color: seriesColor(i),
+		})
+	}
+	legendTop := height - legendHeight(legend, width-2*padding)
+	c.drawLegend(legend, legendTop)
+
+	radius := min(width-2*padding, legendTop-top-padding) / 2
+	if radius <= 0 {
+		return c.RGBA
+	}
+	cx, cy := width/2, top+radius
+
+	// Slice boundaries as fractions of a full turn, clockwise from 12 o'clock
+	bounds := make([]float64, len(values))
+	var sum float64
+	for i, v := range values {
+		sum += v
+		bounds[i] = sum / total
+	}
+	for y := cy - radius; y <= cy+radius; y++ {
+		for x := cx - radius; x <= cx+radius; x++ {
+			dx, dy := float64(x-cx), float64(y-cy)
+			if dx*dx+dy*dy > float64(radius*radius) {
+				continue
+			}
+			turn := math.Atan2(dx, -dy) / (2 * math.Pi)
+			if turn < 0 {
+				turn++
+			}
+			slice := len(bounds) - 1
+			for i, bound := range bounds {
+				if turn < bound {
+					slice = i
+					break
+				}
+			}
+			c.SetRGBA(x, y, seriesColor(slice))
+		}
+	}
+	return c.RGBA
+}
+
+// renderTable renders a table sized to fit its content
+func renderTable(spec Spec) *image.RGBA {
+	columns := len(spec.Columns)
+	widths := make([]int, columns)
+	cell := func(row []string, i int) string {
+		if i < len(row) {
+			return truncate(row[i], maxCellRunes)
+		}
+		return ""
+	}
+	for i, column := range spec.Columns {
+		widths[i] = textWidth(truncate(column, maxCellRunes))
+		for _, row := range spec.Rows {
+			widths[i] = max(widths[i], textWidth(cell(row, i)))
+		}
+		widths[i] += 2 * 8
+	}
+
+	tableWidth := 0
+	for _, w := range widths {
+		tableWidth += w
+	}
+	titleWidth := textWidth(truncate(spec.Title, maxTitleRunes)) + 2*padding
+	width := min(MaxSize, max(MinSize, tableWidth+2*padding, titleWidth))
+	titleHeight := 0
+	if spec.Title != "" {
+		titleHeight = lineHeight + padding
+	}
+	height := min(MaxSize, max(MinSize/2, padding+titleHeight+(len(spec.Rows)+1)*rowHeight+padding))
+
+	c := newCanvas(width, height)
+	top := c.drawTitle(spec.Title)
+	left := max(padding, (width-tableWidth)/2)
+	right := min(width-padding, left+tableWidth)
+
+	// Header, then one line per row
+	c.fillRect(left, top, right, top+rowHeight, headerFill)
+	y := top
+	for r := -1; r < len(spec.Rows); r++ {
+		row := spec.Columns
+		if r >= 0 {
+			row = spec.Rows[r]
+		}
+		x := left
+		for i := range spec.Columns {
+			c.text(x+8, y+4, cell(row, i), foreground)
+			x += widths[i]
+		}
+		c.hline(left, right, y, gridColor)
+		y += rowHeight
+	}
+	c.hline(left, right, y, gridColor)
+	x := left
+	for _, w := range widths {
+		c.vline(x, top, y, gridColor)
+		x += w
+	}
+	c.vline(min(x, right), top, y, gridColor)
+	return c.RGBA
+}
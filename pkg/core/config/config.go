@@ -2,7 +2,12 @@ package config
 
 import (
 	"fmt"
+	"net/url"
+	"path"
+	"regexp"
+	"slices"
 	"strings"
+	"time"
 
 	"github.com/spf13/viper"
 )
@@ -18,13 +23,525 @@ type StaticConfig struct {
 	LogLevel int `mapstructure:"log_level"`
 
 	// WeCom Bot configuration
-	WeComBotKey string `mapstructure:"wecom_bot_key"`
+	WeComBotKey string `mapstructure:"wecom_bot_key" secret:"true"`
+
+	// WeCom API connection configuration
+	WeComAPI WeComAPI `mapstructure:"wecom_api"`
+
+	// RequireConfirmation holds every outgoing message until a human approves it
+	RequireConfirmation bool `mapstructure:"require_confirmation"`
+
+	// DirectoryFile is a YAML or CSV user directory used to resolve mentions by name
+	DirectoryFile string `mapstructure:"directory_file"`
+
+	// ScheduleFile persists scheduled messages across restarts. Empty keeps them in memory only.
+	ScheduleFile string `mapstructure:"schedule_file"`
+
+	// MediaFile persists uploaded media_ids until they expire. Empty keeps them in memory only.
+	MediaFile string `mapstructure:"media_file"`
+
+	// Content policy configuration
+	ContentPolicy ContentPolicy `mapstructure:"content_policy"`
+
+	// URL policy configuration
+	URLPolicy URLPolicy `mapstructure:"url_policy"`
+
+	// Quiet hours configuration
+	QuietHours QuietHours `mapstructure:"quiet_hours"`
+
+	// Digest configuration
+	Digest Digest `mapstructure:"digest"`
+
+	// Sent-message history configuration
+	History History `mapstructure:"history"`
+
+	// Inbound webhook configuration
+	Hooks Hooks `mapstructure:"hooks"`
+
+	// REST API configuration
+	API API `mapstructure:"api"`
+
+	// Receive-side callback configuration
+	Callback Callback `mapstructure:"callback"`
 
 	// Tool configuration
 	EnabledTools  []string `mapstructure:"enabled_tools"`
 	DisabledTools []string `mapstructure:"disabled_tools"`
 }
 
+// DefaultWeComBaseURL is the WeCom API endpoint used when wecom_api.base_url is empty
+const DefaultWeComBaseURL = "https://qyapi.weixin.qq.com"
+
+// WeComAPI configures how the WeCom webhook API is reached
+type WeComAPI struct {
+	// BaseURL is the scheme and host of the WeCom API, e.g. a local fake server. Empty uses DefaultWeComBaseURL.
+	BaseURL string `mapstructure:"base_url"`
+
+	// Proxy is an http, https or socks5 proxy URL. Empty uses HTTP_PROXY, HTTPS_PROXY and NO_PROXY.
+	Proxy string `mapstructure:"proxy" secret:"true"`
+
+	// Timeout limits each API request. Zero uses a 30 second timeout.
+	Timeout time.Duration `mapstructure:"timeout"`
+
+	// CAFile is a PEM bundle of additional CA certificates to trust, e.g. for a TLS-intercepting proxy
+	CAFile string `mapstructure:"ca_file"`
+
+	// Connection pool sizes. Zero keeps the Go defaults.
+	MaxIdleConns        int           `mapstructure:"max_idle_conns"`
+	MaxIdleConnsPerHost int           `mapstructure:"max_idle_conns_per_host"`
+	MaxConnsPerHost     int           `mapstructure:"max_conns_per_host"`
+	IdleConnTimeout     time.Duration `mapstructure:"idle_conn_timeout"`
+}
+
+// Validate validates the WeCom API settings
+func (a *WeComAPI) Validate() error {
+	if a.BaseURL != "" {
+		u, err := url.Parse(a.BaseURL)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return fmt.Errorf("wecom_api.base_url must be an http or https URL, got %q", a.BaseURL)
+		}
+	}
+	if a.Proxy != "" {
+		u, err := url.Parse(a.Proxy)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https" && u.Scheme != "socks5") || u.Host == "" {
+			// The proxy URL may contain credentials, so it is not included in the error
+			return fmt.Errorf("wecom_api.proxy must be an http, https or socks5 URL")
+		}
+	}
+	if a.Timeout < 0 {
+		return fmt.Errorf("wecom_api.timeout must not be negative, got %s", a.Timeout)
+	}
+	if a.IdleConnTimeout < 0 {
+		return fmt.Errorf("wecom_api.idle_conn_timeout must not be negative, got %s", a.IdleConnTimeout)
+	}
+	for _, pool := range []struct {
+		key   string
+		value int
+	}{
+		{key: "max_idle_conns", value: a.MaxIdleConns},
+		{key: "max_idle_conns_per_host", value: a.MaxIdleConnsPerHost},
+		{key: "max_conns_per_host", value: a.MaxConnsPerHost},
+	} {
+		if pool.value < 0 {
+			return fmt.Errorf("wecom_api.%s must not be negative, got %d", pool.key, pool.value)
+		}
+	}
+	return nil
+}
+
+// BaseURLOrDefault returns the configured base URL or DefaultWeComBaseURL
+func (a *WeComAPI) BaseURLOrDefault() string {
+	if a.BaseURL == "" {
+		return DefaultWeComBaseURL
+	}
+	return strings.TrimSuffix(a.BaseURL, "/")
+}
+
+// Content policy actions
+const (
+	ContentActionRedact = "redact"
+	ContentActionReject = "reject"
+	ContentActionWarn   = "warn"
+)
+
+// ContentPolicy configures the content filter applied before every send
+type ContentPolicy struct {
+	// Rules are custom rules. A rule named after a built-in detector overrides it.
+	Rules []ContentRule `mapstructure:"rules"`
+
+	// DisabledRules lists rule names (built-in or custom) to skip
+	DisabledRules []string `mapstructure:"disabled_rules"`
+}
+
+// ContentRule is a single regex-based content filter rule
+type ContentRule struct {
+	Name        string `mapstructure:"name"`
+	Pattern     string `mapstructure:"pattern"`
+	Action      string `mapstructure:"action"`
+	Replacement string `mapstructure:"replacement"`
+}
+
+// Validate validates the content policy rules
+func (p *ContentPolicy) Validate() error {
+	for i, rule := range p.Rules {
+		if rule.Name == "" {
+			return fmt.Errorf("content_policy.rules[%d].name is required", i)
+		}
+		switch rule.Action {
+		case ContentActionRedact, ContentActionReject, ContentActionWarn:
+		default:
+			return fmt.Errorf("content_policy.rules[%d].action must be one of redact, reject, warn, got %q", i, rule.Action)
+		}
+		if rule.Pattern != "" {
+			if _, err := regexp.Compile(rule.Pattern); err != nil {
+				return fmt.Errorf("content_policy.rules[%d].pattern is invalid: %w", i, err)
+			}
+		}
+	}
+	return nil
+}
+
+// URLPolicy configures which domains message links, images and card actions may point to
+type URLPolicy struct {
+	// AllowedDomains restricts URLs to these domains and their subdomains. Empty allows any domain.
+	AllowedDomains []string `mapstructure:"allowed_domains"`
+
+	// DeniedDomains rejects URLs on these domains and their subdomains
+	DeniedDomains []string `mapstructure:"denied_domains"`
+}
+
+// Validate validates the URL policy domain lists
+func (p *URLPolicy) Validate() error {
+	if err := validateDomains("url_policy.allowed_domains", p.AllowedDomains); err != nil {
+		return err
+	}
+	return validateDomains("url_policy.denied_domains", p.DeniedDomains)
+}
+
+// validateDomains checks that every entry is a bare domain name
+func validateDomains(field string, domains []string) error {
+	for i, domain := range domains {
+		if domain == "" || strings.ContainsAny(domain, "/:") {
+			return fmt.Errorf("%s[%d] must be a bare domain name, got %q", field, i, domain)
+		}
+	}
+	return nil
+}
+
+// Quiet hours actions
+const (
+	QuietHoursActionDefer  = "defer"
+	QuietHoursActionReject = "reject"
+)
+
+// QuietHours configures when non-urgent messages are held back
+type QuietHours struct {
+	// Timezone is an IANA time zone name used to evaluate windows and holidays. Empty uses the local time zone.
+	Timezone string `mapstructure:"timezone"`
+
+	// Windows are recurring quiet periods
+	Windows []QuietWindow `mapstructure:"windows"`
+
+	// HolidaysFile lists whole days (one YYYY-MM-DD per line) that are quiet
+	HolidaysFile string `mapstructure:"holidays_file"`
+
+	// Action is what happens to non-urgent messages during quiet hours: defer (default) or reject
+	Action string `mapstructure:"action"`
+}
+
+// QuietWindow is a recurring quiet period. An end at or before the start wraps past midnight.
+type QuietWindow struct {
+	// Days is a list of days or ranges such as "mon-fri" or "sat,sun". Empty means every day.
+	Days  string `mapstructure:"days"`
+	Start string `mapstructure:"start"`
+	End   string `mapstructure:"end"`
+}
+
+// Enabled reports whether any quiet hours are configured
+func (q *QuietHours) Enabled() bool {
+	return len(q.Windows) > 0 || q.HolidaysFile != ""
+}
+
+// Validate validates the quiet hours settings that do not need parsing
+func (q *QuietHours) Validate() error {
+	switch q.Action {
+	case "", QuietHoursActionDefer, QuietHoursActionReject:
+	default:
+		return fmt.Errorf("quiet_hours.action must be one of defer, reject, got %q", q.Action)
+	}
+	for i, window := range q.Windows {
+		if window.Start == "" || window.End == "" {
+			return fmt.Errorf("quiet_hours.windows[%d] requires start and end", i)
+		}
+	}
+	return nil
+}
+
+// Digest configures batching of text and markdown messages into a single markdown message
+type Digest struct {
+	// Window is how long messages are buffered after the first one arrives. Zero disables digest mode.
+	Window time.Duration `mapstructure:"window"`
+}
+
+// History configures the bounded store of sent messages
+type History struct {
+	// Size is the number of messages kept per bot. Zero keeps the last 200.
+	Size int `mapstructure:"size"`
+
+	// File persists the history across restarts. Empty keeps it in memory only.
+	File string `mapstructure:"file"`
+}
+
+// Hook message types
+const (
+	HookMessageText           = "text"
+	HookMessageMarkdown       = "markdown"
+	HookMessageNews           = "news"
+	HookMessageTextNoticeCard = "text_notice_card"
+	HookMessageNewsNoticeCard = "news_notice_card"
+)
+
+// Repository events relayed by the GitHub and GitLab hooks
+const (
+	RepoEventPush        = "push"
+	RepoEventPullRequest = "pull_request"
+	RepoEventRelease     = "release"
+	RepoEventWorkflow    = "workflow"
+)
+
+// RepoEvents lists the repository events the GitHub and GitLab hooks relay
+var RepoEvents = []string{RepoEventPush, RepoEventPullRequest, RepoEventRelease, RepoEventWorkflow}
+
+// DefaultSignatureHeader is the header that carries the HMAC signature of a
+// generic webhook request
+const DefaultSignatureHeader = "X-Signature-256"
+
+// hookEndpointName matches the names of generic webhook endpoints
+var hookEndpointName = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]*$`)
+
+// Hooks configures inbound webhooks, served in HTTP mode, that relay events
+// from other systems to WeCom
+type Hooks struct {
+	// Alertmanager relays Prometheus Alertmanager notifications
+	Alertmanager AlertmanagerHook `mapstructure:"alertmanager"`
+
+	// GitHub relays GitHub repository webhooks
+	GitHub RepoHook `mapstructure:"github"`
+
+	// GitLab relays GitLab project webhooks
+	GitLab RepoHook `mapstructure:"gitlab"`
+
+	// Endpoints are generic /hooks/{name} endpoints that map any JSON body
+	// to a message
+	Endpoints []HookEndpoint `mapstructure:"endpoints"`
+}
+
+// RepoHook configures the /hooks/github or /hooks/gitlab endpoint
+type RepoHook struct {
+	Enabled bool `mapstructure:"enabled"`
+
+	// Secret is the webhook secret: the HMAC key of GitHub signatures, or the
+	// token GitLab sends in X-Gitlab-Token
+	Secret string `mapstructure:"secret" secret:"true"`
+
+	// MessageType is markdown (default) or news
+	MessageType string `mapstructure:"message_type"`
+
+	// Events are the events to relay: push, pull_request, release and
+	// workflow. Empty relays all of them.
+	Events []string `mapstructure:"events"`
+
+	// Routes pick the repositories to relay and their events. Empty relays
+	// every repository.
+	Routes []RepoRoute `mapstructure:"routes"`
+}
+
+// RepoRoute selects the repositories whose events are relayed
+type RepoRoute struct {
+	// Repo is an owner/name pattern such as "acme/*"
+	Repo string `mapstructure:"repo"`
+
+	// Events overrides the hook's events for these repositories
+	Events []string `mapstructure:"events"`
+}
+
+// Validate validates a GitHub or GitLab hook, named by key
+func (h *RepoHook) Validate(key string) error {
+	if !h.Enabled {
+		return nil
+	}
+	if h.Secret == "" {
+		return fmt.Errorf("%s.secret is required", key)
+	}
+	switch h.MessageType {
+	case "", HookMessageMarkdown, HookMessageNews:
+	default:
+		return fmt.Errorf("%s.message_type must be one of markdown, news, got %q", key, h.MessageType)
+	}
+	if err := validateRepoEvents(key+".events", h.Events); err != nil {
+		return err
+	}
+	for i, route := range h.Routes {
+		routeKey := fmt.Sprintf("%s.routes[%d]", key, i)
+		if route.Repo == "" {
+			return fmt.Errorf("%s.repo is required", routeKey)
+		}
+		if _, err := path.Match(route.Repo, ""); err != nil {
+			return fmt.Errorf("%s.repo %q is not a valid pattern: %w", routeKey, route.Repo, err)
+		}
+		if err := validateRepoEvents(routeKey+".events", route.Events); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// validateRepoEvents checks that events are known repository events
+func validateRepoEvents(key string, events []string) error {
+	for _, event := range events {
+		if !slices.Contains(RepoEvents, event) {
+			return fmt.Errorf("%s must contain only %s, got %q", key, strings.Join(RepoEvents, ", "), event)
+		}
+	}
+	return nil
+}
+
+// AlertmanagerHook configures the /hooks/alertmanager/{bot} endpoint
+type AlertmanagerHook struct {
+	Enabled bool `mapstructure:"enabled"`
+
+	// Token is a shared secret that requests must send as a bearer token. Empty accepts any request.
+	Token string `mapstructure:"token" secret:"true"`
+
+	// MessageType is markdown (default) or text_notice_card
+	MessageType string `mapstructure:"message_type"`
+
+	// Template is a Go text/template for the markdown content or the card's
+	// sub-title. Empty uses the built-in template.
+	Template string `mapstructure:"template"`
+
+	// UrgentSeverities are severity label values that are sent with urgent
+	// priority, bypassing quiet hours and digest mode
+	UrgentSeverities []string `mapstructure:"urgent_severities"`
+}
+
+// Validate validates the Alertmanager hook settings that do not need parsing
+func (h *AlertmanagerHook) Validate() error {
+	switch h.MessageType {
+	case "", HookMessageMarkdown, HookMessageTextNoticeCard:
+		return nil
+	default:
+		return fmt.Errorf("hooks.alertmanager.message_type must be one of markdown, text_notice_card, got %q", h.MessageType)
+	}
+}
+
+// HookEndpoint configures a generic /hooks/{name} endpoint
+type HookEndpoint struct {
+	// Name is the last path segment of the endpoint
+	Name string `mapstructure:"name"`
+
+	// Bot is the bot that sends the messages. Empty uses the default bot.
+	Bot string `mapstructure:"bot"`
+
+	// Token is a shared secret that requests send as a bearer token
+	Token string `mapstructure:"token" secret:"true"`
+
+	// HMACSecret is a key that requests sign their body with, sending the
+	// hex HMAC-SHA256 in SignatureHeader
+	HMACSecret string `mapstructure:"hmac_secret" secret:"true"`
+
+	// SignatureHeader is the header carrying the signature. Empty uses X-Signature-256.
+	SignatureHeader string `mapstructure:"signature_header"`
+
+	// MessageType is text, markdown, news, text_notice_card or news_notice_card
+	MessageType string `mapstructure:"message_type"`
+
+	// Template is a Go text/template for the content of text and markdown messages
+	Template string `mapstructure:"template"`
+
+	// Params are further arguments of the send tool. Strings are templates,
+	// or JSONPath expressions such as "$.commits[0].url" that copy a value.
+	Params map[string]any `mapstructure:"params"`
+}
+
+// Validate validates the generic hook settings that do not need parsing
+func (e *HookEndpoint) Validate() error {
+	if !hookEndpointName.MatchString(e.Name) {
+		return fmt.Errorf("hooks.endpoints name must be lowercase letters, digits, '-' and '_', got %q", e.Name)
+	}
+	if e.Name == "alertmanager" || e.Name == "github" || e.Name == "gitlab" {
+		return fmt.Errorf("hooks.endpoints name %q is reserved", e.Name)
+	}
+	if e.Token == "" && e.HMACSecret == "" {
+		return fmt.Errorf("hooks.endpoints %s needs a token or an hmac_secret", e.Name)
+	}
+	switch e.MessageType {
+	case HookMessageText, HookMessageMarkdown:
+		if _, ok := e.Params["content"]; e.Template == "" && !ok {
+			return fmt.Errorf("hooks.endpoints %s needs a template for %s messages", e.Name, e.MessageType)
+		}
+	case HookMessageNews, HookMessageTextNoticeCard, HookMessageNewsNoticeCard:
+		if e.Template != "" {
+			return fmt.Errorf("hooks.endpoints %s: template only applies to text and markdown messages; set %s fields in params", e.Name, e.MessageType)
+		}
+	default:
+		return fmt.Errorf("hooks.endpoints %s message_type must be one of text, markdown, news, text_notice_card, news_notice_card, got %q", e.Name, e.MessageType)
+	}
+	return nil
+}
+
+// Validate validates the webhook settings that do not need parsing
+func (h *Hooks) Validate() error {
+	if err := h.Alertmanager.Validate(); err != nil {
+		return err
+	}
+	if err := h.GitHub.Validate("hooks.github"); err != nil {
+		return err
+	}
+	if err := h.GitLab.Validate("hooks.gitlab"); err != nil {
+		return err
+	}
+	names := make(map[string]bool)
+	for i := range h.Endpoints {
+		endpoint := &h.Endpoints[i]
+		if err := endpoint.Validate(); err != nil {
+			return err
+		}
+		if names[endpoint.Name] {
+			return fmt.Errorf("hooks.endpoints name %q is used more than once", endpoint.Name)
+		}
+		names[endpoint.Name] = true
+	}
+	return nil
+}
+
+// API configures the REST API, served in HTTP mode under /api/v1
+type API struct {
+	Enabled bool `mapstructure:"enabled"`
+
+	// Token is a shared secret that requests must send as a bearer token. Empty accepts any request.
+	Token string `mapstructure:"token" secret:"true"`
+}
+
+// encodingAESKeyLength is the length of a WeCom EncodingAESKey
+const encodingAESKeyLength = 43
+
+// Callback configures the WeCom smart bot callback, served in HTTP mode at
+// /callback/{bot}, that receives the messages users send to the bot
+type Callback struct {
+	Enabled bool `mapstructure:"enabled"`
+
+	// Token is the callback token set in the WeCom admin console, used to sign requests
+	Token string `mapstructure:"token" secret:"true"`
+
+	// EncodingAESKey is the 43-character key that encrypts callback messages
+	EncodingAESKey string `mapstructure:"encoding_aes_key" secret:"true"`
+
+	// ReceiveID is checked against the receiver of decrypted messages. Empty
+	// skips the check, as smart bots send none.
+	ReceiveID string `mapstructure:"receive_id"`
+
+	// Size is the number of received messages kept. Zero keeps the last 200.
+	Size int `mapstructure:"size"`
+}
+
+// Validate validates the callback settings
+func (c *Callback) Validate() error {
+	if c.Size < 0 {
+		return fmt.Errorf("callback.size must not be negative, got %d", c.Size)
+	}
+	if !c.Enabled {
+		return nil
+	}
+	if c.Token == "" {
+		return fmt.Errorf("callback.token is required")
+	}
+	if len(c.EncodingAESKey) != encodingAESKeyLength {
+		return fmt.Errorf("callback.encoding_aes_key must be %d characters, got %d", encodingAESKeyLength, len(c.EncodingAESKey))
+	}
+	return nil
+}
+
 // Validate validates the configuration
 func (c *StaticConfig) Validate() error {
 	// Validate port
@@ -42,12 +559,64 @@ func (c *StaticConfig) Validate() error {
 		return fmt.Errorf("wecom_bot_key is required")
 	}
 
+	if err := c.WeComAPI.Validate(); err != nil {
+		return err
+	}
+
+	if err := c.ContentPolicy.Validate(); err != nil {
+		return err
+	}
+
+	if err := c.URLPolicy.Validate(); err != nil {
+		return err
+	}
+
+	if err := c.QuietHours.Validate(); err != nil {
+		return err
+	}
+
+	if c.Digest.Window < 0 {
+		return fmt.Errorf("digest.window must not be negative, got %s", c.Digest.Window)
+	}
+	// Digests are flushed by a timer, with no caller left to approve them
+	if c.Digest.Window > 0 && c.RequireConfirmation {
+		return fmt.Errorf("digest.window cannot be used with require_confirmation")
+	}
+
+	if c.History.Size < 0 {
+		return fmt.Errorf("history.size must not be negative, got %d", c.History.Size)
+	}
+
+	if err := c.Hooks.Validate(); err != nil {
+		return err
+	}
+
+	if err := c.Callback.Validate(); err != nil {
+		return err
+	}
+
 	return nil
 }
 
-// LoadConfig loads configuration from file and environment variables using Viper
+// LoadConfig loads and validates configuration from file and environment variables using Viper
 // Priority: command-line flags > environment variables > config file > defaults
 func LoadConfig(configPath string) (*StaticConfig, error) {
+	config, err := ReadConfig(configPath)
+	if err != nil {
+		return nil, err
+	}
+
+	// Validate configuration
+	if err := config.Validate(); err != nil {
+		return nil, err
+	}
+
+	return config, nil
+}
+
+// ReadConfig loads configuration like LoadConfig but without validating it,
+// for commands that do not need a complete configuration
+func ReadConfig(configPath string) (*StaticConfig, error) {
 	// Use the global viper instance to access bound command-line flags
 	v := viper.GetViper()
 
@@ -62,21 +631,24 @@ func LoadConfig(configPath string) (*StaticConfig, error) {
 
 	// Configure environment variable support
 	// Environment variables use WECOM_MCP_ prefix and replace - with _
-	v.SetEnvPrefix("WECOM_MCP")
+	v.SetEnvPrefix(EnvPrefix)
 	v.AllowEmptyEnv(true)
 	v.SetEnvKeyReplacer(strings.NewReplacer("-", "_", ".", "_"))
 	v.AutomaticEnv()
 
+	// Bind every key explicitly so that nested keys, which viper only knows
+	// about once they appear in the config file, can also be set from the environment
+	for _, key := range envKeys() {
+		if err := v.BindEnv(key); err != nil {
+			return nil, fmt.Errorf("failed to bind environment variable for %s: %w", key, err)
+		}
+	}
+
 	// Unmarshal configuration into struct
 	config := &StaticConfig{}
 	if err := v.Unmarshal(config); err != nil {
 		return nil, fmt.Errorf("failed to unmarshal config: %w", err)
 	}
 
-	// Validate configuration
-	if err := config.Validate(); err != nil {
-		return nil, err
-	}
-
 	return config, nil
 }
@@ -0,0 +1,198 @@
+package config
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// EnvPrefix is the prefix of environment variables that set configuration keys
+const EnvPrefix = "WECOM_MCP"
+
+// Field is a single configuration key and its effective value
+type Field struct {
+	// Key is the dotted configuration key, e.g. "quiet_hours.timezone"
+	Key string
+	// Value is the field value. Nested structs inside lists are converted to
+	// maps keyed by their configuration names, with secrets masked.
+	Value any
+	// Secret marks values that must not be printed in full
+	Secret bool
+}
+
+// Fields returns every configuration key with its value, in declaration
+// order. Nested structs are flattened into dotted keys; lists are single keys.
+func (c *StaticConfig) Fields() []Field {
+	var fields []Field
+	walkFields("", reflect.ValueOf(c).Elem(), func(key string, value reflect.Value, field reflect.StructField) {
+		fields = append(fields, Field{
+			Key:    key,
+			Value:  plainValue(value),
+			Secret: field.Tag.Get("secret") == "true",
+		})
+	})
+	return fields
+}
+
+// Keys returns every configuration key, in declaration order
+func Keys() []string {
+	var keys []string
+	for _, field := range (&StaticConfig{}).Fields() {
+		keys = append(keys, field.Key)
+	}
+	return keys
+}
+
+// EnvVar returns the environment variable that sets key
+func EnvVar(key string) string {
+	return EnvPrefix + "_" + strings.ToUpper(strings.NewReplacer(".", "_", "-", "_").Replace(key))
+}
+
+// envKeys returns the keys that can be set from environment variables: all
+// keys except lists of structs, which have no sensible string form
+func envKeys() []string {
+	var keys []string
+	walkFields("", reflect.ValueOf(&StaticConfig{}).Elem(), func(key string, value reflect.Value, _ reflect.StructField) {
+		if value.Kind() == reflect.Slice && value.Type().Elem().Kind() == reflect.Struct {
+			return
+		}
+		keys = append(keys, key)
+	})
+	return keys
+}
+
+// walkFields calls visit for every leaf field of a config struct
+func walkFields(prefix string, v reflect.Value, visit func(key string, value reflect.Value, field reflect.StructField)) {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		name := field.Tag.Get("mapstructure")
+		if name == "" || name == "-" {
+			continue
+		}
+		key := prefix + name
+		value := v.Field(i)
+		if value.Kind() == reflect.Struct && value.Type() != reflect.TypeOf(time.Time{}) {
+			walkFields(key+".", value, visit)
+			continue
+		}
+		visit(key, value, field)
+	}
+}
+
+// maskedSecret replaces the secrets of structs inside lists
+const maskedSecret = "********"
+
+// plainValue converts a config value to plain Go values suitable for printing,
+// using configuration names for struct fields and masking their secrets
+func plainValue(v reflect.Value) any {
+	switch v.Kind() {
+	case reflect.Struct:
+		m := make(map[string]any)
+		t := v.Type()
+		for i := 0; i < t.NumField(); i++ {
+			field := t.Field(i)
+			name := field.Tag.Get("mapstructure")
+			if name == "" || name == "-" {
+				continue
+			}
+			if field.Tag.Get("secret") == "true" && !v.Field(i).IsZero() {
+				m[name] = maskedSecret
+				continue
+			}
+			m[name] = plainValue(v.Field(i))
+		}
+		return m
+	case reflect.Slice:
+		if v.IsNil() {
+			return nil
+		}
+		list := make([]any, v.Len())
+		for i := range list {
+			list[i] = plainValue(v.Index(i))
+		}
+		return list
+	default:
+		if d, ok := v.Interface().(time.Duration); ok {
+			return d.String()
+		}
+		return v.Interface()
+	}
+}
+
+// UnknownFileKeys returns the keys in a YAML config file that do not match any
+// configuration key, such as misspelled options
+func UnknownFileKeys(path string) ([]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config file: %w", err)
+	}
+	defer f.Close()
+
+	var raw map[string]any
+	if err := yaml.NewDecoder(f).Decode(&raw); err != nil && err != io.EOF {
+		return nil, fmt.Errorf("failed to parse config file: %w", err)
+	}
+
+	var unknown []string
+	collectUnknownKeys("", raw, reflect.TypeOf(StaticConfig{}), &unknown)
+	sort.Strings(unknown)
+	return unknown, nil
+}
+
+// collectUnknownKeys compares a decoded YAML mapping against a config struct type
+func collectUnknownKeys(prefix string, raw map[string]any, t reflect.Type, unknown *[]string) {
+	fields := make(map[string]reflect.Type)
+	for i := 0; i < t.NumField(); i++ {
+		if name := t.Field(i).Tag.Get("mapstructure"); name != "" && name != "-" {
+			fields[name] = t.Field(i).Type
+		}
+	}
+
+	for name, value := range raw {
+		key := prefix + name
+		fieldType, ok := fields[strings.ToLower(name)]
+		if !ok {
+			*unknown = append(*unknown, key)
+			continue
+		}
+		switch {
+		case fieldType.Kind() == reflect.Struct && fieldType != reflect.TypeOf(time.Time{}):
+			if nested, ok := value.(map[string]any); ok {
+				collectUnknownKeys(key+".", nested, fieldType, unknown)
+			}
+		case fieldType.Kind() == reflect.Slice && fieldType.Elem().Kind() == reflect.Struct:
+			items, _ := value.([]any)
+			for i, item := range items {
+				if nested, ok := item.(map[string]any); ok {
+					collectUnknownKeys(fmt.Sprintf("%s[%d].", key, i), nested, fieldType.Elem(), unknown)
+				}
+			}
+		}
+	}
+}
+
+// UnknownEnvVars returns environment variables with the configuration prefix
+// that do not set any configuration key
+func UnknownEnvVars() []string {
+	known := make(map[string]bool)
+	for _, key := range envKeys() {
+		known[EnvVar(key)] = true
+	}
+
+	var unknown []string
+	for _, env := range os.Environ() {
+		name, _, _ := strings.Cut(env, "=")
+		if strings.HasPrefix(name, EnvPrefix+"_") && !known[name] {
+			unknown = append(unknown, name)
+		}
+	}
+	sort.Strings(unknown)
+	return unknown
+}
@@ -0,0 +1,113 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestFields(t *testing.T) {
+	cfg := validConfig()
+	cfg.QuietHours.Timezone = "Asia/Shanghai"
+	cfg.Digest.Window = 30 * time.Second
+
+	fields := make(map[string]Field)
+	for _, field := range cfg.Fields() {
+		fields[field.Key] = field
+	}
+
+	if !fields["wecom_bot_key"].Secret || fields["port"].Secret {
+		t.Fatalf("unexpected secret flags: %+v", fields)
+	}
+	if fields["quiet_hours.timezone"].Value != "Asia/Shanghai" {
+		t.Fatalf("expected nested key to be flattened, got %+v", fields["quiet_hours.timezone"])
+	}
+	if fields["digest.window"].Value != "30s" {
+		t.Fatalf("expected duration as string, got %v", fields["digest.window"].Value)
+	}
+	if _, ok := fields["quiet_hours"]; ok {
+		t.Fatal("expected struct keys to be flattened")
+	}
+}
+
+func TestFields_MasksSecretsInLists(t *testing.T) {
+	cfg := validConfig()
+	cfg.Hooks.Endpoints = []HookEndpoint{{Name: "ci", Token: "s3cret-token"}}
+
+	for _, field := range cfg.Fields() {
+		if field.Key != "hooks.endpoints" {
+			continue
+		}
+		endpoint := field.Value.([]any)[0].(map[string]any)
+		if endpoint["name"] != "ci" || endpoint["token"] != maskedSecret || endpoint["hmac_secret"] != "" {
+			t.Fatalf("expected only set secrets to be masked, got %v", endpoint)
+		}
+		return
+	}
+	t.Fatal("expected a hooks.endpoints field")
+}
+
+func TestEnvVar(t *testing.T) {
+	if got := EnvVar("quiet_hours.timezone"); got != "WECOM_MCP_QUIET_HOURS_TIMEZONE" {
+		t.Fatalf("unexpected env var: %s", got)
+	}
+	if slices.Contains(envKeys(), "content_policy.rules") {
+		t.Fatal("lists of structs should not be settable from the environment")
+	}
+	if !slices.Contains(envKeys(), "url_policy.allowed_domains") {
+		t.Fatal("expected string lists to be settable from the environment")
+	}
+}
+
+func TestReadConfig_NestedEnv(t *testing.T) {
+	t.Setenv("WECOM_MCP_QUIET_HOURS_TIMEZONE", "Asia/Tokyo")
+	t.Setenv("WECOM_MCP_DIGEST_WINDOW", "1m")
+
+	cfg, err := ReadConfig("")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cfg.QuietHours.Timezone != "Asia/Tokyo" || cfg.Digest.Window != time.Minute {
+		t.Fatalf("expected nested keys from the environment, got %+v %+v", cfg.QuietHours, cfg.Digest)
+	}
+}
+
+func TestUnknownFileKeys(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	content := `port: 8080
+log_levle: 3
+quiet_hours:
+  timezone: UTC
+  windos: []
+  windows:
+    - days: daily
+      start: "22:00"
+      end: "08:00"
+      stop: "09:00"
+`
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	unknown, err := UnknownFileKeys(path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := "log_levle quiet_hours.windos quiet_hours.windows[0].stop"
+	if got := strings.Join(unknown, " "); got != want {
+		t.Fatalf("unexpected unknown keys:\n got: %s\nwant: %s", got, want)
+	}
+}
+
+func TestUnknownEnvVars(t *testing.T) {
+	t.Setenv("WECOM_MCP_PORT", "8080")
+	t.Setenv("WECOM_MCP_POTR", "8080")
+
+	unknown := UnknownEnvVars()
+	if !slices.Contains(unknown, "WECOM_MCP_POTR") || slices.Contains(unknown, "WECOM_MCP_PORT") {
+		t.Fatalf("unexpected unknown env vars: %v", unknown)
+	}
+}
@@ -0,0 +1,357 @@
+package fakewecom
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"net/http"
+	"slices"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/futuretea/wecom-bot-mcp-server/pkg/core/logging"
+	"github.com/futuretea/wecom-bot-mcp-server/pkg/wecomapi"
+)
+
+// Inspection endpoints that are not part of the WeCom API
+const (
+	MessagesPath = "/messages"
+	UploadsPath  = "/uploads"
+)
+
+// DefaultRateLimit is the number of messages WeCom accepts per bot per minute
+const DefaultRateLimit = 20
+
+// maxSendBytes limits the size of a send request, enough for the largest
+// image message once base64 encoded
+const maxSendBytes = 4 << 20
+
+// Upload limits of the webhook API
+const (
+	minUploadBytes = 5
+	maxUploadBytes = 20 * 1024 * 1024
+)
+
+// Options configures a fake server
+type Options struct {
+	// Keys are the accepted webhook keys. Empty accepts any key.
+	Keys []string
+
+	// RateLimit is the number of messages accepted per key per minute before
+	// errcode 45009 is returned. Zero uses DefaultRateLimit; negative disables it.
+	RateLimit int
+
+	// Latency delays every API response
+	Latency time.Duration
+
+	// FailureRate is the probability (0 to 1) that an API call fails with FailureCode
+	FailureRate float64
+
+	// FailureCode is the error code of injected failures. Zero uses -1 (system busy).
+	FailureCode int
+}
+
+// Message is a message accepted by the fake server
+type Message struct {
+	Key        string         `json:"key"`
+	MsgType    string         `json:"msgtype"`
+	Payload    map[string]any `json:"payload"`
+	ReceivedAt time.Time      `json:"received_at"`
+}
+
+// Upload is a file accepted by the fake upload endpoint
+type Upload struct {
+	Key        string    `json:"key"`
+	MediaID    string    `json:"media_id"`
+	Type       string    `json:"type"`
+	Filename   string    `json:"filename"`
+	Data       []byte    `json:"-"`
+	Size       int       `json:"size"`
+	ReceivedAt time.Time `json:"received_at"`
+}
+
+// Server is an in-memory stand-in for the WeCom group bot webhook API. It
+// validates requests like WeCom, answers with the same error codes and
+// records every accepted message and upload.
+type Server struct {
+	opts Options
+	mux  *http.ServeMux
+	now  func() time.Time
+
+	mu       sync.Mutex
+	messages []Message
+	uploads  []Upload
+	sent     map[string][]time.Time
+	failNext []int
+	nextID   int
+}
+
+// New creates a fake server. It implements http.Handler, so it can be served
+// with httptest.NewServer or http.ListenAndServe.
+func New(opts Options) *Server {
+	if opts.RateLimit == 0 {
+		opts.RateLimit = DefaultRateLimit
+	}
+	if opts.FailureCode == 0 {
+		opts.FailureCode = wecomapi.ErrCodeSystemBusy
+	}
+
+	s := &Server{
+		opts: opts,
+		mux:  http.NewServeMux(),
+		now:  time.Now,
+		sent: make(map[string][]time.Time),
+	}
+	s.mux.HandleFunc(wecomapi.SendPath, s.handleSend)
+	s.mux.HandleFunc(wecomapi.UploadPath, s.handleUpload)
+	s.mux.HandleFunc(MessagesPath, s.handleMessages)
+	s.mux.HandleFunc(UploadsPath, s.handleUploads)
+	return s
+}
+
+// ServeHTTP implements http.Handler
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mux.ServeHTTP(w, r)
+}
+
+// Messages returns the accepted messages in the order they were received
+func (s *Server) Messages() []Message {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return slices.Clone(s.messages)
+}
+
+// Uploads returns the accepted uploads in the order they were received
+func (s *Server) Uploads() []Upload {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return slices.Clone(s.uploads)
+}
+
+// Reset forgets all recorded messages, uploads, rate limit counters and queued failures
+func (s *Server) Reset() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.messages = nil
+	s.uploads = nil
+	s.sent = make(map[string][]time.Time)
+	s.failNext = nil
+}
+
+// FailNext makes the next API calls fail with the given error codes, one code per call
+func (s *Server) FailNext(codes ...int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.failNext = append(s.failNext, codes...)
+}
+
+// handleSend implements the webhook send endpoint
+func (s *Server) handleSend(w http.ResponseWriter, r *http.Request) {
+	key, ok := s.begin(w, r)
+	if !ok {
+		return
+	}
+
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxSendBytes))
+	if err != nil {
+		reject(w, r, wecomapi.ErrCodeInvalidParameter, "failed to read request body: "+err.Error())
+		return
+	}
+	var payload map[string]any
+	if err := json.Unmarshal(body, &payload); err != nil {
+		reject(w, r, wecomapi.ErrCodeInvalidParameter, "invalid json: "+err.Error())
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if !s.allow(key) {
+		reject(w, r, wecomapi.ErrCodeRateLimited, "api freq out of limit")
+		return
+	}
+	if code, msg := validateMessage(payload, s.hasMedia); code != wecomapi.ErrCodeOK {
+		reject(w, r, code, msg)
+		return
+	}
+
+	msgType, _ := payload["msgtype"].(string)
+	s.messages = append(s.messages, Message{Key: key, MsgType: msgType, Payload: payload, ReceivedAt: s.now()})
+	logging.Info("Accepted %s message for key %s", msgType, key)
+	writeError(w, wecomapi.ErrCodeOK, "ok")
+}
+
+// handleUpload implements the webhook media upload endpoint
+func (s *Server) handleUpload(w http.ResponseWriter, r *http.Request) {
+	key, ok := s.begin(w, r)
+	if !ok {
+		return
+	}
+
+	mediaType := r.URL.Query().Get("type")
+	if mediaType != "file" && mediaType != "voice" {
+		reject(w, r, wecomapi.ErrCodeInvalidParameter, fmt.Sprintf("invalid media type %q", mediaType))
+		return
+	}
+
+	r.Body = http.MaxBytesReader(w, r.Body, maxUploadBytes+1<<20)
+	file, header, err := r.FormFile("media")
+	if err != nil {
+		reject(w, r, wecomapi.ErrCodeInvalidParameter, "media is required: "+err.Error())
+		return
+	}
+	defer file.Close()
+	data, err := io.ReadAll(file)
+	if err != nil {
+		reject(w, r, wecomapi.ErrCodeInvalidMediaSize, "failed to read media")
+		return
+	}
+	if len(data) <= minUploadBytes || len(data) > maxUploadBytes {
+		reject(w, r, wecomapi.ErrCodeInvalidMediaSize, fmt.Sprintf("media size must be between %d and %d bytes, got %d", minUploadBytes+1, maxUploadBytes, len(data)))
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.nextID++
+	upload := Upload{
+		Key:        key,
+		MediaID:    fmt.Sprintf("fake-media-%d", s.nextID),
+		Type:       mediaType,
+		Filename:   header.Filename,
+		Data:       data,
+		Size:       len(data),
+		ReceivedAt: s.now(),
+	}
+	s.uploads = append(s.uploads, upload)
+	logging.Info("Accepted upload %s (%d bytes) as %s", upload.Filename, upload.Size, upload.MediaID)
+	writeJSON(w, map[string]any{
+		"errcode":    wecomapi.ErrCodeOK,
+		"errmsg":     "ok",
+		"type":       upload.Type,
+		"media_id":   upload.MediaID,
+		"created_at": strconv.FormatInt(upload.ReceivedAt.Unix(), 10),
+	})
+}
+
+// begin applies the checks shared by all API endpoints: method, latency,
+// webhook key and injected failures. It returns the key and whether the
+// request should be processed further.
+func (s *Server) begin(w http.ResponseWriter, r *http.Request) (string, bool) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return "", false
+	}
+
+	if s.opts.Latency > 0 {
+		select {
+		case <-time.After(s.opts.Latency):
+		case <-r.Context().Done():
+			return "", false
+		}
+	}
+
+	key := r.URL.Query().Get("key")
+	if wecomapi.CheckKeyFormat(key) != nil || (len(s.opts.Keys) > 0 && !slices.Contains(s.opts.Keys, key)) {
+		reject(w, r, wecomapi.ErrCodeInvalidWebhookKey, "invalid webhook url")
+		return "", false
+	}
+
+	if code, ok := s.injectedFailure(); ok {
+		reject(w, r, code, "injected failure: "+wecomapi.DescribeErrCode(code))
+		return "", false
+	}
+	return key, true
+}
+
+// injectedFailure returns the error code of a queued or random failure
+func (s *Server) injectedFailure() (int, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if len(s.failNext) > 0 {
+		code := s.failNext[0]
+		s.failNext = s.failNext[1:]
+		return code, true
+	}
+	if s.opts.FailureRate > 0 && rand.Float64() < s.opts.FailureRate {
+		return s.opts.FailureCode, true
+	}
+	return 0, false
+}
+
+// allow records a message for key and reports whether it is within the rate
+// limit. The caller must hold s.mu.
+func (s *Server) allow(key string) bool {
+	if s.opts.RateLimit < 0 {
+		return true
+	}
+	now := s.now()
+	recent := slices.DeleteFunc(s.sent[key], func(t time.Time) bool {
+		return now.Sub(t) >= time.Minute
+	})
+	if len(recent) >= s.opts.RateLimit {
+		s.sent[key] = recent
+		return false
+	}
+	s.sent[key] = append(recent, now)
+	return true
+}
+
+// hasMedia reports whether a media ID was returned by the upload endpoint.
+// The caller must hold s.mu.
+func (s *Server) hasMedia(mediaID string) bool {
+	return slices.ContainsFunc(s.uploads, func(u Upload) bool { return u.MediaID == mediaID })
+}
+
+// handleMessages lists the recorded messages (GET) or resets the server (DELETE)
+func (s *Server) handleMessages(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		messages := s.Messages()
+		if messages == nil {
+			messages = []Message{}
+		}
+		writeJSON(w, map[string]any{"messages": messages})
+	case http.MethodDelete:
+		s.Reset()
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		w.Header().Set("Allow", "GET, DELETE")
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// handleUploads lists the recorded uploads
+func (s *Server) handleUploads(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.Header().Set("Allow", http.MethodGet)
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	uploads := s.Uploads()
+	if uploads == nil {
+		uploads = []Upload{}
+	}
+	writeJSON(w, map[string]any{"uploads": uploads})
+}
+
+// reject logs and writes an error response
+func reject(w http.ResponseWriter, r *http.Request, code int, msg string) {
+	logging.Info("Rejected %s with errcode %d: %s", r.URL.Path, code, msg)
+	writeError(w, code, msg)
+}
+
+// writeError writes a webhook API response with the given error code
+func writeError(w http.ResponseWriter, code int, msg string) {
+	writeJSON(w, wecomapi.Response{ErrCode: code, ErrMsg: msg})
+}
+
+// writeJSON writes v as a JSON response. WeCom answers errors with HTTP 200.
+func writeJSON(w http.ResponseWriter, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(v)
+}
@@ -0,0 +1,218 @@
+package fakewecom
+
+import (
+	"context"
+	"crypto/md5"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/futuretea/wecom-bot-mcp-server/pkg/wecomapi"
+)
+
+const testKey = "693a91f6-7abc-4bc4-97a0-0ec2cafe5aaa"
+
+func textMessage(content string) map[string]any {
+	return map[string]any{"msgtype": "text", "text": map[string]any{"content": content}}
+}
+
+// startServer serves fake and returns an API client for it
+func startServer(t *testing.T, fake *Server) *wecomapi.Client {
+	t.Helper()
+	server := httptest.NewServer(fake)
+	t.Cleanup(server.Close)
+	return wecomapi.NewClient(server.URL, server.Client())
+}
+
+// send posts payload with key and returns the WeCom error code
+func send(t *testing.T, client *wecomapi.Client, key string, payload any) int {
+	t.Helper()
+	resp, err := client.Send(context.Background(), key, payload)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	return resp.ErrCode
+}
+
+func TestValidateMessage(t *testing.T) {
+	hasMedia := func(id string) bool { return id == "media-1" }
+	tests := []struct {
+		name    string
+		payload map[string]any
+		want    int
+	}{
+		{name: "text", payload: textMessage("hello"), want: wecomapi.ErrCodeOK},
+		{name: "empty payload", payload: map[string]any{}, want: wecomapi.ErrCodeInvalidMessageType},
+		{name: "unknown type", payload: map[string]any{"msgtype": "voice", "voice": map[string]any{}}, want: wecomapi.ErrCodeInvalidMessageType},
+		{name: "missing body", payload: map[string]any{"msgtype": "text"}, want: wecomapi.ErrCodeInvalidParameter},
+		{name: "empty text", payload: textMessage("  "), want: wecomapi.ErrCodeEmptyContent},
+		{name: "text too long", payload: textMessage(strings.Repeat("a", maxTextBytes+1)), want: wecomapi.ErrCodeInvalidParameter},
+		{name: "bad mentions", payload: map[string]any{"msgtype": "text", "text": map[string]any{"content": "hi", "mentioned_list": "all"}}, want: wecomapi.ErrCodeInvalidParameter},
+		{name: "markdown too long", payload: map[string]any{"msgtype": "markdown", "markdown": map[string]any{"content": strings.Repeat("a", maxMarkdownBytes+1)}}, want: wecomapi.ErrCodeInvalidParameter},
+		{name: "image bad base64", payload: map[string]any{"msgtype": "image", "image": map[string]any{"base64": "!!", "md5": "x"}}, want: wecomapi.ErrCodeInvalidParameter},
+		{name: "news without articles", payload: map[string]any{"msgtype": "news", "news": map[string]any{"articles": []any{}}}, want: wecomapi.ErrCodeInvalidParameter},
+		{name: "news without url", payload: map[string]any{"msgtype": "news", "news": map[string]any{"articles": []any{map[string]any{"title": "t"}}}}, want: wecomapi.ErrCodeInvalidParameter},
+		{name: "file", payload: map[string]any{"msgtype": "file", "file": map[string]any{"media_id": "media-1"}}, want: wecomapi.ErrCodeOK},
+		{name: "file unknown media", payload: map[string]any{"msgtype": "file", "file": map[string]any{"media_id": "media-2"}}, want: wecomapi.ErrCodeInvalidMediaID},
+		{name: "card", payload: map[string]any{"msgtype": "template_card", "template_card": map[string]any{"card_type": "news_notice"}}, want: wecomapi.ErrCodeOK},
+		{name: "card bad type", payload: map[string]any{"msgtype": "template_card", "template_card": map[string]any{"card_type": "vote"}}, want: wecomapi.ErrCodeInvalidParameter},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got, msg := validateMessage(tt.payload, hasMedia); got != tt.want {
+				t.Fatalf("expected errcode %d, got %d (%s)", tt.want, got, msg)
+			}
+		})
+	}
+}
+
+func TestSend_RecordsMessages(t *testing.T) {
+	fake := New(Options{Keys: []string{testKey}})
+	client := startServer(t, fake)
+
+	if code := send(t, client, testKey, textMessage("hello")); code != wecomapi.ErrCodeOK {
+		t.Fatalf("expected success, got errcode %d", code)
+	}
+	if code := send(t, client, "00000000-0000-0000-0000-000000000000", textMessage("hello")); code != wecomapi.ErrCodeInvalidWebhookKey {
+		t.Fatalf("expected invalid key, got errcode %d", code)
+	}
+	if code := send(t, client, testKey, textMessage("")); code != wecomapi.ErrCodeEmptyContent {
+		t.Fatalf("expected empty content, got errcode %d", code)
+	}
+
+	messages := fake.Messages()
+	if len(messages) != 1 || messages[0].Key != testKey || messages[0].MsgType != "text" {
+		t.Fatalf("unexpected messages: %+v", messages)
+	}
+
+	fake.Reset()
+	if len(fake.Messages()) != 0 {
+		t.Fatal("expected Reset to clear messages")
+	}
+}
+
+func TestSend_LargeImage(t *testing.T) {
+	fake := New(Options{Keys: []string{testKey}})
+	client := startServer(t, fake)
+
+	// An image at the size limit is well over 1 MiB once base64 encoded
+	data := []byte("\x89PNG\r\n\x1a\n" + strings.Repeat("a", maxImageBytes-8))
+	sum := md5.Sum(data)
+	image := map[string]any{"msgtype": "image", "image": map[string]any{
+		"base64": base64.StdEncoding.EncodeToString(data),
+		"md5":    hex.EncodeToString(sum[:]),
+	}}
+	if code := send(t, client, testKey, image); code != wecomapi.ErrCodeOK {
+		t.Fatalf("expected success, got errcode %d", code)
+	}
+	if len(fake.Messages()) != 1 {
+		t.Fatalf("expected the image to be recorded, got %+v", fake.Messages())
+	}
+}
+
+func TestSend_RateLimit(t *testing.T) {
+	fake := New(Options{RateLimit: 2})
+	now := time.Date(2026, 1, 5, 9, 0, 0, 0, time.UTC)
+	fake.now = func() time.Time { return now }
+	client := startServer(t, fake)
+
+	for i := 0; i < 2; i++ {
+		if code := send(t, client, testKey, textMessage("hello")); code != wecomapi.ErrCodeOK {
+			t.Fatalf("message %d: expected success, got errcode %d", i, code)
+		}
+	}
+	if code := send(t, client, testKey, textMessage("hello")); code != wecomapi.ErrCodeRateLimited {
+		t.Fatalf("expected rate limit, got errcode %d", code)
+	}
+
+	now = now.Add(time.Minute)
+	if code := send(t, client, testKey, textMessage("hello")); code != wecomapi.ErrCodeOK {
+		t.Fatalf("expected success after a minute, got errcode %d", code)
+	}
+}
+
+func TestSend_FailureInjection(t *testing.T) {
+	fake := New(Options{FailureRate: 1, FailureCode: wecomapi.ErrCodeBotRemoved})
+	client := startServer(t, fake)
+	if code := send(t, client, testKey, textMessage("hello")); code != wecomapi.ErrCodeBotRemoved {
+		t.Fatalf("expected injected failure, got errcode %d", code)
+	}
+
+	fake = New(Options{})
+	client = startServer(t, fake)
+	fake.FailNext(wecomapi.ErrCodeSystemBusy)
+	if code := send(t, client, testKey, textMessage("hello")); code != wecomapi.ErrCodeSystemBusy {
+		t.Fatalf("expected queued failure, got errcode %d", code)
+	}
+	if code := send(t, client, testKey, textMessage("hello")); code != wecomapi.ErrCodeOK {
+		t.Fatalf("expected success after queued failure, got errcode %d", code)
+	}
+}
+
+func TestSend_Latency(t *testing.T) {
+	client := startServer(t, New(Options{Latency: 50 * time.Millisecond}))
+	start := time.Now()
+	send(t, client, testKey, textMessage("hello"))
+	if elapsed := time.Since(start); elapsed < 50*time.Millisecond {
+		t.Fatalf("expected response to be delayed, took %s", elapsed)
+	}
+}
+
+func TestUploadMedia(t *testing.T) {
+	fake := New(Options{})
+	client := startServer(t, fake)
+
+	media, err := client.UploadMedia(context.Background(), testKey, "report.txt", []byte("uptime 99.9%"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	uploads := fake.Uploads()
+	if len(uploads) != 1 || uploads[0].MediaID != media.MediaID || string(uploads[0].Data) != "uptime 99.9%" {
+		t.Fatalf("unexpected uploads: %+v", uploads)
+	}
+
+	payload := map[string]any{"msgtype": "file", "file": map[string]any{"media_id": media.MediaID}}
+	if code := send(t, client, testKey, payload); code != wecomapi.ErrCodeOK {
+		t.Fatalf("expected file message to be accepted, got errcode %d", code)
+	}
+
+	if _, err := client.UploadMedia(context.Background(), testKey, "tiny.txt", []byte("hi")); err == nil || !strings.Contains(err.Error(), "40006") {
+		t.Fatalf("expected media size error, got %v", err)
+	}
+}
+
+func TestMessagesEndpoint(t *testing.T) {
+	fake := New(Options{})
+	server := httptest.NewServer(fake)
+	defer server.Close()
+	client := wecomapi.NewClient(server.URL, server.Client())
+	send(t, client, testKey, textMessage("hello"))
+
+	resp, err := http.Get(server.URL + MessagesPath)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer resp.Body.Close()
+	var listed struct {
+		Messages []Message `json:"messages"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&listed); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(listed.Messages) != 1 || listed.Messages[0].MsgType != "text" {
+		t.Fatalf("unexpected messages: %+v", listed.Messages)
+	}
+
+	req, _ := http.NewRequest(http.MethodDelete, server.URL+MessagesPath, nil)
+	if resp, err := http.DefaultClient.Do(req); err != nil || resp.StatusCode != http.StatusNoContent {
+		t.Fatalf("expected reset to succeed, got %v %v", resp, err)
+	}
+	if len(fake.Messages()) != 0 {
+		t.Fatal("expected messages to be cleared")
+	}
+}
@@ -0,0 +1,132 @@
+package fakewecom
+
+import (
+	"crypto/md5"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"strings"
+
+	"github.com/futuretea/wecom-bot-mcp-server/pkg/wecomapi"
+)
+
+// Message limits of the webhook API
+const (
+	maxTextBytes     = 2048
+	maxMarkdownBytes = 4096
+	maxImageBytes    = 2 * 1024 * 1024
+	maxNewsArticles  = 8
+)
+
+// validateMessage checks a message payload like WeCom does and returns the
+// error code and message of the first problem, or ErrCodeOK. hasMedia reports
+// whether a media ID was uploaded.
+func validateMessage(payload map[string]any, hasMedia func(string) bool) (int, string) {
+	msgType, _ := payload["msgtype"].(string)
+	body, _ := payload[msgType].(map[string]any)
+	if msgType != "" && body == nil {
+		return wecomapi.ErrCodeInvalidParameter, fmt.Sprintf("missing %s", msgType)
+	}
+
+	switch msgType {
+	case "text":
+		if code, msg := validateContent(body, maxTextBytes); code != wecomapi.ErrCodeOK {
+			return code, msg
+		}
+		for _, field := range []string{"mentioned_list", "mentioned_mobile_list"} {
+			if !isStringList(body[field]) {
+				return wecomapi.ErrCodeInvalidParameter, fmt.Sprintf("%s must be a list of strings", field)
+			}
+		}
+		return wecomapi.ErrCodeOK, ""
+	case "markdown":
+		return validateContent(body, maxMarkdownBytes)
+	case "image":
+		return validateImage(body)
+	case "news":
+		return validateNews(body)
+	case "file":
+		mediaID, _ := body["media_id"].(string)
+		if mediaID == "" || !hasMedia(mediaID) {
+			return wecomapi.ErrCodeInvalidMediaID, fmt.Sprintf("invalid media_id %q", mediaID)
+		}
+		return wecomapi.ErrCodeOK, ""
+	case "template_card":
+		// Only the card type is checked; the card layout is not validated
+		switch cardType, _ := body["card_type"].(string); cardType {
+		case "text_notice", "news_notice":
+			return wecomapi.ErrCodeOK, ""
+		default:
+			return wecomapi.ErrCodeInvalidParameter, fmt.Sprintf("invalid card_type %q", cardType)
+		}
+	default:
+		return wecomapi.ErrCodeInvalidMessageType, fmt.Sprintf("invalid message type %q", msgType)
+	}
+}
+
+// validateContent checks the content of text and markdown messages
+func validateContent(body map[string]any, maxBytes int) (int, string) {
+	content, _ := body["content"].(string)
+	if strings.TrimSpace(content) == "" {
+		return wecomapi.ErrCodeEmptyContent, "empty content"
+	}
+	if len(content) > maxBytes {
+		return wecomapi.ErrCodeInvalidParameter, fmt.Sprintf("content exceed max length %d", maxBytes)
+	}
+	return wecomapi.ErrCodeOK, ""
+}
+
+// validateImage checks the base64 data and MD5 checksum of an image message
+func validateImage(body map[string]any) (int, string) {
+	encoded, _ := body["base64"].(string)
+	checksum, _ := body["md5"].(string)
+	if encoded == "" || checksum == "" {
+		return wecomapi.ErrCodeInvalidParameter, "base64 and md5 are required"
+	}
+	data, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return wecomapi.ErrCodeInvalidParameter, "invalid base64"
+	}
+	if len(data) == 0 || len(data) > maxImageBytes {
+		return wecomapi.ErrCodeInvalidImageSize, fmt.Sprintf("image size must be at most %d bytes, got %d", maxImageBytes, len(data))
+	}
+	sum := md5.Sum(data)
+	if !strings.EqualFold(hex.EncodeToString(sum[:]), checksum) {
+		return wecomapi.ErrCodeInvalidParameter, "md5 does not match image data"
+	}
+	return wecomapi.ErrCodeOK, ""
+}
+
+// validateNews checks the articles of a news message
+func validateNews(body map[string]any) (int, string) {
+	articles, _ := body["articles"].([]any)
+	if len(articles) == 0 || len(articles) > maxNewsArticles {
+		return wecomapi.ErrCodeInvalidParameter, fmt.Sprintf("articles must contain 1 to %d items, got %d", maxNewsArticles, len(articles))
+	}
+	for i, item := range articles {
+		article, _ := item.(map[string]any)
+		for _, field := range []string{"title", "url"} {
+			if value, _ := article[field].(string); value == "" {
+				return wecomapi.ErrCodeInvalidParameter, fmt.Sprintf("articles[%d].%s is required", i, field)
+			}
+		}
+	}
+	return wecomapi.ErrCodeOK, ""
+}
+
+// isStringList reports whether v is absent or a JSON array of strings
+func isStringList(v any) bool {
+	if v == nil {
+		return true
+	}
+	items, ok := v.([]any)
+	if !ok {
+		return false
+	}
+	for _, item := range items {
+		if _, ok := item.(string); !ok {
+			return false
+		}
+	}
+	return true
+}
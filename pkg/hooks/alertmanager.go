@@ -0,0 +1,286 @@
+package hooks
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/futuretea/wecom-bot-mcp-server/pkg/core/config"
+	"github.com/futuretea/wecom-bot-mcp-server/pkg/core/logging"
+)
+
+// Alert statuses
+const (
+	alertFiring   = "firing"
+	alertResolved = "resolved"
+)
+
+// maxCardLabels is the WeCom limit on horizontal content items in a card
+const maxCardLabels = 6
+
+// defaultAlertmanagerMarkdown is the built-in template for markdown messages
+const defaultAlertmanagerMarkdown = `
+{{- if .Firing -}}
+### <font color="warning">Firing ({{ len .Firing }})</font> {{ .GroupLabels.alertname }}
+{{ range .Firing -}}
+> **{{ .Labels.alertname }}**{{ with .Labels.severity }} [{{ . }}]{{ end }}{{ with .Labels.instance }} {{ . }}{{ end }}
+{{ with or .Annotations.summary .Annotations.description }}> {{ . }}
+{{ end }}> <font color="comment">Since {{ .StartsAt.Format "2006-01-02 15:04:05 MST" }}</font>
+
+{{ end -}}
+{{ end -}}
+{{ if .Resolved -}}
+### <font color="info">Resolved ({{ len .Resolved }})</font> {{ .GroupLabels.alertname }}
+{{ range .Resolved -}}
+> **{{ .Labels.alertname }}**{{ with .Labels.instance }} {{ . }}{{ end }}
+> <font color="comment">Resolved at {{ .EndsAt.Format "2006-01-02 15:04:05 MST" }}</font>
+
+{{ end -}}
+{{ end -}}
+{{ if .TruncatedAlerts }}{{ .TruncatedAlerts }} more alert(s) not shown{{ end }}`
+
+// defaultAlertmanagerCard is the built-in template for the card sub-title
+const defaultAlertmanagerCard = `
+{{- range .Firing }}{{ with or .Annotations.summary .Labels.alertname }}[firing] {{ . }}
+{{ end }}{{ end }}
+{{- range .Resolved }}{{ with or .Annotations.summary .Labels.alertname }}[resolved] {{ . }}
+{{ end }}{{ end }}`
+
+// AlertmanagerPayload is the body of an Alertmanager webhook notification.
+// Alertmanager groups alerts by its route's group_by labels; one notification
+// holds both the firing and resolved alerts of a group.
+type AlertmanagerPayload struct {
+	Version           string            `json:"version"`
+	GroupKey          string            `json:"groupKey"`
+	TruncatedAlerts   int               `json:"truncatedAlerts"`
+	Status            string            `json:"status"`
+	Receiver          string            `json:"receiver"`
+	GroupLabels       map[string]string `json:"groupLabels"`
+	CommonLabels      map[string]string `json:"commonLabels"`
+	CommonAnnotations map[string]string `json:"commonAnnotations"`
+	ExternalURL       string            `json:"externalURL"`
+	Alerts            []Alert           `json:"alerts"`
+}
+
+// Alert is a single alert of an Alertmanager notification
+type Alert struct {
+	Status       string            `json:"status"`
+	Labels       map[string]string `json:"labels"`
+	Annotations  map[string]string `json:"annotations"`
+	StartsAt     time.Time         `json:"startsAt"`
+	EndsAt       time.Time         `json:"endsAt"`
+	GeneratorURL string            `json:"generatorURL"`
+	Fingerprint  string            `json:"fingerprint"`
+}
+
+// alertmanagerData is the data passed to Alertmanager templates: the
+// notification with its alerts split into firing and resolved
+type alertmanagerData struct {
+	AlertmanagerPayload
+	Firing   []Alert
+	Resolved []Alert
+}
+
+// AlertmanagerHandler relays Alertmanager notifications to WeCom as a markdown
+// message or a text notice card.
+type AlertmanagerHandler struct {
+	cfg      config.AlertmanagerHook
+	template *template.Template
+	// fallback renders cards that have no link as markdown messages
+	fallback   *template.Template
+	dispatcher Dispatcher
+}
+
+// NewAlertmanagerHandler creates the handler of the Alertmanager webhook. A
+// nil dispatcher only checks the configuration.
+func NewAlertmanagerHandler(cfg config.AlertmanagerHook, dispatcher Dispatcher) (*AlertmanagerHandler, error) {
+	if cfg.MessageType == "" {
+		cfg.MessageType = config.HookMessageMarkdown
+	}
+	text := cfg.Template
+	if text == "" {
+		text = defaultAlertmanagerMarkdown
+		if cfg.MessageType == config.HookMessageTextNoticeCard {
+			text = defaultAlertmanagerCard
+		}
+	}
+	tmpl, err := parseTemplate("hooks.alertmanager", text)
+	if err != nil {
+		return nil, err
+	}
+	h := &AlertmanagerHandler{cfg: cfg, template: tmpl, dispatcher: dispatcher}
+
+	tools := []string{sendTool(cfg.MessageType)}
+	if cfg.MessageType == config.HookMessageTextNoticeCard {
+		// WeCom rejects cards without a link, so notifications without an
+		// Alertmanager or generator URL are sent as markdown instead
+		if h.fallback, err = parseTemplate("hooks.alertmanager", defaultAlertmanagerMarkdown); err != nil {
+			return nil, err
+		}
+		tools = append(tools, sendTool(config.HookMessageMarkdown))
+	}
+	if dispatcher != nil {
+		for _, tool := range tools {
+			if err := requireTool(dispatcher, "hooks.alertmanager", tool); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return h, nil
+}
+
+// sendTool returns the tool that sends a hook message type
+func sendTool(messageType string) string {
+	if messageType == config.HookMessageTextNoticeCard {
+		return "send_text_notice_card"
+	}
+	return "send_markdown"
+}
+
+// ServeHTTP handles an Alertmanager notification
+func (h *AlertmanagerHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if !checkRequest(w, r, h.cfg.Token) {
+		return
+	}
+
+	var payload AlertmanagerPayload
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxRequestBytes)).Decode(&payload); err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Sprintf("invalid Alertmanager payload: %v", err))
+		return
+	}
+	if len(payload.Alerts) == 0 {
+		writeResult(w, "No alerts to send")
+		return
+	}
+
+	tool, params, err := h.message(payload)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	result, err := h.dispatcher.CallTool(tool, params)
+	if err != nil {
+		// Alertmanager retries notifications that fail with a 5xx status
+		logging.Warn("Failed to relay Alertmanager notification %s: %v", payload.GroupKey, err)
+		writeError(w, http.StatusBadGateway, err.Error())
+		return
+	}
+	writeResult(w, result)
+}
+
+// message renders a notification as the name and arguments of a send tool
+func (h *AlertmanagerHandler) message(payload AlertmanagerPayload) (string, map[string]any, error) {
+	data := alertmanagerData{AlertmanagerPayload: payload}
+	for _, alert := range payload.Alerts {
+		if alert.Status == alertResolved {
+			data.Resolved = append(data.Resolved, alert)
+		} else {
+			data.Firing = append(data.Firing, alert)
+		}
+	}
+	messageType, tmpl := h.cfg.MessageType, h.template
+	url := alertURL(data)
+	if messageType == config.HookMessageTextNoticeCard && url == "" {
+		messageType, tmpl = config.HookMessageMarkdown, h.fallback
+	}
+	body, err := executeTemplate(tmpl, data)
+	if err != nil {
+		return "", nil, err
+	}
+
+	params := map[string]any{}
+	if h.urgent(data.Firing) {
+		params["priority"] = "urgent"
+	}
+
+	if messageType != config.HookMessageTextNoticeCard {
+		if body == "" {
+			return "", nil, fmt.Errorf("hooks.alertmanager template rendered an empty message")
+		}
+		params["content"] = truncateMarkdown(body)
+		return sendTool(messageType), params, nil
+	}
+
+	params["main_title"] = alertTitle(data)
+	if summary := payload.CommonAnnotations["summary"]; summary != "" {
+		params["main_title_desc"] = summary
+	}
+	if len(data.Firing) > 0 {
+		params["emphasis_content"] = map[string]any{"title": strconv.Itoa(len(data.Firing)), "desc": alertFiring}
+	} else {
+		params["emphasis_content"] = map[string]any{"title": strconv.Itoa(len(data.Resolved)), "desc": alertResolved}
+	}
+	if body != "" {
+		params["sub_title"] = body
+	}
+	if labels := cardLabels(payload); len(labels) > 0 {
+		params["horizontal_content_list"] = labels
+	}
+	params["card_action"] = map[string]any{"url": url}
+	return sendTool(messageType), params, nil
+}
+
+// urgent reports whether any firing alert has an urgent severity
+func (h *AlertmanagerHandler) urgent(firing []Alert) bool {
+	for _, alert := range firing {
+		if slices.Contains(h.cfg.UrgentSeverities, alert.Labels["severity"]) {
+			return true
+		}
+	}
+	return false
+}
+
+// alertTitle returns a title such as "[FIRING:2] HighLatency"
+func alertTitle(data alertmanagerData) string {
+	status := strings.ToUpper(data.Status)
+	if status == "" {
+		status = strings.ToUpper(alertFiring)
+	}
+	title := fmt.Sprintf("[%s:%d]", status, len(data.Firing))
+	if name := data.GroupLabels["alertname"]; name != "" {
+		title += " " + name
+	} else if name := data.CommonLabels["alertname"]; name != "" {
+		title += " " + name
+	}
+	return title
+}
+
+// cardLabels returns the common labels of a notification, except the alert
+// name shown in the title, as card key-value pairs
+func cardLabels(payload AlertmanagerPayload) []any {
+	keys := make([]string, 0, len(payload.CommonLabels))
+	for key := range payload.CommonLabels {
+		if key != "alertname" {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	if len(keys) > maxCardLabels {
+		keys = keys[:maxCardLabels]
+	}
+	labels := make([]any, 0, len(keys))
+	for _, key := range keys {
+		labels = append(labels, map[string]any{"keyname": key, "value": payload.CommonLabels[key]})
+	}
+	return labels
+}
+
+// alertURL returns the link of a card: the Alertmanager URL, or the
+// generator URL of the first alert when Alertmanager did not send its own
+func alertURL(data alertmanagerData) string {
+	if data.ExternalURL != "" {
+		return data.ExternalURL
+	}
+	for _, alert := range data.Alerts {
+		if alert.GeneratorURL != "" {
+			return alert.GeneratorURL
+		}
+	}
+	return ""
+}
@@ -0,0 +1,235 @@
+package hooks
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/futuretea/wecom-bot-mcp-server/pkg/core/config"
+)
+
+// fakeDispatcher records tool calls
+type fakeDispatcher struct {
+	disabled []string
+	calls    []toolCall
+	err      error
+}
+
+type toolCall struct {
+	tool   string
+	params map[string]any
+}
+
+func (d *fakeDispatcher) HasTool(name string) bool {
+	for _, disabled := range d.disabled {
+		if disabled == name {
+			return false
+		}
+	}
+	return true
+}
+
+func (d *fakeDispatcher) CallTool(name string, params map[string]any) (string, error) {
+	d.calls = append(d.calls, toolCall{tool: name, params: params})
+	if d.err != nil {
+		return "", d.err
+	}
+	return "sent", nil
+}
+
+const alertmanagerPayload = `{
+  "version": "4",
+  "groupKey": "{}:{alertname=\"HighLatency\"}",
+  "status": "firing",
+  "receiver": "wecom",
+  "groupLabels": {"alertname": "HighLatency"},
+  "commonLabels": {"alertname": "HighLatency", "job": "api"},
+  "commonAnnotations": {"summary": "API latency is high"},
+  "externalURL": "http://alertmanager:9093",
+  "alerts": [
+    {
+      "status": "firing",
+      "labels": {"alertname": "HighLatency", "instance": "api-1", "severity": "critical", "job": "api"},
+      "annotations": {"summary": "p99 latency above 2s on api-1"},
+      "startsAt": "2025-01-06T09:00:00Z",
+      "endsAt": "0001-01-01T00:00:00Z"
+    },
+    {
+      "status": "resolved",
+      "labels": {"alertname": "HighLatency", "instance": "api-2", "severity": "warning", "job": "api"},
+      "annotations": {},
+      "startsAt": "2025-01-06T08:00:00Z",
+      "endsAt": "2025-01-06T08:30:00Z"
+    }
+  ]
+}`
+
+// serve registers the hooks and sends a request to path
+func serve(t *testing.T, cfg config.Hooks, dispatcher Dispatcher, path, token, body string) *httptest.ResponseRecorder {
+	t.Helper()
+	mux := http.NewServeMux()
+	if err := Register(mux, cfg, dispatcher); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	req := httptest.NewRequest(http.MethodPost, path, strings.NewReader(body))
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestAlertmanager_Markdown(t *testing.T) {
+	dispatcher := &fakeDispatcher{}
+	cfg := config.Hooks{Alertmanager: config.AlertmanagerHook{Enabled: true, UrgentSeverities: []string{"critical"}}}
+	rec := serve(t, cfg, dispatcher, "/hooks/alertmanager/default", "", alertmanagerPayload)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rec.Code, rec.Body)
+	}
+	if len(dispatcher.calls) != 1 || dispatcher.calls[0].tool != "send_markdown" {
+		t.Fatalf("expected one send_markdown call, got %+v", dispatcher.calls)
+	}
+
+	params := dispatcher.calls[0].params
+	content := params["content"].(string)
+	for _, want := range []string{"Firing (1)", "p99 latency above 2s on api-1", "[critical]", "Since 2025-01-06 09:00:00 UTC", "Resolved (1)", "api-2", "Resolved at 2025-01-06 08:30:00 UTC"} {
+		if !strings.Contains(content, want) {
+			t.Fatalf("expected content to contain %q, got:\n%s", want, content)
+		}
+	}
+	if params["priority"] != "urgent" {
+		t.Fatalf("expected a critical alert to be urgent, got %v", params["priority"])
+	}
+}
+
+func TestAlertmanager_TextNoticeCard(t *testing.T) {
+	dispatcher := &fakeDispatcher{}
+	cfg := config.Hooks{Alertmanager: config.AlertmanagerHook{Enabled: true, MessageType: config.HookMessageTextNoticeCard}}
+	rec := serve(t, cfg, dispatcher, "/hooks/alertmanager/default", "", alertmanagerPayload)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rec.Code, rec.Body)
+	}
+	if len(dispatcher.calls) != 1 || dispatcher.calls[0].tool != "send_text_notice_card" {
+		t.Fatalf("expected one send_text_notice_card call, got %+v", dispatcher.calls)
+	}
+
+	params := dispatcher.calls[0].params
+	if params["main_title"] != "[FIRING:1] HighLatency" || params["main_title_desc"] != "API latency is high" {
+		t.Fatalf("unexpected title %v / %v", params["main_title"], params["main_title_desc"])
+	}
+	if params["sub_title"] != "[firing] p99 latency above 2s on api-1\n[resolved] HighLatency" {
+		t.Fatalf("unexpected sub_title %q", params["sub_title"])
+	}
+	labels := params["horizontal_content_list"].([]any)
+	if len(labels) != 1 || labels[0].(map[string]any)["keyname"] != "job" {
+		t.Fatalf("expected the common labels except alertname, got %v", labels)
+	}
+	if action := params["card_action"].(map[string]any); action["url"] != "http://alertmanager:9093" {
+		t.Fatalf("unexpected card action %v", action)
+	}
+	if _, ok := params["priority"]; ok {
+		t.Fatal("expected normal priority without urgent severities")
+	}
+}
+
+func TestAlertmanager_TextNoticeCardWithoutURL(t *testing.T) {
+	dispatcher := &fakeDispatcher{}
+	cfg := config.Hooks{Alertmanager: config.AlertmanagerHook{Enabled: true, MessageType: config.HookMessageTextNoticeCard}}
+	payload := strings.Replace(alertmanagerPayload, `"externalURL": "http://alertmanager:9093"`, `"externalURL": ""`, 1)
+	rec := serve(t, cfg, dispatcher, "/hooks/alertmanager/default", "", payload)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rec.Code, rec.Body)
+	}
+	if len(dispatcher.calls) != 1 || dispatcher.calls[0].tool != "send_markdown" {
+		t.Fatalf("expected a card without a link to be sent as markdown, got %+v", dispatcher.calls)
+	}
+	if content := dispatcher.calls[0].params["content"].(string); !strings.Contains(content, "Firing (1)") {
+		t.Fatalf("expected the default markdown content, got:\n%s", content)
+	}
+
+	withGenerator := strings.Replace(payload, `"endsAt": "0001-01-01T00:00:00Z"`, `"endsAt": "0001-01-01T00:00:00Z", "generatorURL": "http://prometheus:9090/graph"`, 1)
+	dispatcher.calls = nil
+	serve(t, cfg, dispatcher, "/hooks/alertmanager/default", "", withGenerator)
+	if len(dispatcher.calls) != 1 || dispatcher.calls[0].tool != "send_text_notice_card" {
+		t.Fatalf("expected a card linking to the generator URL, got %+v", dispatcher.calls)
+	}
+	if action := dispatcher.calls[0].params["card_action"].(map[string]any); action["url"] != "http://prometheus:9090/graph" {
+		t.Fatalf("unexpected card action %v", action)
+	}
+}
+
+func TestAlertmanager_CustomTemplate(t *testing.T) {
+	dispatcher := &fakeDispatcher{}
+	cfg := config.Hooks{Alertmanager: config.AlertmanagerHook{
+		Enabled:  true,
+		Template: `{{ .Receiver }}: {{ len .Firing }} firing, {{ len .Resolved }} resolved ({{ .CommonLabels.job | upper }}{{ .CommonLabels.missing }})`,
+	}}
+	serve(t, cfg, dispatcher, "/hooks/alertmanager/default", "", alertmanagerPayload)
+	if len(dispatcher.calls) != 1 || dispatcher.calls[0].params["content"] != "wecom: 1 firing, 1 resolved (API)" {
+		t.Fatalf("unexpected calls %+v", dispatcher.calls)
+	}
+}
+
+func TestAlertmanager_Errors(t *testing.T) {
+	cfg := config.Hooks{Alertmanager: config.AlertmanagerHook{Enabled: true, Token: "s3cret"}}
+	tests := []struct {
+		name       string
+		path       string
+		token      string
+		body       string
+		err        error
+		wantStatus int
+	}{
+		{name: "unknown bot", path: "/hooks/alertmanager/other", token: "s3cret", body: alertmanagerPayload, wantStatus: http.StatusNotFound},
+		{name: "missing token", path: "/hooks/alertmanager/default", body: alertmanagerPayload, wantStatus: http.StatusUnauthorized},
+		{name: "wrong token", path: "/hooks/alertmanager/default", token: "guess", body: alertmanagerPayload, wantStatus: http.StatusUnauthorized},
+		{name: "invalid payload", path: "/hooks/alertmanager/default", token: "s3cret", body: "{", wantStatus: http.StatusBadRequest},
+		{name: "send failure", path: "/hooks/alertmanager/default", token: "s3cret", body: alertmanagerPayload, err: fmt.Errorf("rate limited"), wantStatus: http.StatusBadGateway},
+		{name: "no alerts", path: "/hooks/alertmanager/default", token: "s3cret", body: `{"alerts": []}`, wantStatus: http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dispatcher := &fakeDispatcher{err: tt.err}
+			rec := serve(t, cfg, dispatcher, tt.path, tt.token, tt.body)
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("expected %d, got %d: %s", tt.wantStatus, rec.Code, rec.Body)
+			}
+			var body response
+			if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+				t.Fatalf("expected a JSON response, got %q", rec.Body)
+			}
+			if (tt.wantStatus == http.StatusOK) != (body.Error == "") {
+				t.Fatalf("unexpected response %+v", body)
+			}
+		})
+	}
+}
+
+func TestNewAlertmanagerHandler_Errors(t *testing.T) {
+	if _, err := NewAlertmanagerHandler(config.AlertmanagerHook{Template: "{{ .Nope"}, nil); err == nil || !strings.Contains(err.Error(), "invalid hooks.alertmanager template") {
+		t.Fatalf("expected template error, got %v", err)
+	}
+	dispatcher := &fakeDispatcher{disabled: []string{"send_markdown"}}
+	if _, err := NewAlertmanagerHandler(config.AlertmanagerHook{}, dispatcher); err == nil || !strings.Contains(err.Error(), "send_markdown tool, which is disabled") {
+		t.Fatalf("expected disabled tool error, got %v", err)
+	}
+	card := config.AlertmanagerHook{MessageType: config.HookMessageTextNoticeCard}
+	if _, err := NewAlertmanagerHandler(card, dispatcher); err == nil || !strings.Contains(err.Error(), "send_markdown tool, which is disabled") {
+		t.Fatalf("expected cards to need send_markdown for notifications without a link, got %v", err)
+	}
+}
+
+func TestTruncateMarkdown(t *testing.T) {
+	content := strings.Repeat("a line of text\n", 400)
+	got := truncateMarkdown(content)
+	if len(got) > maxMarkdownBytes || !strings.HasSuffix(got, "a line of text\n\n(truncated)") {
+		t.Fatalf("expected content cut at a line break, got %d bytes ending %q", len(got), got[len(got)-40:])
+	}
+	if truncateMarkdown("short") != "short" {
+		t.Fatal("expected short content to be unchanged")
+	}
+}
@@ -0,0 +1,279 @@
+package hooks
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"text/template"
+
+	"github.com/futuretea/wecom-bot-mcp-server/pkg/core/config"
+	"github.com/futuretea/wecom-bot-mcp-server/pkg/core/logging"
+	wecomToolset "github.com/futuretea/wecom-bot-mcp-server/pkg/toolset/wecom"
+)
+
+// renderFunc renders a compiled tool argument for a request body
+type renderFunc func(data any) (any, error)
+
+// GenericHandler relays any JSON body to WeCom, mapping it to a message with
+// templates and JSONPath expressions.
+type GenericHandler struct {
+	cfg        config.HookEndpoint
+	name       string
+	content    *template.Template
+	params     map[string]renderFunc
+	dispatcher Dispatcher
+}
+
+// NewGenericHandler creates the handler of a /hooks/{name} endpoint. A nil
+// dispatcher only checks the configuration.
+func NewGenericHandler(cfg config.HookEndpoint, dispatcher Dispatcher) (*GenericHandler, error) {
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	if cfg.SignatureHeader == "" {
+		cfg.SignatureHeader = config.DefaultSignatureHeader
+	}
+	name := "hooks.endpoints." + cfg.Name
+	if cfg.Bot != "" && cfg.Bot != wecomToolset.DefaultBotName {
+		return nil, fmt.Errorf("%s sends with unknown bot %q; the only bot is %q", name, cfg.Bot, wecomToolset.DefaultBotName)
+	}
+
+	h := &GenericHandler{cfg: cfg, name: name, params: make(map[string]renderFunc), dispatcher: dispatcher}
+	if cfg.Template != "" {
+		tmpl, err := parseTemplate(name, cfg.Template)
+		if err != nil {
+			return nil, err
+		}
+		h.content = tmpl
+	}
+	for key, value := range cfg.Params {
+		render, err := compileParam(name+".params."+key, value)
+		if err != nil {
+			return nil, err
+		}
+		h.params[key] = render
+	}
+	if dispatcher != nil {
+		if err := requireTool(dispatcher, name, h.tool()); err != nil {
+			return nil, err
+		}
+	}
+	return h, nil
+}
+
+// tool returns the send tool of the endpoint's message type
+func (h *GenericHandler) tool() string {
+	return "send_" + h.cfg.MessageType
+}
+
+// ServeHTTP handles a webhook request
+func (h *GenericHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxRequestBytes))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Sprintf("failed to read request body: %v", err))
+		return
+	}
+	if h.cfg.Token != "" && !validToken(r, h.cfg.Token) {
+		writeError(w, http.StatusUnauthorized, "missing or invalid bearer token")
+		return
+	}
+	if h.cfg.HMACSecret != "" && !validSignature(r.Header.Get(h.cfg.SignatureHeader), body, h.cfg.HMACSecret) {
+		writeError(w, http.StatusUnauthorized, fmt.Sprintf("missing or invalid %s signature", h.cfg.SignatureHeader))
+		return
+	}
+
+	var data any
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	decoder.UseNumber()
+	if err := decoder.Decode(&data); err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Sprintf("invalid JSON body: %v", err))
+		return
+	}
+
+	params, err := h.message(data)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	result, err := h.dispatcher.CallTool(h.tool(), params)
+	if err != nil {
+		logging.Warn("Failed to relay %s request: %v", h.name, err)
+		writeError(w, http.StatusBadGateway, err.Error())
+		return
+	}
+	writeResult(w, result)
+}
+
+// message renders a request body as the arguments of the send tool
+func (h *GenericHandler) message(data any) (map[string]any, error) {
+	params := make(map[string]any)
+	for key, render := range h.params {
+		value, err := render(data)
+		if err != nil {
+			return nil, err
+		}
+		if value != nil {
+			params[key] = value
+		}
+	}
+
+	if h.content != nil {
+		content, err := executeJSONTemplate(h.content, data)
+		if err != nil {
+			return nil, err
+		}
+		if content != "" {
+			params["content"] = content
+		}
+	}
+	if h.cfg.MessageType == config.HookMessageText || h.cfg.MessageType == config.HookMessageMarkdown {
+		content, _ := params["content"].(string)
+		if content == "" {
+			return nil, fmt.Errorf("%s template rendered an empty message", h.name)
+		}
+		limit := maxTextBytes
+		if h.cfg.MessageType == config.HookMessageMarkdown {
+			limit = maxMarkdownBytes
+		}
+		params["content"] = truncate(content, limit)
+	}
+	return params, nil
+}
+
+// executeJSONTemplate renders a template over decoded JSON. Missing members
+// of JSON objects render as nothing rather than "<no value>".
+func executeJSONTemplate(tmpl *template.Template, data any) (string, error) {
+	s, err := executeTemplate(tmpl, data)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(strings.ReplaceAll(s, "<no value>", "")), nil
+}
+
+// validSignature reports whether signature is the hex HMAC-SHA256 of body,
+// optionally prefixed with "sha256=" as GitHub and others send it
+func validSignature(signature string, body []byte, secret string) bool {
+	presented, err := hex.DecodeString(strings.TrimPrefix(signature, "sha256="))
+	if err != nil || len(presented) == 0 {
+		return false
+	}
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return hmac.Equal(presented, mac.Sum(nil))
+}
+
+// compileParam compiles a configured tool argument. Strings are JSONPath
+// expressions or templates; maps and lists are compiled item by item, and
+// other values are used as they are. Missing values and empty strings are
+// left out.
+func compileParam(name string, value any) (renderFunc, error) {
+	switch value := value.(type) {
+	case string:
+		if isJSONPath(value) {
+			path, err := parseJSONPath(value)
+			if err != nil {
+				return nil, fmt.Errorf("invalid %s JSONPath: %w", name, err)
+			}
+			return func(data any) (any, error) {
+				found, ok := path.lookup(data)
+				if !ok {
+					return nil, nil
+				}
+				return plainJSON(found), nil
+			}, nil
+		}
+		tmpl, err := parseTemplate(name, value)
+		if err != nil {
+			return nil, err
+		}
+		return func(data any) (any, error) {
+			s, err := executeJSONTemplate(tmpl, data)
+			if err != nil || s == "" {
+				return nil, err
+			}
+			return s, nil
+		}, nil
+
+	case map[string]any:
+		fields := make(map[string]renderFunc, len(value))
+		for key, item := range value {
+			render, err := compileParam(name+"."+key, item)
+			if err != nil {
+				return nil, err
+			}
+			fields[key] = render
+		}
+		return func(data any) (any, error) {
+			out := make(map[string]any, len(fields))
+			for key, render := range fields {
+				v, err := render(data)
+				if err != nil {
+					return nil, err
+				}
+				if v != nil {
+					out[key] = v
+				}
+			}
+			return out, nil
+		}, nil
+
+	case []any:
+		items := make([]renderFunc, len(value))
+		for i, item := range value {
+			render, err := compileParam(fmt.Sprintf("%s[%d]", name, i), item)
+			if err != nil {
+				return nil, err
+			}
+			items[i] = render
+		}
+		return func(data any) (any, error) {
+			out := make([]any, 0, len(items))
+			for _, render := range items {
+				v, err := render(data)
+				if err != nil {
+					return nil, err
+				}
+				if v != nil {
+					out = append(out, v)
+				}
+			}
+			return out, nil
+		}, nil
+
+	default:
+		return func(any) (any, error) { return value, nil }, nil
+	}
+}
+
+// plainJSON converts the numbers of a decoded JSON value to float64, as tool
+// arguments decoded from an MCP call have them
+func plainJSON(value any) any {
+	switch value := value.(type) {
+	case json.Number:
+		f, err := value.Float64()
+		if err != nil {
+			return value.String()
+		}
+		return f
+	case map[string]any:
+		out := make(map[string]any, len(value))
+		for key, item := range value {
+			out[key] = plainJSON(item)
+		}
+		return out
+	case []any:
+		out := make([]any, len(value))
+		for i, item := range value {
+			out[i] = plainJSON(item)
+		}
+		return out
+	default:
+		return value
+	}
+}
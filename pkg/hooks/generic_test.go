@@ -0,0 +1,230 @@
+package hooks
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/futuretea/wecom-bot-mcp-server/pkg/core/config"
+)
+
+const pipelinePayload = `{
+  "pipeline": {"id": 1234567, "status": "failed", "ref": "main", "url": "https://ci.example.com/p/1234567"},
+  "user": {"name": "alice"},
+  "jobs": [{"name": "build", "status": "success"}, {"name": "test", "status": "failed"}],
+  "notify": ["alice", "bob"]
+}`
+
+// sign returns the GitHub-style signature of body
+func sign(body, secret string) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(body))
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// serveGeneric registers one generic endpoint and sends a request to it
+func serveGeneric(t *testing.T, endpoint config.HookEndpoint, dispatcher Dispatcher, header http.Header, body string) *httptest.ResponseRecorder {
+	t.Helper()
+	mux := http.NewServeMux()
+	if err := Register(mux, config.Hooks{Endpoints: []config.HookEndpoint{endpoint}}, dispatcher); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	req := httptest.NewRequest(http.MethodPost, "/hooks/"+endpoint.Name, strings.NewReader(body))
+	for key, values := range header {
+		req.Header[key] = values
+	}
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestGeneric_Markdown(t *testing.T) {
+	dispatcher := &fakeDispatcher{}
+	endpoint := config.HookEndpoint{
+		Name:        "ci",
+		HMACSecret:  "s3cret",
+		MessageType: config.HookMessageMarkdown,
+		Template:    `Pipeline {{ .pipeline.id }} on {{ .pipeline.ref }} {{ .pipeline.status | upper }} ({{ jsonpath "$.jobs[-1].name" . }}{{ jsonpath "$.missing" . }})`,
+		Params: map[string]any{
+			"mentioned_list": "$.notify",
+			"priority":       `{{ if eq .pipeline.status "failed" }}urgent{{ end }}`,
+			"dedup_key":      "$.nothing",
+		},
+	}
+	header := http.Header{"X-Signature-256": {sign(pipelinePayload, "s3cret")}}
+	rec := serveGeneric(t, endpoint, dispatcher, header, pipelinePayload)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rec.Code, rec.Body)
+	}
+	if len(dispatcher.calls) != 1 || dispatcher.calls[0].tool != "send_markdown" {
+		t.Fatalf("expected one send_markdown call, got %+v", dispatcher.calls)
+	}
+
+	want := map[string]any{
+		"content":        "Pipeline 1234567 on main FAILED (test)",
+		"mentioned_list": []any{"alice", "bob"},
+		"priority":       "urgent",
+	}
+	if got := dispatcher.calls[0].params; !reflect.DeepEqual(got, want) {
+		t.Fatalf("expected params %v, got %v", want, got)
+	}
+}
+
+func TestGeneric_TextNoticeCard(t *testing.T) {
+	dispatcher := &fakeDispatcher{}
+	endpoint := config.HookEndpoint{
+		Name:        "ci",
+		Token:       "s3cret",
+		MessageType: config.HookMessageTextNoticeCard,
+		Params: map[string]any{
+			"main_title": "Pipeline {{ .pipeline.status }}",
+			"emphasis_content": map[string]any{
+				"title": "$.pipeline.id",
+				"desc":  "{{ .user.name }}",
+			},
+			"horizontal_content_list": []any{
+				map[string]any{"keyname": "ref", "value": "$.pipeline.ref"},
+			},
+			"card_action": map[string]any{"type": 1, "url": "$.pipeline.url"},
+		},
+	}
+	header := http.Header{"Authorization": {"Bearer s3cret"}}
+	rec := serveGeneric(t, endpoint, dispatcher, header, pipelinePayload)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rec.Code, rec.Body)
+	}
+
+	want := map[string]any{
+		"main_title":              "Pipeline failed",
+		"emphasis_content":        map[string]any{"title": float64(1234567), "desc": "alice"},
+		"horizontal_content_list": []any{map[string]any{"keyname": "ref", "value": "main"}},
+		"card_action":             map[string]any{"type": 1, "url": "https://ci.example.com/p/1234567"},
+	}
+	if len(dispatcher.calls) != 1 || dispatcher.calls[0].tool != "send_text_notice_card" {
+		t.Fatalf("expected one send_text_notice_card call, got %+v", dispatcher.calls)
+	}
+	if got := dispatcher.calls[0].params; !reflect.DeepEqual(got, want) {
+		t.Fatalf("expected params %v, got %v", want, got)
+	}
+}
+
+func TestGeneric_Errors(t *testing.T) {
+	endpoint := config.HookEndpoint{
+		Name:        "ci",
+		Token:       "t0ken",
+		HMACSecret:  "s3cret",
+		MessageType: config.HookMessageText,
+		Template:    "{{ .message }}",
+	}
+	tests := []struct {
+		name       string
+		header     http.Header
+		body       string
+		wantStatus int
+	}{
+		{name: "valid", header: http.Header{"Authorization": {"Bearer t0ken"}, "X-Signature-256": {sign(`{"message": "hi"}`, "s3cret")}}, body: `{"message": "hi"}`, wantStatus: http.StatusOK},
+		{name: "missing token", header: http.Header{"X-Signature-256": {sign(`{"message": "hi"}`, "s3cret")}}, body: `{"message": "hi"}`, wantStatus: http.StatusUnauthorized},
+		{name: "missing signature", header: http.Header{"Authorization": {"Bearer t0ken"}}, body: `{"message": "hi"}`, wantStatus: http.StatusUnauthorized},
+		{name: "wrong signature", header: http.Header{"Authorization": {"Bearer t0ken"}, "X-Signature-256": {sign(`{"message": "hi"}`, "guess")}}, body: `{"message": "hi"}`, wantStatus: http.StatusUnauthorized},
+		{name: "invalid JSON", header: http.Header{"Authorization": {"Bearer t0ken"}, "X-Signature-256": {sign("{", "s3cret")}}, body: "{", wantStatus: http.StatusBadRequest},
+		{name: "empty message", header: http.Header{"Authorization": {"Bearer t0ken"}, "X-Signature-256": {sign(`{}`, "s3cret")}}, body: `{}`, wantStatus: http.StatusInternalServerError},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dispatcher := &fakeDispatcher{}
+			rec := serveGeneric(t, endpoint, dispatcher, tt.header, tt.body)
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("expected %d, got %d: %s", tt.wantStatus, rec.Code, rec.Body)
+			}
+			if (tt.wantStatus == http.StatusOK) != (len(dispatcher.calls) == 1) {
+				t.Fatalf("unexpected calls %+v", dispatcher.calls)
+			}
+		})
+	}
+}
+
+func TestGeneric_TruncatesText(t *testing.T) {
+	dispatcher := &fakeDispatcher{}
+	endpoint := config.HookEndpoint{Name: "logs", Token: "t", MessageType: config.HookMessageText, Template: "{{ .log }}"}
+	body := `{"log": "` + strings.Repeat("x", 3000) + `"}`
+	serveGeneric(t, endpoint, dispatcher, http.Header{"Authorization": {"Bearer t"}}, body)
+	if len(dispatcher.calls) != 1 {
+		t.Fatalf("expected one call, got %+v", dispatcher.calls)
+	}
+	content := dispatcher.calls[0].params["content"].(string)
+	if len(content) > maxTextBytes || !strings.HasSuffix(content, "(truncated)") {
+		t.Fatalf("expected content truncated to %d bytes, got %d", maxTextBytes, len(content))
+	}
+}
+
+func TestNewGenericHandler_Errors(t *testing.T) {
+	valid := config.HookEndpoint{Name: "ci", Token: "t", MessageType: config.HookMessageMarkdown, Template: "{{ .x }}"}
+	tests := []struct {
+		name    string
+		modify  func(e *config.HookEndpoint)
+		wantErr string
+	}{
+		{name: "unknown bot", modify: func(e *config.HookEndpoint) { e.Bot = "ops" }, wantErr: `unknown bot "ops"`},
+		{name: "invalid template", modify: func(e *config.HookEndpoint) { e.Template = "{{ .x" }, wantErr: "invalid hooks.endpoints.ci template"},
+		{name: "invalid param template", modify: func(e *config.HookEndpoint) { e.Params = map[string]any{"card_action": map[string]any{"url": "{{"}} }, wantErr: "invalid hooks.endpoints.ci.params.card_action.url template"},
+		{name: "invalid JSONPath", modify: func(e *config.HookEndpoint) { e.Params = map[string]any{"mentioned_list": "$.users[first]"} }, wantErr: "invalid hooks.endpoints.ci.params.mentioned_list JSONPath"},
+		{name: "invalid config", modify: func(e *config.HookEndpoint) { e.Token = "" }, wantErr: "needs a token or an hmac_secret"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			endpoint := valid
+			tt.modify(&endpoint)
+			if _, err := NewGenericHandler(endpoint, nil); err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("expected error containing %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
+
+	dispatcher := &fakeDispatcher{disabled: []string{"send_markdown"}}
+	if _, err := NewGenericHandler(valid, dispatcher); err == nil || !strings.Contains(err.Error(), "send_markdown tool, which is disabled") {
+		t.Fatalf("expected disabled tool error, got %v", err)
+	}
+}
+
+func TestJSONPath(t *testing.T) {
+	data := map[string]any{
+		"a":     map[string]any{"b c": []any{"x", "y", "z"}},
+		"empty": nil,
+	}
+	tests := []struct {
+		expr   string
+		want   any
+		wantOK bool
+	}{
+		{expr: "$", want: data, wantOK: true},
+		{expr: "$.a['b c'][0]", want: "x", wantOK: true},
+		{expr: `$["a"]["b c"][-1]`, want: "z", wantOK: true},
+		{expr: "$.a['b c'][3]", wantOK: false},
+		{expr: "$.a.missing", wantOK: false},
+		{expr: "$.empty", wantOK: false},
+		{expr: "$.a['b c'].x", wantOK: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			path, err := parseJSONPath(tt.expr)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			got, ok := path.lookup(data)
+			if ok != tt.wantOK || (ok && !reflect.DeepEqual(got, tt.want)) {
+				t.Fatalf("expected %v (%v), got %v (%v)", tt.want, tt.wantOK, got, ok)
+			}
+		})
+	}
+
+	for _, expr := range []string{"a.b", "$.", "$..a", "$[", "$[*]", "$x"} {
+		if _, err := parseJSONPath(expr); err == nil {
+			t.Errorf("expected %q to be rejected", expr)
+		}
+	}
+}
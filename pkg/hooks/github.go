@@ -0,0 +1,172 @@
+package hooks
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/futuretea/wecom-bot-mcp-server/pkg/core/config"
+)
+
+// githubEvent holds the fields of the relayed GitHub webhook payloads
+type githubEvent struct {
+	Action      string             `json:"action"`
+	Ref         string             `json:"ref"`
+	Deleted     bool               `json:"deleted"`
+	Compare     string             `json:"compare"`
+	Commits     []githubCommit     `json:"commits"`
+	Repository  githubRepository   `json:"repository"`
+	Sender      githubUser         `json:"sender"`
+	PullRequest *githubPullRequest `json:"pull_request"`
+	Release     *githubRelease     `json:"release"`
+	WorkflowRun *githubWorkflowRun `json:"workflow_run"`
+}
+
+type githubRepository struct {
+	FullName string `json:"full_name"`
+	HTMLURL  string `json:"html_url"`
+}
+
+type githubUser struct {
+	Login string `json:"login"`
+}
+
+type githubCommit struct {
+	ID      string `json:"id"`
+	Message string `json:"message"`
+	Author  struct {
+		Name string `json:"name"`
+	} `json:"author"`
+}
+
+type githubPullRequest struct {
+	Number  int    `json:"number"`
+	Title   string `json:"title"`
+	HTMLURL string `json:"html_url"`
+	Merged  bool   `json:"merged"`
+	Base    struct {
+		Ref string `json:"ref"`
+	} `json:"base"`
+	Head struct {
+		Ref string `json:"ref"`
+	} `json:"head"`
+}
+
+type githubRelease struct {
+	TagName    string `json:"tag_name"`
+	Name       string `json:"name"`
+	HTMLURL    string `json:"html_url"`
+	Body       string `json:"body"`
+	Prerelease bool   `json:"prerelease"`
+}
+
+type githubWorkflowRun struct {
+	Name       string     `json:"name"`
+	HeadBranch string     `json:"head_branch"`
+	HTMLURL    string     `json:"html_url"`
+	RunNumber  int        `json:"run_number"`
+	Conclusion string     `json:"conclusion"`
+	Actor      githubUser `json:"actor"`
+	HeadCommit struct {
+		Message string `json:"message"`
+	} `json:"head_commit"`
+}
+
+// github verifies and parses GitHub webhooks
+var github = repoProvider{
+	name: "GitHub",
+	key:  "hooks.github",
+	verify: func(r *http.Request, body []byte, secret string) bool {
+		return validSignature(r.Header.Get("X-Hub-Signature-256"), body, secret)
+	},
+	parse: parseGitHubEvent,
+}
+
+// NewGitHubHandler creates the handler of the GitHub webhook. A nil
+// dispatcher only checks the configuration.
+func NewGitHubHandler(cfg config.RepoHook, dispatcher Dispatcher) (*RepoHandler, error) {
+	return newRepoHandler(cfg, github, dispatcher)
+}
+
+// parseGitHubEvent parses a GitHub webhook named by its X-GitHub-Event header
+func parseGitHubEvent(r *http.Request, body []byte) (*repoEvent, string, error) {
+	name := r.Header.Get("X-GitHub-Event")
+	if name == "ping" {
+		return nil, "pong", nil
+	}
+	var payload githubEvent
+	if err := json.Unmarshal(body, &payload); err != nil {
+		return nil, "", err
+	}
+	event := &repoEvent{repo: payload.Repository.FullName, repoURL: payload.Repository.HTMLURL}
+	actor := payload.Sender.Login
+
+	switch {
+	case name == "push":
+		if payload.Deleted {
+			return nil, "Ignored deleted ref " + payload.Ref, nil
+		}
+		event.kind = config.RepoEventPush
+		event.url = payload.Compare
+		if tag, ok := strings.CutPrefix(payload.Ref, "refs/tags/"); ok {
+			event.headline = fmt.Sprintf("%s pushed tag %s", actor, tag)
+			break
+		}
+		branch := strings.TrimPrefix(payload.Ref, "refs/heads/")
+		event.headline = fmt.Sprintf("%s pushed %s to %s", actor, plural(len(payload.Commits), "commit"), branch)
+		event.details = commitDetails(payload.Commits, len(payload.Commits), func(c githubCommit) string {
+			return fmt.Sprintf("%s %s (%s)", shortSHA(c.ID), firstLine(c.Message), c.Author.Name)
+		})
+
+	case name == "pull_request" && payload.PullRequest != nil:
+		pr := payload.PullRequest
+		var verb string
+		switch {
+		case payload.Action == "opened" || payload.Action == "reopened":
+			verb = payload.Action
+		case payload.Action == "closed" && pr.Merged:
+			verb = "merged"
+		default:
+			return nil, fmt.Sprintf("Ignored pull_request %s action", payload.Action), nil
+		}
+		event.kind = config.RepoEventPullRequest
+		event.headline = fmt.Sprintf("%s %s pull request #%d: %s", actor, verb, pr.Number, pr.Title)
+		event.url = pr.HTMLURL
+		event.details = []string{fmt.Sprintf("%s → %s", pr.Head.Ref, pr.Base.Ref)}
+
+	case name == "release" && payload.Release != nil:
+		if payload.Action != "published" {
+			return nil, fmt.Sprintf("Ignored release %s action", payload.Action), nil
+		}
+		release := payload.Release
+		kind := "release"
+		if release.Prerelease {
+			kind = "pre-release"
+		}
+		event.kind = config.RepoEventRelease
+		event.headline = fmt.Sprintf("%s published %s %s", actor, kind, release.TagName)
+		if release.Name != "" && release.Name != release.TagName {
+			event.headline += ": " + release.Name
+		}
+		event.url = release.HTMLURL
+		event.details = textDetails(release.Body)
+
+	case name == "workflow_run" && payload.WorkflowRun != nil:
+		run := payload.WorkflowRun
+		if payload.Action != "completed" || (run.Conclusion != "failure" && run.Conclusion != "timed_out") {
+			return nil, fmt.Sprintf("Ignored workflow_run %s action with conclusion %q", payload.Action, run.Conclusion), nil
+		}
+		event.kind = config.RepoEventWorkflow
+		event.headline = fmt.Sprintf("Workflow %s failed on %s (run #%d)", run.Name, run.HeadBranch, run.RunNumber)
+		event.url = run.HTMLURL
+		if message := firstLine(run.HeadCommit.Message); message != "" {
+			event.details = append(event.details, message)
+		}
+		event.details = append(event.details, "Triggered by "+run.Actor.Login)
+
+	default:
+		return nil, fmt.Sprintf("Ignored unsupported %q event", name), nil
+	}
+	return event, "", nil
+}
@@ -0,0 +1,164 @@
+package hooks
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/futuretea/wecom-bot-mcp-server/pkg/core/config"
+)
+
+// gitlabZeroSHA is the commit of a deleted ref
+const gitlabZeroSHA = "0000000000000000000000000000000000000000"
+
+// gitlabEvent holds the fields of the relayed GitLab webhook payloads
+type gitlabEvent struct {
+	ObjectKind        string           `json:"object_kind"`
+	Ref               string           `json:"ref"`
+	After             string           `json:"after"`
+	UserName          string           `json:"user_name"`
+	TotalCommitsCount int              `json:"total_commits_count"`
+	Commits           []gitlabCommit   `json:"commits"`
+	User              gitlabUser       `json:"user"`
+	Project           gitlabProject    `json:"project"`
+	ObjectAttributes  gitlabAttributes `json:"object_attributes"`
+	Commit            gitlabCommit     `json:"commit"`
+
+	// Release hook fields
+	Action      string `json:"action"`
+	Tag         string `json:"tag"`
+	Name        string `json:"name"`
+	URL         string `json:"url"`
+	Description string `json:"description"`
+}
+
+type gitlabUser struct {
+	Name     string `json:"name"`
+	Username string `json:"username"`
+}
+
+type gitlabProject struct {
+	PathWithNamespace string `json:"path_with_namespace"`
+	WebURL            string `json:"web_url"`
+}
+
+type gitlabCommit struct {
+	ID      string `json:"id"`
+	Title   string `json:"title"`
+	Message string `json:"message"`
+	Author  struct {
+		Name string `json:"name"`
+	} `json:"author"`
+}
+
+// gitlabAttributes holds the object attributes of merge request and pipeline events
+type gitlabAttributes struct {
+	ID           int    `json:"id"`
+	IID          int    `json:"iid"`
+	Title        string `json:"title"`
+	URL          string `json:"url"`
+	Action       string `json:"action"`
+	Status       string `json:"status"`
+	Ref          string `json:"ref"`
+	SourceBranch string `json:"source_branch"`
+	TargetBranch string `json:"target_branch"`
+}
+
+// gitlab verifies and parses GitLab webhooks
+var gitlab = repoProvider{
+	name: "GitLab",
+	key:  "hooks.gitlab",
+	verify: func(r *http.Request, _ []byte, secret string) bool {
+		return subtle.ConstantTimeCompare([]byte(r.Header.Get("X-Gitlab-Token")), []byte(secret)) == 1
+	},
+	parse: parseGitLabEvent,
+}
+
+// NewGitLabHandler creates the handler of the GitLab webhook. A nil
+// dispatcher only checks the configuration.
+func NewGitLabHandler(cfg config.RepoHook, dispatcher Dispatcher) (*RepoHandler, error) {
+	return newRepoHandler(cfg, gitlab, dispatcher)
+}
+
+// parseGitLabEvent parses a GitLab webhook named by its object_kind
+func parseGitLabEvent(_ *http.Request, body []byte) (*repoEvent, string, error) {
+	var payload gitlabEvent
+	if err := json.Unmarshal(body, &payload); err != nil {
+		return nil, "", err
+	}
+	event := &repoEvent{repo: payload.Project.PathWithNamespace, repoURL: payload.Project.WebURL}
+	actor := payload.User.Username
+	if actor == "" {
+		actor = payload.UserName
+	}
+	attributes := payload.ObjectAttributes
+
+	switch payload.ObjectKind {
+	case "push", "tag_push":
+		if payload.After == gitlabZeroSHA {
+			return nil, "Ignored deleted ref " + payload.Ref, nil
+		}
+		event.kind = config.RepoEventPush
+		if tag, ok := strings.CutPrefix(payload.Ref, "refs/tags/"); ok {
+			event.headline = fmt.Sprintf("%s pushed tag %s", actor, tag)
+			event.url = payload.Project.WebURL + "/-/tags/" + tag
+			break
+		}
+		branch := strings.TrimPrefix(payload.Ref, "refs/heads/")
+		event.headline = fmt.Sprintf("%s pushed %s to %s", actor, plural(payload.TotalCommitsCount, "commit"), branch)
+		event.url = payload.Project.WebURL + "/-/commits/" + branch
+		event.details = commitDetails(payload.Commits, payload.TotalCommitsCount, func(c gitlabCommit) string {
+			return fmt.Sprintf("%s %s (%s)", shortSHA(c.ID), firstLine(c.Message), c.Author.Name)
+		})
+
+	case "merge_request":
+		var verb string
+		switch attributes.Action {
+		case "open":
+			verb = "opened"
+		case "reopen":
+			verb = "reopened"
+		case "merge":
+			verb = "merged"
+		default:
+			return nil, fmt.Sprintf("Ignored merge_request %s action", attributes.Action), nil
+		}
+		event.kind = config.RepoEventPullRequest
+		event.headline = fmt.Sprintf("%s %s merge request !%d: %s", actor, verb, attributes.IID, attributes.Title)
+		event.url = attributes.URL
+		event.details = []string{fmt.Sprintf("%s → %s", attributes.SourceBranch, attributes.TargetBranch)}
+
+	case "release":
+		if payload.Action != "create" {
+			return nil, fmt.Sprintf("Ignored release %s action", payload.Action), nil
+		}
+		event.kind = config.RepoEventRelease
+		event.headline = fmt.Sprintf("Published release %s", payload.Tag)
+		if payload.Name != "" && payload.Name != payload.Tag {
+			event.headline += ": " + payload.Name
+		}
+		event.url = payload.URL
+		event.details = textDetails(payload.Description)
+
+	case "pipeline":
+		if attributes.Status != "failed" {
+			return nil, fmt.Sprintf("Ignored pipeline with status %q", attributes.Status), nil
+		}
+		event.kind = config.RepoEventWorkflow
+		event.headline = fmt.Sprintf("Pipeline #%d failed on %s", attributes.ID, attributes.Ref)
+		event.url = attributes.URL
+		if event.url == "" {
+			event.url = fmt.Sprintf("%s/-/pipelines/%d", payload.Project.WebURL, attributes.ID)
+		}
+		if title := firstLine(payload.Commit.Message); title != "" {
+			event.details = append(event.details, title)
+		}
+		event.details = append(event.details, "Triggered by "+actor)
+
+	default:
+		return nil, fmt.Sprintf("Ignored unsupported %q event", payload.ObjectKind), nil
+	}
+	return event, "", nil
+}
@@ -0,0 +1,184 @@
+// Package hooks serves inbound webhooks that relay events from other systems,
+// such as Prometheus Alertmanager, GitHub, GitLab or any service that posts
+// JSON, to WeCom. Messages are sent by calling the send tools, so they go
+// through the same send policies as MCP tool calls.
+package hooks
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"text/template"
+
+	"github.com/futuretea/wecom-bot-mcp-server/pkg/core/config"
+	"github.com/futuretea/wecom-bot-mcp-server/pkg/core/logging"
+	wecomToolset "github.com/futuretea/wecom-bot-mcp-server/pkg/toolset/wecom"
+)
+
+// maxRequestBytes limits the size of a webhook request body
+const maxRequestBytes = 1 << 20
+
+// WeCom limits on message content
+const (
+	maxTextBytes     = 2048
+	maxMarkdownBytes = 4096
+)
+
+// Dispatcher runs send tools on behalf of the webhooks.
+type Dispatcher interface {
+	// HasTool reports whether the tool is registered and enabled.
+	HasTool(name string) bool
+
+	// CallTool runs the tool with the given arguments.
+	CallTool(name string, params map[string]any) (string, error)
+}
+
+// Register adds the enabled webhooks to mux. It fails when a webhook's
+// template is invalid or the tool it sends with is disabled.
+func Register(mux *http.ServeMux, cfg config.Hooks, dispatcher Dispatcher) error {
+	for _, endpoint := range cfg.Endpoints {
+		handler, err := NewGenericHandler(endpoint, dispatcher)
+		if err != nil {
+			return err
+		}
+		mux.Handle("POST /hooks/"+endpoint.Name, handler)
+		logging.Info("Webhook %s enabled at /hooks/%s", endpoint.Name, endpoint.Name)
+	}
+	for _, hook := range []struct {
+		cfg    config.RepoHook
+		path   string
+		create func(config.RepoHook, Dispatcher) (*RepoHandler, error)
+	}{
+		{cfg: cfg.GitHub, path: "/hooks/github", create: NewGitHubHandler},
+		{cfg: cfg.GitLab, path: "/hooks/gitlab", create: NewGitLabHandler},
+	} {
+		if !hook.cfg.Enabled {
+			continue
+		}
+		handler, err := hook.create(hook.cfg, dispatcher)
+		if err != nil {
+			return err
+		}
+		mux.Handle("POST "+hook.path, handler)
+		logging.Info("%s webhook enabled at %s", handler.provider.name, hook.path)
+	}
+	if cfg.Alertmanager.Enabled {
+		handler, err := NewAlertmanagerHandler(cfg.Alertmanager, dispatcher)
+		if err != nil {
+			return err
+		}
+		mux.Handle("POST /hooks/alertmanager/{bot}", handler)
+		logging.Info("Alertmanager webhook enabled at /hooks/alertmanager/%s", wecomToolset.DefaultBotName)
+	}
+	return nil
+}
+
+// templateFuncs are the functions available to webhook templates
+var templateFuncs = template.FuncMap{
+	"join":     strings.Join,
+	"upper":    strings.ToUpper,
+	"lower":    strings.ToLower,
+	"jsonpath": jsonPathFunc,
+	"json":     toJSON,
+}
+
+// toJSON is the json template function, which encodes a value as JSON
+func toJSON(v any) (string, error) {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return "", err
+	}
+	return string(b), nil
+}
+
+// parseTemplate parses a webhook template with the template functions
+func parseTemplate(name, text string) (*template.Template, error) {
+	tmpl, err := template.New(name).Funcs(templateFuncs).Option("missingkey=zero").Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s template: %w", name, err)
+	}
+	return tmpl, nil
+}
+
+// executeTemplate renders a template and trims surrounding whitespace
+func executeTemplate(tmpl *template.Template, data any) (string, error) {
+	var b strings.Builder
+	if err := tmpl.Execute(&b, data); err != nil {
+		return "", fmt.Errorf("failed to render %s template: %w", tmpl.Name(), err)
+	}
+	return strings.TrimSpace(b.String()), nil
+}
+
+// truncateMarkdown shortens markdown content to the WeCom size limit, cutting
+// at a line break and noting that the message was truncated
+func truncateMarkdown(content string) string {
+	return truncate(content, maxMarkdownBytes)
+}
+
+// truncate shortens content to limit bytes, cutting at a line break, or at a
+// character when there is none, and noting that the message was truncated
+func truncate(content string, limit int) string {
+	const marker = "\n\n(truncated)"
+	if len(content) <= limit {
+		return content
+	}
+	cut := content[:limit-len(marker)]
+	if i := strings.LastIndexByte(cut, '\n'); i > 0 {
+		cut = cut[:i]
+	} else {
+		cut = strings.ToValidUTF8(cut, "")
+	}
+	return strings.TrimRight(cut, "\n") + marker
+}
+
+// requireTool checks that a webhook's send tool is enabled
+func requireTool(dispatcher Dispatcher, hook, tool string) error {
+	if !dispatcher.HasTool(tool) {
+		return fmt.Errorf("%s sends with the %s tool, which is disabled", hook, tool)
+	}
+	return nil
+}
+
+// checkRequest verifies the bot named in the path and the bearer token,
+// writing an error response and returning false when either is wrong
+func checkRequest(w http.ResponseWriter, r *http.Request, token string) bool {
+	if bot := r.PathValue("bot"); bot != wecomToolset.DefaultBotName {
+		writeError(w, http.StatusNotFound, fmt.Sprintf("unknown bot %q; the only bot is %q", bot, wecomToolset.DefaultBotName))
+		return false
+	}
+	if token != "" && !validToken(r, token) {
+		writeError(w, http.StatusUnauthorized, "missing or invalid bearer token")
+		return false
+	}
+	return true
+}
+
+// validToken reports whether a request sends token as its bearer token
+func validToken(r *http.Request, token string) bool {
+	presented, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	return ok && subtle.ConstantTimeCompare([]byte(presented), []byte(token)) == 1
+}
+
+// response is the JSON body of a webhook response
+type response struct {
+	Result string `json:"result,omitempty"`
+	Error  string `json:"error,omitempty"`
+}
+
+// writeResult writes a successful webhook response
+func writeResult(w http.ResponseWriter, result string) {
+	writeJSON(w, http.StatusOK, response{Result: result})
+}
+
+// writeError writes a failed webhook response
+func writeError(w http.ResponseWriter, status int, message string) {
+	writeJSON(w, status, response{Error: message})
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
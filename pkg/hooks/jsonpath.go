@@ -0,0 +1,111 @@
+package hooks
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// jsonPath is a parsed JSONPath expression. Only the child operators are
+// supported: $.name, $['name'] and $[index], where a negative index counts
+// from the end of a list.
+type jsonPath []pathStep
+
+// pathStep selects an object member by key or a list item by index
+type pathStep struct {
+	key     string
+	index   int
+	isIndex bool
+}
+
+// isJSONPath reports whether a configured string is a JSONPath expression
+// rather than a template
+func isJSONPath(s string) bool {
+	return s == "$" || strings.HasPrefix(s, "$.") || strings.HasPrefix(s, "$[")
+}
+
+// parseJSONPath parses a JSONPath expression
+func parseJSONPath(expr string) (jsonPath, error) {
+	rest, ok := strings.CutPrefix(expr, "$")
+	if !ok {
+		return nil, fmt.Errorf("%q does not start with $", expr)
+	}
+	var path jsonPath
+	for rest != "" {
+		switch rest[0] {
+		case '.':
+			rest = rest[1:]
+			end := strings.IndexAny(rest, ".[")
+			if end < 0 {
+				end = len(rest)
+			}
+			if end == 0 {
+				return nil, fmt.Errorf("%q has an empty member name", expr)
+			}
+			path = append(path, pathStep{key: rest[:end]})
+			rest = rest[end:]
+		case '[':
+			end := strings.IndexByte(rest, ']')
+			if end < 0 {
+				return nil, fmt.Errorf("%q has an unclosed [", expr)
+			}
+			inner := rest[1:end]
+			rest = rest[end+1:]
+			if len(inner) >= 2 && (inner[0] == '\'' || inner[0] == '"') && inner[len(inner)-1] == inner[0] {
+				path = append(path, pathStep{key: inner[1 : len(inner)-1]})
+				continue
+			}
+			index, err := strconv.Atoi(inner)
+			if err != nil {
+				return nil, fmt.Errorf("%q has an unsupported selector [%s]", expr, inner)
+			}
+			path = append(path, pathStep{index: index, isIndex: true})
+		default:
+			return nil, fmt.Errorf("%q has an unexpected %q", expr, rest[0])
+		}
+	}
+	return path, nil
+}
+
+// lookup returns the value the path selects in decoded JSON, reporting false
+// when it does not exist
+func (p jsonPath) lookup(data any) (any, bool) {
+	for _, step := range p {
+		if step.isIndex {
+			list, ok := data.([]any)
+			if !ok {
+				return nil, false
+			}
+			index := step.index
+			if index < 0 {
+				index += len(list)
+			}
+			if index < 0 || index >= len(list) {
+				return nil, false
+			}
+			data = list[index]
+			continue
+		}
+		object, ok := data.(map[string]any)
+		if !ok {
+			return nil, false
+		}
+		if data, ok = object[step.key]; !ok {
+			return nil, false
+		}
+	}
+	return data, data != nil
+}
+
+// jsonPathFunc is the jsonpath template function. It returns an empty string
+// when the value does not exist, so optional fields render as nothing.
+func jsonPathFunc(expr string, data any) (any, error) {
+	path, err := parseJSONPath(expr)
+	if err != nil {
+		return nil, err
+	}
+	if value, ok := path.lookup(data); ok {
+		return value, nil
+	}
+	return "", nil
+}
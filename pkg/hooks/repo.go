@@ -0,0 +1,242 @@
+package hooks
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"path"
+	"slices"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/futuretea/wecom-bot-mcp-server/pkg/core/config"
+	"github.com/futuretea/wecom-bot-mcp-server/pkg/core/logging"
+)
+
+// WeCom limits on news articles
+const (
+	maxNewsTitleBytes       = 128
+	maxNewsDescriptionBytes = 512
+)
+
+// maxEventDetails is the number of detail lines, such as commits, shown for an event
+const maxEventDetails = 5
+
+// repoEvent is a GitHub or GitLab event in a provider-neutral form
+type repoEvent struct {
+	// kind is one of config.RepoEvents
+	kind string
+	// repo is the owner/name of the repository
+	repo    string
+	repoURL string
+	// headline is a one-line summary such as "alice merged pull request #12: Fix login"
+	headline string
+	url      string
+	// details are plain text lines such as the pushed commits
+	details []string
+}
+
+// repoProvider verifies and parses the webhooks of GitHub or GitLab
+type repoProvider struct {
+	// name is the provider name used in messages
+	name string
+	// key is the configuration key of the hook
+	key string
+	// verify checks the signature or token of a request
+	verify func(r *http.Request, body []byte, secret string) bool
+	// parse returns the event of a request, or a nil event and the reason
+	// when the event is not relayed
+	parse func(r *http.Request, body []byte) (*repoEvent, string, error)
+}
+
+// RepoHandler relays GitHub or GitLab repository events to WeCom as markdown
+// or news messages.
+type RepoHandler struct {
+	cfg        config.RepoHook
+	provider   repoProvider
+	dispatcher Dispatcher
+}
+
+// newRepoHandler creates the handler of a provider's webhook. A nil
+// dispatcher only checks the configuration.
+func newRepoHandler(cfg config.RepoHook, provider repoProvider, dispatcher Dispatcher) (*RepoHandler, error) {
+	if err := cfg.Validate(provider.key); err != nil {
+		return nil, err
+	}
+	if cfg.MessageType == "" {
+		cfg.MessageType = config.HookMessageMarkdown
+	}
+	h := &RepoHandler{cfg: cfg, provider: provider, dispatcher: dispatcher}
+	if dispatcher != nil {
+		if err := requireTool(dispatcher, provider.key, h.tool()); err != nil {
+			return nil, err
+		}
+	}
+	return h, nil
+}
+
+// tool returns the send tool of the hook's message type
+func (h *RepoHandler) tool() string {
+	return "send_" + h.cfg.MessageType
+}
+
+// ServeHTTP handles a repository webhook
+func (h *RepoHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxRequestBytes))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Sprintf("failed to read request body: %v", err))
+		return
+	}
+	if !h.provider.verify(r, body, h.cfg.Secret) {
+		writeError(w, http.StatusUnauthorized, fmt.Sprintf("missing or invalid %s webhook signature", h.provider.name))
+		return
+	}
+
+	event, reason, err := h.provider.parse(r, body)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Sprintf("invalid %s payload: %v", h.provider.name, err))
+		return
+	}
+	if event == nil {
+		writeResult(w, reason)
+		return
+	}
+	if !h.relays(event) {
+		writeResult(w, fmt.Sprintf("Not relaying %s events of %s", event.kind, event.repo))
+		return
+	}
+
+	result, err := h.dispatcher.CallTool(h.tool(), h.message(event))
+	if err != nil {
+		logging.Warn("Failed to relay %s %s event of %s: %v", h.provider.name, event.kind, event.repo, err)
+		writeError(w, http.StatusBadGateway, err.Error())
+		return
+	}
+	writeResult(w, result)
+}
+
+// relays reports whether an event is relayed. The first route matching the
+// repository decides, and repositories that match no route are dropped.
+// Without routes, every repository is relayed.
+func (h *RepoHandler) relays(event *repoEvent) bool {
+	events := h.cfg.Events
+	if len(h.cfg.Routes) > 0 {
+		route, ok := h.route(event.repo)
+		if !ok {
+			return false
+		}
+		if len(route.Events) > 0 {
+			events = route.Events
+		}
+	}
+	return len(events) == 0 || slices.Contains(events, event.kind)
+}
+
+// route returns the first route matching a repository
+func (h *RepoHandler) route(repo string) (config.RepoRoute, bool) {
+	for _, route := range h.cfg.Routes {
+		if ok, _ := path.Match(route.Repo, repo); ok {
+			return route, true
+		}
+	}
+	return config.RepoRoute{}, false
+}
+
+// message renders an event as the arguments of the send tool
+func (h *RepoHandler) message(event *repoEvent) map[string]any {
+	url := event.url
+	if url == "" {
+		url = event.repoURL
+	}
+
+	if h.cfg.MessageType == config.HookMessageNews {
+		article := map[string]any{
+			"title": clip(fmt.Sprintf("[%s] %s", event.repo, event.headline), maxNewsTitleBytes),
+			"url":   url,
+		}
+		if len(event.details) > 0 {
+			article["description"] = clip(strings.Join(event.details, "\n"), maxNewsDescriptionBytes)
+		}
+		return map[string]any{"articles": []any{article}}
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "**[%s](%s)** ", event.repo, event.repoURL)
+	if url != "" {
+		fmt.Fprintf(&b, "[%s](%s)", event.headline, url)
+	} else {
+		b.WriteString(event.headline)
+	}
+	for _, detail := range event.details {
+		fmt.Fprintf(&b, "\n> %s", detail)
+	}
+	return map[string]any{"content": truncateMarkdown(b.String())}
+}
+
+// clip shortens s to limit bytes at a character boundary, ending it with an
+// ellipsis when it was cut
+func clip(s string, limit int) string {
+	const ellipsis = "..."
+	if len(s) <= limit {
+		return s
+	}
+	cut := s[:limit-len(ellipsis)]
+	for len(cut) > 0 && !utf8.ValidString(cut) {
+		cut = cut[:len(cut)-1]
+	}
+	return cut + ellipsis
+}
+
+// firstLine returns the first line of a commit message or description
+func firstLine(s string) string {
+	line, _, _ := strings.Cut(strings.TrimSpace(s), "\n")
+	return strings.TrimSpace(line)
+}
+
+// shortSHA abbreviates a commit hash
+func shortSHA(sha string) string {
+	if len(sha) > 7 {
+		return sha[:7]
+	}
+	return sha
+}
+
+// commitDetails lists at most maxEventDetails commits, noting how many more
+// were pushed in total
+func commitDetails[T any](commits []T, total int, describe func(T) string) []string {
+	var details []string
+	for i, commit := range commits {
+		if i == maxEventDetails {
+			break
+		}
+		details = append(details, describe(commit))
+	}
+	if more := total - len(details); more > 0 {
+		details = append(details, fmt.Sprintf("... and %d more", more))
+	}
+	return details
+}
+
+// textDetails returns the first non-empty lines of a description
+func textDetails(text string) []string {
+	var details []string
+	for _, line := range strings.Split(text, "\n") {
+		if line = strings.TrimSpace(line); line == "" {
+			continue
+		}
+		if len(details) == maxEventDetails {
+			details = append(details, "...")
+			break
+		}
+		details = append(details, line)
+	}
+	return details
+}
+
+// plural returns "1 commit" or "3 commits"
+func plural(n int, noun string) string {
+	if n == 1 {
+		return fmt.Sprintf("1 %s", noun)
+	}
+	return fmt.Sprintf("%d %ss", n, noun)
+}
@@ -0,0 +1,264 @@
+package hooks
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/futuretea/wecom-bot-mcp-server/pkg/core/config"
+)
+
+const githubPushPayload = `{
+  "ref": "refs/heads/main",
+  "compare": "https://github.com/acme/api/compare/a...b",
+  "repository": {"full_name": "acme/api", "html_url": "https://github.com/acme/api"},
+  "sender": {"login": "alice"},
+  "commits": [
+    {"id": "0123456789abcdef", "message": "Fix login\n\nDetails", "author": {"name": "Alice"}},
+    {"id": "fedcba9876543210", "message": "Add tests", "author": {"name": "Bob"}}
+  ]
+}`
+
+const githubPullRequestPayload = `{
+  "action": "closed",
+  "repository": {"full_name": "acme/api", "html_url": "https://github.com/acme/api"},
+  "sender": {"login": "bob"},
+  "pull_request": {"number": 12, "title": "Fix login", "html_url": "https://github.com/acme/api/pull/12", "merged": true, "base": {"ref": "main"}, "head": {"ref": "fix-login"}}
+}`
+
+const githubReleasePayload = `{
+  "action": "published",
+  "repository": {"full_name": "acme/api", "html_url": "https://github.com/acme/api"},
+  "sender": {"login": "alice"},
+  "release": {"tag_name": "v1.2.0", "name": "Spring release", "html_url": "https://github.com/acme/api/releases/tag/v1.2.0", "body": "## Changes\n\n- Faster login"}
+}`
+
+const githubWorkflowPayload = `{
+  "action": "completed",
+  "repository": {"full_name": "acme/api", "html_url": "https://github.com/acme/api"},
+  "sender": {"login": "alice"},
+  "workflow_run": {"name": "CI", "head_branch": "main", "html_url": "https://github.com/acme/api/actions/runs/1", "run_number": 42, "conclusion": "failure", "actor": {"login": "alice"}, "head_commit": {"message": "Fix login"}}
+}`
+
+const gitlabMergeRequestPayload = `{
+  "object_kind": "merge_request",
+  "user": {"name": "Bob", "username": "bob"},
+  "project": {"path_with_namespace": "acme/web", "web_url": "https://gitlab.com/acme/web"},
+  "object_attributes": {"iid": 7, "title": "Dark mode", "url": "https://gitlab.com/acme/web/-/merge_requests/7", "action": "open", "source_branch": "dark", "target_branch": "main"}
+}`
+
+const gitlabPipelinePayload = `{
+  "object_kind": "pipeline",
+  "user": {"name": "Alice", "username": "alice"},
+  "project": {"path_with_namespace": "acme/web", "web_url": "https://gitlab.com/acme/web"},
+  "object_attributes": {"id": 99, "status": "failed", "ref": "main"},
+  "commit": {"message": "Bump deps\n\nmore"}
+}`
+
+// serveRepo registers the GitHub and GitLab hooks and sends a request to path
+func serveRepo(t *testing.T, cfg config.Hooks, dispatcher Dispatcher, path string, header http.Header, body string) *httptest.ResponseRecorder {
+	t.Helper()
+	mux := http.NewServeMux()
+	if err := Register(mux, cfg, dispatcher); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	req := httptest.NewRequest(http.MethodPost, path, strings.NewReader(body))
+	for key, values := range header {
+		req.Header[key] = values
+	}
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, req)
+	return rec
+}
+
+// githubHeader returns the headers GitHub sends with an event
+func githubHeader(event, body, secret string) http.Header {
+	return http.Header{"X-Github-Event": {event}, "X-Hub-Signature-256": {sign(body, secret)}}
+}
+
+func TestGitHub_Markdown(t *testing.T) {
+	tests := []struct {
+		event   string
+		payload string
+		want    string
+	}{
+		{
+			event:   "push",
+			payload: githubPushPayload,
+			want:    "**[acme/api](https://github.com/acme/api)** [alice pushed 2 commits to main](https://github.com/acme/api/compare/a...b)\n> 0123456 Fix login (Alice)\n> fedcba9 Add tests (Bob)",
+		},
+		{
+			event:   "pull_request",
+			payload: githubPullRequestPayload,
+			want:    "**[acme/api](https://github.com/acme/api)** [bob merged pull request #12: Fix login](https://github.com/acme/api/pull/12)\n> fix-login → main",
+		},
+		{
+			event:   "release",
+			payload: githubReleasePayload,
+			want:    "**[acme/api](https://github.com/acme/api)** [alice published release v1.2.0: Spring release](https://github.com/acme/api/releases/tag/v1.2.0)\n> ## Changes\n> - Faster login",
+		},
+		{
+			event:   "workflow_run",
+			payload: githubWorkflowPayload,
+			want:    "**[acme/api](https://github.com/acme/api)** [Workflow CI failed on main (run #42)](https://github.com/acme/api/actions/runs/1)\n> Fix login\n> Triggered by alice",
+		},
+	}
+	cfg := config.Hooks{GitHub: config.RepoHook{Enabled: true, Secret: "s3cret"}}
+	for _, tt := range tests {
+		t.Run(tt.event, func(t *testing.T) {
+			dispatcher := &fakeDispatcher{}
+			rec := serveRepo(t, cfg, dispatcher, "/hooks/github", githubHeader(tt.event, tt.payload, "s3cret"), tt.payload)
+			if rec.Code != http.StatusOK {
+				t.Fatalf("expected 200, got %d: %s", rec.Code, rec.Body)
+			}
+			if len(dispatcher.calls) != 1 || dispatcher.calls[0].tool != "send_markdown" {
+				t.Fatalf("expected one send_markdown call, got %+v", dispatcher.calls)
+			}
+			if got := dispatcher.calls[0].params["content"]; got != tt.want {
+				t.Fatalf("expected content:\n%s\ngot:\n%s", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestGitHub_Ignored(t *testing.T) {
+	cfg := config.Hooks{GitHub: config.RepoHook{Enabled: true, Secret: "s3cret"}}
+	tests := []struct {
+		name    string
+		event   string
+		payload string
+	}{
+		{name: "ping", event: "ping", payload: `{"zen": "Keep it simple"}`},
+		{name: "unsupported event", event: "issues", payload: `{"action": "opened"}`},
+		{name: "closed without merge", event: "pull_request", payload: strings.Replace(githubPullRequestPayload, `"merged": true`, `"merged": false`, 1)},
+		{name: "successful workflow", event: "workflow_run", payload: strings.Replace(githubWorkflowPayload, `"failure"`, `"success"`, 1)},
+		{name: "deleted branch", event: "push", payload: `{"ref": "refs/heads/old", "deleted": true}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dispatcher := &fakeDispatcher{}
+			rec := serveRepo(t, cfg, dispatcher, "/hooks/github", githubHeader(tt.event, tt.payload, "s3cret"), tt.payload)
+			if rec.Code != http.StatusOK || len(dispatcher.calls) != 0 {
+				t.Fatalf("expected 200 without sending, got %d %s and calls %+v", rec.Code, rec.Body, dispatcher.calls)
+			}
+		})
+	}
+}
+
+func TestGitHub_Signature(t *testing.T) {
+	cfg := config.Hooks{GitHub: config.RepoHook{Enabled: true, Secret: "s3cret"}}
+	for _, header := range []http.Header{
+		{"X-Github-Event": {"push"}},
+		githubHeader("push", githubPushPayload, "guess"),
+	} {
+		dispatcher := &fakeDispatcher{}
+		rec := serveRepo(t, cfg, dispatcher, "/hooks/github", header, githubPushPayload)
+		if rec.Code != http.StatusUnauthorized || len(dispatcher.calls) != 0 {
+			t.Fatalf("expected 401, got %d: %s", rec.Code, rec.Body)
+		}
+	}
+}
+
+func TestGitLab_News(t *testing.T) {
+	cfg := config.Hooks{GitLab: config.RepoHook{Enabled: true, Secret: "t0ken", MessageType: config.HookMessageNews}}
+	tests := []struct {
+		name    string
+		payload string
+		want    map[string]any
+	}{
+		{
+			name:    "merge request",
+			payload: gitlabMergeRequestPayload,
+			want: map[string]any{
+				"title":       "[acme/web] bob opened merge request !7: Dark mode",
+				"url":         "https://gitlab.com/acme/web/-/merge_requests/7",
+				"description": "dark → main",
+			},
+		},
+		{
+			name:    "pipeline",
+			payload: gitlabPipelinePayload,
+			want: map[string]any{
+				"title":       "[acme/web] Pipeline #99 failed on main",
+				"url":         "https://gitlab.com/acme/web/-/pipelines/99",
+				"description": "Bump deps\nTriggered by alice",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dispatcher := &fakeDispatcher{}
+			rec := serveRepo(t, cfg, dispatcher, "/hooks/gitlab", http.Header{"X-Gitlab-Token": {"t0ken"}}, tt.payload)
+			if rec.Code != http.StatusOK {
+				t.Fatalf("expected 200, got %d: %s", rec.Code, rec.Body)
+			}
+			if len(dispatcher.calls) != 1 || dispatcher.calls[0].tool != "send_news" {
+				t.Fatalf("expected one send_news call, got %+v", dispatcher.calls)
+			}
+			articles := dispatcher.calls[0].params["articles"].([]any)
+			if len(articles) != 1 || !reflect.DeepEqual(articles[0], tt.want) {
+				t.Fatalf("expected article %v, got %v", tt.want, articles)
+			}
+		})
+	}
+
+	rec := serveRepo(t, cfg, &fakeDispatcher{}, "/hooks/gitlab", http.Header{"X-Gitlab-Token": {"guess"}}, gitlabPipelinePayload)
+	if rec.Code != http.StatusUnauthorized {
+		t.Fatalf("expected 401 for a wrong token, got %d", rec.Code)
+	}
+}
+
+func TestRepoHandler_Routes(t *testing.T) {
+	cfg := config.Hooks{GitHub: config.RepoHook{
+		Enabled: true,
+		Secret:  "s3cret",
+		Events:  []string{config.RepoEventRelease},
+		Routes: []config.RepoRoute{
+			{Repo: "acme/api", Events: []string{config.RepoEventPush, config.RepoEventRelease}},
+			{Repo: "acme/*"},
+		},
+	}}
+	tests := []struct {
+		name     string
+		event    string
+		payload  string
+		wantSent bool
+	}{
+		{name: "route events", event: "push", payload: githubPushPayload, wantSent: true},
+		{name: "excluded by route events", event: "pull_request", payload: githubPullRequestPayload, wantSent: false},
+		{name: "hook events", event: "release", payload: strings.ReplaceAll(githubReleasePayload, "acme/api", "acme/web"), wantSent: true},
+		{name: "excluded by hook events", event: "push", payload: strings.ReplaceAll(githubPushPayload, "acme/api", "acme/web"), wantSent: false},
+		{name: "no route", event: "release", payload: strings.ReplaceAll(githubReleasePayload, "acme/api", "other/api"), wantSent: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dispatcher := &fakeDispatcher{}
+			rec := serveRepo(t, cfg, dispatcher, "/hooks/github", githubHeader(tt.event, tt.payload, "s3cret"), tt.payload)
+			if rec.Code != http.StatusOK || (len(dispatcher.calls) == 1) != tt.wantSent {
+				t.Fatalf("expected sent=%v, got %d %s and calls %+v", tt.wantSent, rec.Code, rec.Body, dispatcher.calls)
+			}
+		})
+	}
+}
+
+func TestNewRepoHandler_Errors(t *testing.T) {
+	if _, err := NewGitHubHandler(config.RepoHook{Enabled: true}, nil); err == nil || !strings.Contains(err.Error(), "hooks.github.secret is required") {
+		t.Fatalf("expected secret error, got %v", err)
+	}
+	dispatcher := &fakeDispatcher{disabled: []string{"send_news"}}
+	if _, err := NewGitHubHandler(config.RepoHook{Enabled: true, Secret: "t", MessageType: config.HookMessageNews}, dispatcher); err == nil || !strings.Contains(err.Error(), "send_news tool, which is disabled") {
+		t.Fatalf("expected disabled tool error, got %v", err)
+	}
+}
+
+func TestClip(t *testing.T) {
+	if got := clip("短消息", 100); got != "短消息" {
+		t.Fatalf("expected short text unchanged, got %q", got)
+	}
+	got := clip(strings.Repeat("界", 50), 20)
+	if len(got) > 20 || !strings.HasSuffix(got, "...") || !strings.HasPrefix(got, "界界界界界") {
+		t.Fatalf("expected text cut at a character boundary, got %q", got)
+	}
+}
@@ -0,0 +1,123 @@
+package render
+
+import (
+	"image/color"
+	"strings"
+
+	"github.com/alecthomas/chroma/v2"
+	"github.com/alecthomas/chroma/v2/lexers"
+	"github.com/alecthomas/chroma/v2/styles"
+	"github.com/yuin/goldmark/text"
+	"golang.org/x/image/font"
+)
+
+// codePadding is the space between a code block and its background
+const codePadding = 10
+
+// codeStyle is the chroma style used to highlight code
+var codeStyle = styles.Get("github")
+
+// codeRun is a run of code characters with one color
+type codeRun struct {
+	text  string
+	color color.RGBA
+	bold  bool
+}
+
+// code lays out a code block, highlighted for the language when it is known.
+// Lines longer than the block are wrapped.
+func (l *layout) code(lines []text.Segment, language string, x, width int) {
+	var source strings.Builder
+	for _, segment := range lines {
+		source.Write(segment.Value(l.source))
+	}
+	code := strings.TrimRight(strings.ReplaceAll(source.String(), "\t", "    "), "\n")
+
+	m := l.fonts.mono.Metrics()
+	lineHeight := m.Height.Ceil() * 4 / 3
+	baseline := (lineHeight-m.Height.Ceil())/2 + m.Ascent.Ceil()
+	charWidth := measure(l.fonts.mono, "M")
+	columns := max(1, (width-2*codePadding)/charWidth)
+
+	rows := wrapCode(highlight(code, language), columns)
+	top := l.y
+	bottom := top + 2*codePadding + len(rows)*lineHeight
+	l.draw(func(c canvas) { c.fillRect(x, top, x+width, bottom, codeFill) })
+	for i, row := range rows {
+		y := top + codePadding + i*lineHeight + baseline
+		cx := x + codePadding
+		for _, run := range row {
+			face := l.fonts.mono
+			if run.bold {
+				face = l.fonts.monoBold
+			}
+			l.drawCode(face, cx, y, run)
+			cx += charWidth * len([]rune(run.text))
+		}
+	}
+	l.y = bottom
+}
+
+// drawCode queues drawing a run of code
+func (l *layout) drawCode(face font.Face, x, baseline int, run codeRun) {
+	l.draw(func(c canvas) { c.text(face, x, baseline, run.text, run.color) })
+}
+
+// highlight splits code into colored runs. Code in an unknown language is
+// detected from its content, falling back to plain text.
+func highlight(code, language string) []codeRun {
+	lexer := lexers.Get(language)
+	if lexer == nil && language == "" {
+		lexer = lexers.Analyse(code)
+	}
+	if lexer == nil {
+		lexer = lexers.Fallback
+	}
+	iterator, err := chroma.Coalesce(lexer).Tokenise(nil, code)
+	if err != nil {
+		return []codeRun{{text: code, color: foreground}}
+	}
+	var runs []codeRun
+	for _, token := range iterator.Tokens() {
+		entry := codeStyle.Get(token.Type)
+		run := codeRun{text: token.Value, color: foreground, bold: entry.Bold == chroma.Yes}
+		if entry.Colour.IsSet() {
+			run.color = color.RGBA{entry.Colour.Red(), entry.Colour.Green(), entry.Colour.Blue(), 0xff}
+		}
+		runs = append(runs, run)
+	}
+	return runs
+}
+
+// wrapCode splits colored runs into rows of at most columns characters,
+// breaking at newlines and wrapping longer lines
+func wrapCode(runs []codeRun, columns int) [][]codeRun {
+	rows := [][]codeRun{nil}
+	used := 0
+	for _, run := range runs {
+		for i, part := range strings.Split(run.text, "\n") {
+			if i > 0 {
+				rows = append(rows, nil)
+				used = 0
+			}
+			chars := []rune(part)
+			for len(chars) > 0 {
+				if used == columns {
+					rows = append(rows, nil)
+					used = 0
+				}
+				n := min(len(chars), columns-used)
+				piece := run
+				piece.text = string(chars[:n])
+				rows[len(rows)-1] = append(rows[len(rows)-1], piece)
+				used += n
+				chars = chars[n:]
+			}
+		}
+	}
+	// Chroma ends the code with a newline, which is not an extra row
+	for len(rows) > 1 && len(rows[len(rows)-1]) == 0 {
+		rows = rows[:len(rows)-1]
+	}
+	return rows
+}
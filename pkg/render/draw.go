@@ -0,0 +1,126 @@
+package render
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+	"sync"
+
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/gofont/gobold"
+	"golang.org/x/image/font/gofont/gobolditalic"
+	"golang.org/x/image/font/gofont/goitalic"
+	"golang.org/x/image/font/gofont/gomono"
+	"golang.org/x/image/font/gofont/gomonobold"
+	"golang.org/x/image/font/gofont/goregular"
+	"golang.org/x/image/font/opentype"
+	"golang.org/x/image/math/fixed"
+)
+
+// Colors of the light theme
+var (
+	background = color.RGBA{0xff, 0xff, 0xff, 0xff}
+	foreground = color.RGBA{0x1f, 0x23, 0x28, 0xff}
+	mutedColor = color.RGBA{0x59, 0x63, 0x6e, 0xff}
+	linkColor  = color.RGBA{0x09, 0x69, 0xda, 0xff}
+	ruleColor  = color.RGBA{0xd1, 0xd9, 0xe0, 0xff}
+	codeFill   = color.RGBA{0xf6, 0xf8, 0xfa, 0xff}
+	spanFill   = color.RGBA{0xef, 0xf1, 0xf3, 0xff}
+	headerFill = color.RGBA{0xf0, 0xf3, 0xf6, 0xff}
+)
+
+// Font sizes in pixels
+const (
+	bodySize = 15
+	monoSize = 14
+)
+
+// headingSizes are the font sizes of heading levels 1 to 6
+var headingSizes = [6]float64{28, 23, 19, 17, bodySize, bodySize}
+
+// parsedFonts holds the embedded Go fonts, parsed once
+var parsedFonts = sync.OnceValue(func() map[string]*opentype.Font {
+	fonts := make(map[string]*opentype.Font)
+	for name, data := range map[string][]byte{
+		"regular":    goregular.TTF,
+		"bold":       gobold.TTF,
+		"italic":     goitalic.TTF,
+		"boldItalic": gobolditalic.TTF,
+		"mono":       gomono.TTF,
+		"monoBold":   gomonobold.TTF,
+	} {
+		f, err := opentype.Parse(data)
+		if err != nil {
+			// The fonts are embedded, so this cannot fail at run time
+			panic("render: invalid embedded font " + name + ": " + err.Error())
+		}
+		fonts[name] = f
+	}
+	return fonts
+})
+
+// fonts holds the faces used by one rendering. Faces cache glyphs and are not
+// safe for concurrent use, so every rendering creates its own.
+type fonts struct {
+	regular, bold, italic, boldItalic font.Face
+	mono, monoBold                    font.Face
+	headings                          [6]font.Face
+}
+
+// newFonts creates the faces for a rendering
+func newFonts() *fonts {
+	parsed := parsedFonts()
+	face := func(name string, size float64) font.Face {
+		f, err := opentype.NewFace(parsed[name], &opentype.FaceOptions{Size: size, DPI: 72, Hinting: font.HintingFull})
+		if err != nil {
+			panic("render: cannot create font face: " + err.Error())
+		}
+		return f
+	}
+	f := &fonts{
+		regular:    face("regular", bodySize),
+		bold:       face("bold", bodySize),
+		italic:     face("italic", bodySize),
+		boldItalic: face("boldItalic", bodySize),
+		mono:       face("mono", monoSize),
+		monoBold:   face("monoBold", monoSize),
+	}
+	for i, size := range headingSizes {
+		f.headings[i] = face("bold", size)
+	}
+	return f
+}
+
+// canvas is an image with drawing helpers
+type canvas struct {
+	*image.RGBA
+}
+
+// newCanvas creates a canvas filled with the background color
+func newCanvas(width, height int) canvas {
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	draw.Draw(img, img.Bounds(), image.NewUniform(background), image.Point{}, draw.Src)
+	return canvas{img}
+}
+
+// fillRect fills the rectangle from (x0, y0) to (x1, y1)
+func (c canvas) fillRect(x0, y0, x1, y1 int, col color.Color) {
+	r := image.Rect(x0, y0, x1, y1).Intersect(c.Bounds())
+	draw.Draw(c.RGBA, r, image.NewUniform(col), image.Point{}, draw.Src)
+}
+
+// text draws s with its baseline starting at (x, baseline)
+func (c canvas) text(face font.Face, x, baseline int, s string, col color.Color) {
+	d := font.Drawer{
+		Dst:  c.RGBA,
+		Src:  image.NewUniform(col),
+		Face: face,
+		Dot:  fixed.P(x, baseline),
+	}
+	d.DrawString(s)
+}
+
+// measure returns the width of s in pixels
+func measure(face font.Face, s string) int {
+	return font.MeasureString(face, s).Ceil()
+}
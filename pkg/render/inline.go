@@ -0,0 +1,295 @@
+package render
+
+import (
+	"image/color"
+	"strings"
+	"unicode"
+
+	"github.com/yuin/goldmark/ast"
+	extast "github.com/yuin/goldmark/extension/ast"
+	"golang.org/x/image/font"
+)
+
+// style is the formatting of a run of inline text
+type style struct {
+	heading int // heading level, or 0 for body text
+	bold    bool
+	italic  bool
+	code    bool
+	link    bool
+	strike  bool
+}
+
+// span is a run of inline text with one style. A span of "\n" is a hard line
+// break.
+type span struct {
+	text  string
+	style style
+}
+
+// face returns the font face of a style
+func (f *fonts) face(st style) font.Face {
+	switch {
+	case st.code && st.bold:
+		return f.monoBold
+	case st.code:
+		return f.mono
+	case st.heading > 0:
+		return f.headings[st.heading-1]
+	case st.bold && st.italic:
+		return f.boldItalic
+	case st.bold:
+		return f.bold
+	case st.italic:
+		return f.italic
+	default:
+		return f.regular
+	}
+}
+
+// spans flattens the inline children of n into styled text runs
+func (l *layout) spans(n ast.Node, st style, out []span) []span {
+	for child := n.FirstChild(); child != nil; child = child.NextSibling() {
+		switch child := child.(type) {
+		case *ast.Text:
+			out = append(out, span{text: string(child.Value(l.source)), style: st})
+			switch {
+			case child.HardLineBreak():
+				out = append(out, span{text: "\n", style: st})
+			case child.SoftLineBreak():
+				out = append(out, span{text: " ", style: st})
+			}
+		case *ast.String:
+			out = append(out, span{text: string(child.Value), style: st})
+		case *ast.CodeSpan:
+			inner := st
+			inner.code = true
+			out = l.spans(child, inner, out)
+		case *ast.Emphasis:
+			inner := st
+			if child.Level >= 2 {
+				inner.bold = true
+			} else {
+				inner.italic = true
+			}
+			out = l.spans(child, inner, out)
+		case *ast.Link:
+			inner := st
+			inner.link = true
+			out = l.spans(child, inner, out)
+		case *ast.AutoLink:
+			inner := st
+			inner.link = true
+			out = append(out, span{text: string(child.Label(l.source)), style: inner})
+		case *ast.Image:
+			// Images are not fetched; their alt text is shown instead
+			inner := st
+			inner.italic = true
+			out = append(out, span{text: "[image: ", style: inner})
+			out = l.spans(child, inner, out)
+			out = append(out, span{text: "]", style: inner})
+		case *ast.RawHTML:
+			inner := st
+			inner.code = true
+			for i := 0; i < child.Segments.Len(); i++ {
+				segment := child.Segments.At(i)
+				out = append(out, span{text: string(segment.Value(l.source)), style: inner})
+			}
+		case *extast.Strikethrough:
+			inner := st
+			inner.strike = true
+			out = l.spans(child, inner, out)
+		case *extast.TaskCheckBox:
+			box := "[ ] "
+			if child.IsChecked {
+				box = "[x] "
+			}
+			inner := st
+			inner.code = true
+			out = append(out, span{text: box, style: inner})
+		default:
+			out = l.spans(child, st, out)
+		}
+	}
+	return out
+}
+
+// word is a piece of a line: a word, a run of spaces or a line break
+type word struct {
+	text  string
+	style style
+	width int
+}
+
+// placed is a word positioned on a line, relative to the line start
+type placed struct {
+	word
+	x int
+}
+
+// textLine is a laid out line of inline text
+type textLine struct {
+	words    []placed
+	width    int
+	baseline int // offset of the baseline from the top of the line
+	height   int
+}
+
+// words splits spans into words and runs of spaces, measured in their faces
+func (l *layout) words(spans []span) []word {
+	var out []word
+	for _, s := range spans {
+		face := l.fonts.face(s.style)
+		if s.text == "\n" {
+			out = append(out, word{text: "\n", style: s.style})
+			continue
+		}
+		text := strings.ReplaceAll(s.text, "\t", "    ")
+		start, space := 0, false
+		for i, r := range text {
+			if i > start && unicode.IsSpace(r) != space {
+				out = append(out, word{text: text[start:i], style: s.style, width: measure(face, text[start:i])})
+				start = i
+			}
+			space = unicode.IsSpace(r)
+		}
+		if start < len(text) {
+			out = append(out, word{text: text[start:], style: s.style, width: measure(face, text[start:])})
+		}
+	}
+	return out
+}
+
+// wrap lays out spans into lines no wider than width. Words wider than a line
+// are split between characters.
+func (l *layout) wrap(spans []span, width int) []textLine {
+	var lines []textLine
+	var current textLine
+	flush := func() {
+		// Trailing spaces do not count towards the line width
+		for len(current.words) > 0 && strings.TrimSpace(current.words[len(current.words)-1].text) == "" {
+			current.words = current.words[:len(current.words)-1]
+		}
+		current.width = 0
+		if n := len(current.words); n > 0 {
+			last := current.words[n-1]
+			current.width = last.x + last.width
+		}
+		l.measureLine(&current, spans)
+		lines = append(lines, current)
+		current = textLine{}
+	}
+	x := 0
+	for _, w := range l.words(spans) {
+		switch {
+		case w.text == "\n":
+			flush()
+			x = 0
+			continue
+		case strings.TrimSpace(w.text) == "":
+			if x == 0 {
+				continue
+			}
+		case x+w.width > width && x > 0:
+			flush()
+			x = 0
+		}
+		for w.width > width && x == 0 {
+			head, rest := l.splitWord(w, width)
+			current.words = append(current.words, placed{word: head, x: x})
+			flush()
+			x = 0
+			w = rest
+		}
+		current.words = append(current.words, placed{word: w, x: x})
+		x += w.width
+	}
+	if len(current.words) > 0 || len(lines) == 0 {
+		flush()
+	}
+	return lines
+}
+
+// splitWord splits w into a head at most width pixels wide and the rest
+func (l *layout) splitWord(w word, width int) (word, word) {
+	face := l.fonts.face(w.style)
+	runes := []rune(w.text)
+	// The head has at least one character, so that splitting always progresses
+	n := 1
+	for n < len(runes) && measure(face, string(runes[:n+1])) <= width {
+		n++
+	}
+	head := string(runes[:n])
+	rest := string(runes[n:])
+	return word{text: head, style: w.style, width: measure(face, head)},
+		word{text: rest, style: w.style, width: measure(face, rest)}
+}
+
+// measureLine sets the baseline and height of a line from its words, or from
+// the first span when the line is empty
+func (l *layout) measureLine(line *textLine, spans []span) {
+	faces := make([]font.Face, 0, len(line.words))
+	for _, w := range line.words {
+		faces = append(faces, l.fonts.face(w.style))
+	}
+	if len(faces) == 0 {
+		st := style{}
+		if len(spans) > 0 {
+			st = spans[0].style
+		}
+		faces = append(faces, l.fonts.face(st))
+	}
+	ascent, height := 0, 0
+	for _, face := range faces {
+		m := face.Metrics()
+		ascent = max(ascent, m.Ascent.Ceil())
+		height = max(height, m.Height.Ceil())
+	}
+	// Leave room between lines, split above and below the text
+	line.height = height * 3 / 2
+	line.baseline = (line.height-height)/2 + ascent
+}
+
+// inline lays out the inline content of n at the current position and moves
+// below it. align is -1, 0 or 1 for left, centered or right aligned text.
+func (l *layout) inline(n ast.Node, st style, x, width, align int, col color.RGBA) {
+	l.drawLines(l.wrap(l.spans(n, st, nil), width), x, width, align, col)
+}
+
+// drawLines draws laid out lines at the current position and moves below them
+func (l *layout) drawLines(lines []textLine, x, width, align int, col color.RGBA) {
+	for _, line := range lines {
+		left := x
+		switch align {
+		case 0:
+			left += (width - line.width) / 2
+		case 1:
+			left += width - line.width
+		}
+		for _, w := range line.words {
+			l.drawWord(w.word, left+w.x, l.y, l.y+line.baseline, line.height, col)
+		}
+		l.y += line.height
+	}
+}
+
+// drawWord queues drawing a word with its decorations
+func (l *layout) drawWord(w word, x, top, baseline, height int, col color.RGBA) {
+	face := l.fonts.face(w.style)
+	if w.style.link {
+		col = linkColor
+	}
+	ascent := face.Metrics().Ascent.Ceil()
+	l.ops = append(l.ops, func(c canvas) {
+		if w.style.code {
+			c.fillRect(x-1, top+2, x+w.width+1, top+height-2, spanFill)
+		}
+		c.text(face, x, baseline, w.text, col)
+		if w.style.link {
+			c.fillRect(x, baseline+2, x+w.width, baseline+3, col)
+		}
+		if w.style.strike {
+			c.fillRect(x, baseline-ascent/3, x+w.width, baseline-ascent/3+1, col)
+		}
+	})
+}
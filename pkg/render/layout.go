@@ -0,0 +1,120 @@
+package render
+
+import (
+	"image/color"
+	"strconv"
+
+	"github.com/yuin/goldmark/ast"
+	extast "github.com/yuin/goldmark/extension/ast"
+)
+
+// Spacing in pixels
+const (
+	pagePadding = 24
+	blockGap    = 12
+	listIndent  = 28
+	quoteIndent = 16
+)
+
+// layout positions the blocks of a document from top to bottom. Drawing is
+// queued as ops and replayed once the height of the image is known.
+type layout struct {
+	source []byte
+	fonts  *fonts
+	y      int
+	ops    []func(c canvas)
+}
+
+// newLayout creates a layout of the source of a parsed document
+func newLayout(source []byte, f *fonts) *layout {
+	return &layout{source: source, fonts: f}
+}
+
+// draw queues a drawing op
+func (l *layout) draw(op func(c canvas)) {
+	l.ops = append(l.ops, op)
+}
+
+// blocks lays out the block children of n in the column from x to x+width
+func (l *layout) blocks(n ast.Node, x, width int, col color.RGBA) {
+	gap := blockGap
+	if list, ok := n.Parent().(*ast.List); ok && list.IsTight {
+		gap = blockGap / 3
+	}
+	for child := n.FirstChild(); child != nil; child = child.NextSibling() {
+		if child != n.FirstChild() {
+			l.y += gap
+		}
+		l.block(child, x, width, col)
+	}
+}
+
+// block lays out a single block
+func (l *layout) block(n ast.Node, x, width int, col color.RGBA) {
+	switch n := n.(type) {
+	case *ast.Paragraph, *ast.TextBlock:
+		l.inline(n, style{}, x, width, -1, col)
+	case *ast.Heading:
+		if n.PreviousSibling() != nil {
+			l.y += blockGap / 2
+		}
+		l.inline(n, style{heading: n.Level, bold: true}, x, width, -1, col)
+		if n.Level <= 2 {
+			y := l.y + 2
+			l.draw(func(c canvas) { c.fillRect(x, y, x+width, y+1, ruleColor) })
+			l.y += 6
+		}
+	case *ast.ThematicBreak:
+		y := l.y + blockGap/2
+		l.draw(func(c canvas) { c.fillRect(x, y, x+width, y+2, ruleColor) })
+		l.y += blockGap
+	case *ast.FencedCodeBlock:
+		l.code(n.Lines().Sliced(0, n.Lines().Len()), string(n.Language(l.source)), x, width)
+	case *ast.CodeBlock:
+		l.code(n.Lines().Sliced(0, n.Lines().Len()), "", x, width)
+	case *ast.HTMLBlock:
+		// Raw HTML is not rendered, but shown as highlighted source
+		lines := n.Lines().Sliced(0, n.Lines().Len())
+		if n.HasClosure() {
+			lines = append(lines, n.ClosureLine)
+		}
+		l.code(lines, "html", x, width)
+	case *ast.Blockquote:
+		top := l.y
+		l.blocks(n, x+quoteIndent, width-quoteIndent, mutedColor)
+		bottom := l.y
+		l.draw(func(c canvas) { c.fillRect(x, top, x+4, bottom, ruleColor) })
+	case *ast.List:
+		l.list(n, x, width, col)
+	case *extast.Table:
+		l.table(n, x, width)
+	default:
+		l.blocks(n, x, width, col)
+	}
+}
+
+// list lays out a bulleted or numbered list
+func (l *layout) list(n *ast.List, x, width int, col color.RGBA) {
+	gap := blockGap
+	if n.IsTight {
+		gap = blockGap / 3
+	}
+	number := n.Start
+	for item := n.FirstChild(); item != nil; item = item.NextSibling() {
+		if item != n.FirstChild() {
+			l.y += gap
+		}
+		marker := "•"
+		if n.IsOrdered() {
+			marker = strconv.Itoa(number) + "."
+			number++
+		}
+		// The marker is right-aligned in the indent, on the first line of the item
+		line := l.wrap([]span{{text: marker}}, listIndent)[0]
+		markerX := x + listIndent - 8 - line.width
+		baseline := l.y + line.baseline
+		face := l.fonts.regular
+		l.draw(func(c canvas) { c.text(face, markerX, baseline, marker, col) })
+		l.blocks(item, x+listIndent, width-listIndent, col)
+	}
+}
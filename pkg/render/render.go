@@ -0,0 +1,72 @@
+// Package render rasterises markdown to PNG images, for rich content that
+// WeCom markdown messages cannot display well, such as long tables and code
+// blocks. Rendering is pure Go and needs no system fonts or network access:
+// markdown is parsed with goldmark, code is highlighted with chroma and text is
+// drawn with the embedded Go fonts, which cover Latin, Greek and Cyrillic.
+// Other characters, including CJK, are drawn as a placeholder box.
+package render
+
+import (
+	"bytes"
+	"fmt"
+	"image/png"
+	"strings"
+
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/extension"
+	"github.com/yuin/goldmark/text"
+)
+
+// Size limits of a rendered image
+const (
+	DefaultWidth = 800
+	MinWidth     = 300
+	MaxWidth     = 2000
+	MaxHeight    = 8000
+
+	// MaxSourceBytes is the maximum size of the markdown source
+	MaxSourceBytes = 32 * 1024
+)
+
+// parser parses CommonMark with the GitHub extensions: tables, strikethrough,
+// task lists and autolinks
+var parser = goldmark.New(goldmark.WithExtensions(extension.GFM)).Parser()
+
+// Markdown renders markdown source as a PNG image of the given width in
+// pixels. Zero uses the default width; the height fits the content.
+func Markdown(source string, width int) ([]byte, error) {
+	if strings.TrimSpace(source) == "" {
+		return nil, fmt.Errorf("markdown must not be empty")
+	}
+	if len(source) > MaxSourceBytes {
+		return nil, fmt.Errorf("markdown exceeds maximum size of %d bytes", MaxSourceBytes)
+	}
+	if width == 0 {
+		width = DefaultWidth
+	}
+	if width < MinWidth || width > MaxWidth {
+		return nil, fmt.Errorf("width must be between %d and %d pixels, got %d", MinWidth, MaxWidth, width)
+	}
+
+	src := []byte(source)
+	doc := parser.Parse(text.NewReader(src))
+
+	l := newLayout(src, newFonts())
+	l.y = pagePadding
+	l.blocks(doc, pagePadding, width-2*pagePadding, foreground)
+	height := l.y + pagePadding
+	if height > MaxHeight {
+		return nil, fmt.Errorf("rendered content is %d pixels tall, more than the maximum of %d; split it into smaller parts or use a larger width", height, MaxHeight)
+	}
+
+	c := newCanvas(width, height)
+	for _, op := range l.ops {
+		op(c)
+	}
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, c.RGBA); err != nil {
+		return nil, fmt.Errorf("failed to encode image: %w", err)
+	}
+	return buf.Bytes(), nil
+}
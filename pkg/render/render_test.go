@@ -0,0 +1,139 @@
+package render
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/png"
+	"strings"
+	"testing"
+)
+
+func decode(t *testing.T, data []byte) image.Image {
+	t.Helper()
+	img, err := png.Decode(bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("expected a PNG image, got %v", err)
+	}
+	return img
+}
+
+// hasColor reports whether any pixel of img has the color want
+func hasColor(img image.Image, want color.Color) bool {
+	wr, wg, wb, _ := want.RGBA()
+	b := img.Bounds()
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		for x := b.Min.X; x < b.Max.X; x++ {
+			r, g, bl, _ := img.At(x, y).RGBA()
+			if r == wr && g == wg && bl == wb {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func TestMarkdown(t *testing.T) {
+	tests := []struct {
+		name      string
+		source    string
+		width     int
+		wantWidth int
+		wantColor color.Color
+	}{
+		{name: "paragraph", source: "Hello, **world**", wantWidth: DefaultWidth, wantColor: foreground},
+		{name: "table", source: "| a | b |\n|---|---|\n| 1 | 2 |", width: 400, wantWidth: 400, wantColor: headerFill},
+		{name: "code", source: "```go\nfunc main() {}\n```", wantWidth: DefaultWidth, wantColor: codeFill},
+		{name: "link", source: "See [docs](https://example.com)", wantWidth: DefaultWidth, wantColor: linkColor},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, err := Markdown(tt.source, tt.width)
+			if err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+			img := decode(t, data)
+			if img.Bounds().Dx() != tt.wantWidth {
+				t.Fatalf("expected width %d, got %v", tt.wantWidth, img.Bounds())
+			}
+			if !hasColor(img, tt.wantColor) {
+				t.Fatalf("expected color %v to be drawn", tt.wantColor)
+			}
+		})
+	}
+}
+
+func TestMarkdown_HeightFitsContent(t *testing.T) {
+	short, err := Markdown("one line", 0)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	long, err := Markdown(strings.Repeat("a line\n\n", 20), 0)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if decode(t, short).Bounds().Dy() >= decode(t, long).Bounds().Dy() {
+		t.Fatal("expected longer content to produce a taller image")
+	}
+}
+
+func TestMarkdown_Errors(t *testing.T) {
+	tests := []struct {
+		name    string
+		source  string
+		width   int
+		wantErr string
+	}{
+		{name: "empty", source: " \n", wantErr: "must not be empty"},
+		{name: "too large", source: strings.Repeat("x", MaxSourceBytes+1), wantErr: "maximum size"},
+		{name: "too narrow", source: "x", width: MinWidth - 1, wantErr: "width must be between"},
+		{name: "too tall", source: strings.Repeat("line\n\n", 1000), wantErr: "pixels tall"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := Markdown(tt.source, tt.width); err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("expected error containing %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestWrap(t *testing.T) {
+	l := newLayout(nil, newFonts())
+	spans := []span{{text: "the quick brown fox jumps over the lazy dog"}}
+	width := measure(l.fonts.regular, "the quick brown")
+	lines := l.wrap(spans, width)
+	if len(lines) < 3 {
+		t.Fatalf("expected the text to wrap, got %d lines", len(lines))
+	}
+	for i, line := range lines {
+		if line.width > width {
+			t.Fatalf("line %d is %d pixels wide, more than %d", i, line.width, width)
+		}
+	}
+
+	// A word wider than the line is split
+	lines = l.wrap([]span{{text: strings.Repeat("x", 50)}}, 100)
+	if len(lines) < 2 || lines[0].width > 100 {
+		t.Fatalf("expected a long word to be split, got %+v", lines)
+	}
+}
+
+func TestFitColumns(t *testing.T) {
+	tests := []struct {
+		natural []int
+		width   int
+		want    []int
+	}{
+		{natural: []int{50, 60}, width: 200, want: []int{50, 60}},
+		{natural: []int{50, 400, 300}, width: 450, want: []int{50, 200, 200}},
+	}
+	for _, tt := range tests {
+		got := fitColumns(tt.natural, tt.width)
+		for i := range got {
+			if got[i] != tt.want[i] {
+				t.Fatalf("fitColumns(%v, %d) = %v; want %v", tt.natural, tt.width, got, tt.want)
+			}
+		}
+	}
+}
@@ -0,0 +1,145 @@
+package render
+
+import (
+	"github.com/yuin/goldmark/ast"
+	extast "github.com/yuin/goldmark/extension/ast"
+)
+
+// cellPadding is the space between a table cell's border and its text
+const cellPadding = 8
+
+// table lays out a table. Columns keep their natural width when the table
+// fits; otherwise the widest columns are narrowed and their text wrapped.
+func (l *layout) table(n *extast.Table, x, width int) {
+	var rows [][]ast.Node
+	for row := n.FirstChild(); row != nil; row = row.NextSibling() {
+		var cells []ast.Node
+		for cell := row.FirstChild(); cell != nil; cell = cell.NextSibling() {
+			cells = append(cells, cell)
+		}
+		rows = append(rows, cells)
+	}
+	columns := len(n.Alignments)
+	if columns == 0 || len(rows) == 0 {
+		return
+	}
+
+	cellStyle := func(row int) style {
+		return style{bold: row == 0}
+	}
+	natural := make([]int, columns)
+	for r, cells := range rows {
+		for i, cell := range cells {
+			if i >= columns {
+				break
+			}
+			for _, line := range l.wrap(l.spans(cell, cellStyle(r), nil), 1<<30) {
+				natural[i] = max(natural[i], line.width+2*cellPadding)
+			}
+		}
+	}
+	widths := fitColumns(natural, width)
+
+	top := l.y
+	for r, cells := range rows {
+		rowTop := l.y
+		rowBottom := rowTop
+		for i := 0; i < columns; i++ {
+			var lines []textLine
+			if i < len(cells) {
+				lines = l.wrap(l.spans(cells[i], cellStyle(r), nil), widths[i]-2*cellPadding)
+			}
+			height := 0
+			for _, line := range lines {
+				height += line.height
+			}
+			rowBottom = max(rowBottom, rowTop+height+2*cellPadding)
+		}
+
+		left, right := x, x
+		for _, w := range widths {
+			right += w
+		}
+		fill := background
+		if r == 0 {
+			fill = headerFill
+		} else if r%2 == 0 {
+			fill = codeFill
+		}
+		l.draw(func(c canvas) { c.fillRect(left, rowTop, right, rowBottom, fill) })
+
+		cx := x
+		for i := 0; i < columns && i < len(cells); i++ {
+			l.y = rowTop + cellPadding
+			lines := l.wrap(l.spans(cells[i], cellStyle(r), nil), widths[i]-2*cellPadding)
+			l.drawLines(lines, cx+cellPadding, widths[i]-2*cellPadding, alignment(n.Alignments[i]), foreground)
+			cx += widths[i]
+		}
+		l.y = rowBottom
+		l.draw(func(c canvas) { c.fillRect(left, rowTop, right, rowTop+1, ruleColor) })
+	}
+
+	// Outer and column borders
+	bottom := l.y
+	l.draw(func(c canvas) {
+		cx := x
+		for _, w := range widths {
+			c.fillRect(cx, top, cx+1, bottom+1, ruleColor)
+			cx += w
+		}
+		c.fillRect(cx, top, cx+1, bottom+1, ruleColor)
+		c.fillRect(x, bottom, cx, bottom+1, ruleColor)
+	})
+	l.y++
+}
+
+// fitColumns returns column widths that fit in width. Columns narrower than
+// an equal share of the remaining space keep their natural width, and the
+// rest share what is left.
+func fitColumns(natural []int, width int) []int {
+	widths := make([]int, len(natural))
+	copy(widths, natural)
+	total := 0
+	for _, w := range natural {
+		total += w
+	}
+	if total <= width {
+		return widths
+	}
+
+	fixed := make([]bool, len(natural))
+	remaining, open := width, len(natural)
+	for open > 0 {
+		share := remaining / open
+		changed := false
+		for i, w := range natural {
+			if !fixed[i] && w <= share {
+				fixed[i] = true
+				remaining -= w
+				open--
+				changed = true
+			}
+		}
+		if !changed {
+			for i := range natural {
+				if !fixed[i] {
+					widths[i] = max(2*cellPadding+1, share)
+				}
+			}
+			break
+		}
+	}
+	return widths
+}
+
+// alignment converts a table column alignment to the alignment of drawLines
+func alignment(a extast.Alignment) int {
+	switch a {
+	case extast.AlignCenter:
+		return 0
+	case extast.AlignRight:
+		return 1
+	default:
+		return -1
+	}
+}
@@ -10,8 +10,11 @@ import (
 	"syscall"
 	"time"
 
+	"github.com/futuretea/wecom-bot-mcp-server/pkg/api"
+	"github.com/futuretea/wecom-bot-mcp-server/pkg/callback"
 	"github.com/futuretea/wecom-bot-mcp-server/pkg/core/config"
 	"github.com/futuretea/wecom-bot-mcp-server/pkg/core/logging"
+	"github.com/futuretea/wecom-bot-mcp-server/pkg/hooks"
 	"github.com/futuretea/wecom-bot-mcp-server/pkg/server/mcp"
 )
 
@@ -45,8 +48,8 @@ func Serve(ctx context.Context, mcpServer *mcp.Server, cfg *config.StaticConfig)
 	sseServer := mcpServer.ServeSse(cfg.SSEBaseURL, httpServer)
 	streamableHttpServer := mcpServer.ServeHTTP(httpServer)
 	mux.Handle(sseEndpoint, sseServer)
-	mux.Handle(sseMessageEndpoint, sseServer)
-	mux.Handle(mcpEndpoint, streamableHttpServer)
+	mux.Handle(sseMessageEndpoint, mcpServer.HandleSubscriptions(sseServer))
+	mux.Handle(mcpEndpoint, mcpServer.HandleSubscriptions(streamableHttpServer))
 	mux.HandleFunc(healthEndpoint, func(w http.ResponseWriter, r *http.Request) {
 		if mcpServer.IsHealthy() {
 			w.WriteHeader(http.StatusOK)
@@ -57,6 +60,16 @@ func Serve(ctx context.Context, mcpServer *mcp.Server, cfg *config.StaticConfig)
 		}
 	})
 
+	if err := hooks.Register(mux, cfg.Hooks, mcpServer); err != nil {
+		return err
+	}
+	if err := api.Register(mux, cfg.API, mcpServer); err != nil {
+		return err
+	}
+	if err := callback.Register(mux, cfg.Callback, mcpServer.Inbox()); err != nil {
+		return err
+	}
+
 	ctx, cancel := context.WithCancel(ctx)
 	defer cancel()
 
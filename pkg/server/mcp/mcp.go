@@ -2,9 +2,9 @@ package mcp
 
 import (
 	"context"
+	"fmt"
 	"net/http"
 
-	wecombot "github.com/futuretea/go-wecom-bot"
 	"github.com/mark3labs/mcp-go/mcp"
 	"github.com/mark3labs/mcp-go/server"
 
@@ -13,54 +13,192 @@ import (
 	"github.com/futuretea/wecom-bot-mcp-server/pkg/core/version"
 	"github.com/futuretea/wecom-bot-mcp-server/pkg/toolset"
 	wecomToolset "github.com/futuretea/wecom-bot-mcp-server/pkg/toolset/wecom"
+	"github.com/futuretea/wecom-bot-mcp-server/pkg/wecomapi"
 )
 
+// Compile-time interface check
+var _ wecomToolset.Dispatcher = (*Server)(nil)
+
 // Server represents the MCP server
 type Server struct {
 	config       *config.StaticConfig
 	server       *server.MCPServer
 	enabledTools []string
-	bot          *wecombot.Bot
+	tools        map[string]toolset.ServerTool
+	bot          *wecomapi.Bot
+	client       *wecomToolset.Client
+	scheduler    *wecomToolset.Scheduler
+	// subscriptions are the resources each session subscribed to
+	subscriptions *subscriptions
 }
 
 // NewServer creates a new MCP server with the given configuration
 func NewServer(cfg *config.StaticConfig) (*Server, error) {
+	subscriptions := newSubscriptions()
 	serverOptions := []server.ServerOption{
 		server.WithToolCapabilities(true),
+		server.WithResourceCapabilities(true, false),
 		server.WithLogging(),
+		server.WithHooks(subscriptions.hooks()),
+	}
+	if cfg.RequireConfirmation {
+		serverOptions = append(serverOptions, server.WithElicitation())
 	}
 
 	// Initialize WeCom bot client
-	bot := wecombot.New(cfg.WeComBotKey)
-	logging.Info("WeCom bot client initialized")
+	api, err := wecomapi.New(cfg.WeComAPI)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create WeCom API client: %w", err)
+	}
+	bot := api.Bot(cfg.WeComBotKey)
+	logging.Info("WeCom bot client initialized for %s", api.BaseURL())
+
+	contentFilter, err := wecomToolset.NewContentFilter(cfg.ContentPolicy, cfg.WeComAPI.BaseURLOrDefault(), cfg.WeComBotKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build content policy: %w", err)
+	}
+
+	client := wecomToolset.NewClient(bot)
+	client.RequireConfirmation = cfg.RequireConfirmation
+	client.ContentFilter = contentFilter
+	client.URLPolicy = wecomToolset.NewURLPolicy(cfg.URLPolicy)
+
+	if cfg.DirectoryFile != "" {
+		directory, err := wecomToolset.LoadDirectory(cfg.DirectoryFile)
+		if err != nil {
+			return nil, err
+		}
+		client.Directory = directory
+		logging.Info("User directory loaded from %s", cfg.DirectoryFile)
+	}
+	if cfg.QuietHours.Enabled() {
+		quietHours, err := wecomToolset.NewQuietHours(cfg.QuietHours)
+		if err != nil {
+			return nil, err
+		}
+		client.QuietHours = quietHours
+		logging.Info("Quiet hours enabled with %d window(s)", len(cfg.QuietHours.Windows))
+	}
+	if cfg.Digest.Window > 0 {
+		client.Digest = wecomToolset.NewDigest(cfg.Digest.Window, client)
+		logging.Info("Digest mode enabled with a %s window", cfg.Digest.Window)
+	}
+	if cfg.RequireConfirmation {
+		logging.Info("Human confirmation is required before sending messages")
+	}
+
+	history, err := wecomToolset.NewHistory(wecomToolset.DefaultBotName, cfg.History.Size, cfg.History.File)
+	if err != nil {
+		return nil, err
+	}
+	client.History = history
+
+	media, err := wecomToolset.NewMediaRegistry(cfg.MediaFile)
+	if err != nil {
+		return nil, err
+	}
+	client.Media = media
+
+	if cfg.Callback.Enabled {
+		client.Inbox = wecomToolset.NewInbox(wecomToolset.DefaultBotName, cfg.Callback.Size)
+	}
 
 	s := &Server{
 		config: cfg,
 		server: server.NewMCPServer(version.BinaryName, version.Version, serverOptions...),
+		tools:  make(map[string]toolset.ServerTool),
 		bot:    bot,
+		client: client,
+
+		subscriptions: subscriptions,
+	}
+
+	// Start the message scheduler. Jobs only run while the server process is alive.
+	scheduler, err := wecomToolset.NewScheduler(cfg.ScheduleFile, s)
+	if err != nil {
+		return nil, err
 	}
+	s.scheduler = scheduler
+	client.Scheduler = scheduler
 
-	// Register tools
+	// Register tools and resources
 	s.registerTools()
+	s.registerResources()
+
+	if client.Inbox != nil {
+		uri := wecomToolset.BotIncomingURI(client.Inbox.Bot())
+		client.Inbox.OnMessage(func(wecomToolset.IncomingMessage) {
+			s.notifyUpdated(uri)
+		})
+	}
+
+	scheduler.Start()
 
 	return s, nil
 }
 
 // registerTools registers all available tools based on configuration
 func (s *Server) registerTools() {
-	wecomToolset := &wecomToolset.Toolset{}
-	tools := wecomToolset.GetTools(s.bot)
-
-	for _, tool := range tools {
-		if !s.isToolEnabled(tool.Tool.Name) {
-			continue
-		}
+	for _, tool := range s.availableTools() {
 		s.registerTool(tool)
 	}
 
 	logging.Info("MCP server initialized with %d tools", len(s.enabledTools))
 }
 
+// registerResources registers the toolset's resources and resource templates
+func (s *Server) registerResources() {
+	var provider toolset.ResourceProvider = &wecomToolset.Toolset{}
+
+	for _, resource := range provider.GetResources(s.client) {
+		s.server.AddResource(resource.Resource, s.createResourceHandler(resource.Resource.MIMEType, resource.Handler))
+		logging.Info("Registered resource: %s", resource.Resource.URI)
+	}
+	for _, template := range provider.GetResourceTemplates(s.client) {
+		handler := s.createResourceHandler(template.Template.MIMEType, template.Handler)
+		s.server.AddResourceTemplate(template.Template, server.ResourceTemplateHandlerFunc(handler))
+		logging.Info("Registered resource template: %s", template.Template.URITemplate.Raw())
+	}
+}
+
+// createResourceHandler creates the handler function for a resource or resource template
+func (s *Server) createResourceHandler(mimeType string, handler toolset.ResourceHandler) server.ResourceHandlerFunc {
+	return func(ctx context.Context, request mcp.ReadResourceRequest) ([]mcp.ResourceContents, error) {
+		logging.Debug("Resource %s read", request.Params.URI)
+
+		text, err := handler(s.client, request.Params.URI)
+		if err != nil {
+			return nil, err
+		}
+		return []mcp.ResourceContents{
+			mcp.TextResourceContents{
+				URI:      request.Params.URI,
+				MIMEType: mimeType,
+				Text:     text,
+			},
+		}, nil
+	}
+}
+
+// AvailableTools returns the tools enabled by the configuration, in
+// registration order, without creating a server
+func AvailableTools(cfg *config.StaticConfig) []toolset.ServerTool {
+	return (&Server{config: cfg}).availableTools()
+}
+
+// availableTools returns the toolset's tools that are enabled by configuration
+func (s *Server) availableTools() []toolset.ServerTool {
+	wecomToolset := &wecomToolset.Toolset{}
+
+	var tools []toolset.ServerTool
+	for _, tool := range wecomToolset.GetTools(s.client) {
+		if s.isToolEnabled(tool.Tool.Name) {
+			tools = append(tools, tool)
+		}
+	}
+	return tools
+}
+
 // isToolEnabled determines if a tool should be enabled based on configuration
 func (s *Server) isToolEnabled(toolName string) bool {
 	// Explicitly disabled tools take highest priority
@@ -88,6 +226,7 @@ func (s *Server) isToolEnabled(toolName string) bool {
 func (s *Server) registerTool(tool toolset.ServerTool) {
 	handler := s.createToolHandler(tool)
 	s.server.AddTool(tool.Tool, handler)
+	s.tools[tool.Tool.Name] = tool
 	s.enabledTools = append(s.enabledTools, tool.Tool.Name)
 
 	logging.Info("Registered tool: %s", tool.Tool.Name)
@@ -99,11 +238,96 @@ func (s *Server) createToolHandler(tool toolset.ServerTool) server.ToolHandlerFu
 		logging.Debug("Tool %s called with params: %v", tool.Tool.Name, request.Params.Arguments)
 
 		params := extractParams(request.Params.Arguments)
-		result, err := tool.Handler(s.bot, params)
+		result, err := tool.Handler(s.clientFor(ctx), params)
 		return NewTextResult(result, err), nil
 	}
 }
 
+// clientFor returns the WeCom client to use for a tool call. When confirmation
+// is required and the calling client supports elicitation, approval is
+// requested interactively; otherwise the two-phase confirm_send flow is used.
+func (s *Server) clientFor(ctx context.Context) *wecomToolset.Client {
+	if !s.client.RequireConfirmation || !supportsElicitation(ctx) {
+		return s.client
+	}
+
+	return s.client.WithConfirm(func(kind, preview string) (bool, error) {
+		result, err := s.server.RequestElicitation(ctx, mcp.ElicitationRequest{
+			Params: mcp.ElicitationParams{
+				Message: fmt.Sprintf("Send this %s to the WeCom group?\n\n%s", kind, preview),
+				RequestedSchema: map[string]any{
+					"type": "object",
+					"properties": map[string]any{
+						"approved": map[string]any{
+							"type":        "boolean",
+							"description": "Approve sending this message",
+						},
+					},
+					"required": []string{"approved"},
+				},
+			},
+		})
+		if err != nil {
+			return false, err
+		}
+		if result.Action != mcp.ElicitationResponseActionAccept {
+			return false, nil
+		}
+		content, _ := result.Content.(map[string]any)
+		approved, _ := content["approved"].(bool)
+		return approved, nil
+	})
+}
+
+// supportsElicitation reports whether the client behind ctx declared the
+// elicitation capability during initialization.
+func supportsElicitation(ctx context.Context) bool {
+	session, ok := server.ClientSessionFromContext(ctx).(server.SessionWithClientInfo)
+	if !ok {
+		return false
+	}
+	if _, ok := session.(server.SessionWithElicitation); !ok {
+		return false
+	}
+	return session.GetClientCapabilities().Elicitation != nil
+}
+
+// HasTool reports whether the named tool is registered and enabled
+func (s *Server) HasTool(name string) bool {
+	_, ok := s.tools[name]
+	return ok
+}
+
+// ToolDefinition returns the definition of an enabled tool
+func (s *Server) ToolDefinition(name string) (mcp.Tool, bool) {
+	tool, ok := s.tools[name]
+	return tool.Tool, ok
+}
+
+// CallTool runs an enabled tool outside of an MCP request, e.g. for scheduled
+// messages. Interactive confirmation is not available in this context.
+func (s *Server) CallTool(name string, params map[string]any) (string, error) {
+	tool, ok := s.tools[name]
+	if !ok {
+		return "", fmt.Errorf("tool %q is not available", name)
+	}
+	return tool.Handler(s.client, params)
+}
+
+// InvokeTool runs an enabled tool through the same handler used for MCP
+// requests and returns the CallToolResult a client would receive
+func (s *Server) InvokeTool(ctx context.Context, name string, args map[string]any) (*mcp.CallToolResult, error) {
+	tool, ok := s.tools[name]
+	if !ok {
+		return nil, fmt.Errorf("tool %q is not available; enabled tools: %v", name, s.enabledTools)
+	}
+
+	request := mcp.CallToolRequest{}
+	request.Params.Name = name
+	request.Params.Arguments = args
+	return s.createToolHandler(tool)(ctx, request)
+}
+
 // extractParams extracts the parameters map from the request arguments
 func extractParams(args any) map[string]any {
 	params, ok := args.(map[string]any)
@@ -151,6 +375,12 @@ func (s *Server) GetEnabledTools() []string {
 	return s.enabledTools
 }
 
+// Inbox returns the store of messages received by the bot, or nil when the
+// callback is disabled
+func (s *Server) Inbox() *wecomToolset.Inbox {
+	return s.client.Inbox
+}
+
 // IsHealthy returns true if the server is properly initialized
 func (s *Server) IsHealthy() bool {
 	return s.bot != nil
@@ -159,6 +389,12 @@ func (s *Server) IsHealthy() bool {
 // Close cleans up the server resources
 func (s *Server) Close() {
 	logging.Info("Closing MCP server")
+	if s.scheduler != nil {
+		s.scheduler.Stop()
+	}
+	if s.client != nil && s.client.Digest != nil {
+		s.client.Digest.Stop()
+	}
 }
 
 // NewTextResult creates a standardized text result for tool responses
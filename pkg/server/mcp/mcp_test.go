@@ -1,12 +1,18 @@
 package mcp
 
 import (
+	"context"
+	"encoding/json"
 	"errors"
+	"fmt"
+	"net/http/httptest"
+	"strings"
 	"testing"
 
 	mcpgo "github.com/mark3labs/mcp-go/mcp"
 
 	"github.com/futuretea/wecom-bot-mcp-server/pkg/core/config"
+	"github.com/futuretea/wecom-bot-mcp-server/pkg/fakewecom"
 )
 
 // --- isToolEnabled tests ---
@@ -116,3 +122,135 @@ func TestNewTextResult_Error(t *testing.T) {
 		t.Fatalf("expected 'something failed', got %q", tc.Text)
 	}
 }
+
+// --- InvokeTool tests ---
+
+func TestInvokeTool(t *testing.T) {
+	s, err := NewServer(&config.StaticConfig{
+		WeComBotKey:   "test-key",
+		DisabledTools: []string{"send_image"},
+	})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	defer s.Close()
+
+	result, err := s.InvokeTool(context.Background(), "send_text", map[string]any{})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if !result.IsError {
+		t.Fatal("expected an error result for missing content")
+	}
+	text, ok := result.Content[0].(mcpgo.TextContent)
+	if !ok || text.Text != "content is required" {
+		t.Fatalf("unexpected result content: %v", result.Content)
+	}
+
+	if _, err := s.InvokeTool(context.Background(), "send_image", nil); err == nil {
+		t.Fatal("expected disabled tool to be unavailable")
+	}
+}
+
+func TestAvailableTools(t *testing.T) {
+	tools := AvailableTools(&config.StaticConfig{EnabledTools: []string{"send_markdown", "send_text"}})
+	if len(tools) != 2 || tools[0].Tool.Name != "send_text" || tools[1].Tool.Name != "send_markdown" {
+		t.Fatalf("expected enabled tools in registration order, got %v", tools)
+	}
+}
+
+// --- Resource tests ---
+
+// readResource reads a resource through the MCP protocol handler
+func readResource(t *testing.T, s *Server, uri string) (string, error) {
+	t.Helper()
+	request := fmt.Sprintf(`{"jsonrpc":"2.0","id":1,"method":"resources/read","params":{"uri":%q}}`, uri)
+	response := s.server.HandleMessage(context.Background(), json.RawMessage(request))
+
+	data, err := json.Marshal(response)
+	if err != nil {
+		t.Fatalf("failed to encode response: %v", err)
+	}
+	var decoded struct {
+		Result struct {
+			Contents []struct {
+				URI      string `json:"uri"`
+				MIMEType string `json:"mimeType"`
+				Text     string `json:"text"`
+			} `json:"contents"`
+		} `json:"result"`
+		Error *struct {
+			Message string `json:"message"`
+		} `json:"error"`
+	}
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatalf("failed to decode response %s: %v", data, err)
+	}
+	if decoded.Error != nil {
+		return "", errors.New(decoded.Error.Message)
+	}
+	if len(decoded.Result.Contents) != 1 || decoded.Result.Contents[0].MIMEType != "application/json" {
+		t.Fatalf("unexpected resource contents: %s", data)
+	}
+	return decoded.Result.Contents[0].Text, nil
+}
+
+func TestMessageHistoryResources(t *testing.T) {
+	fake := httptest.NewServer(fakewecom.New(fakewecom.Options{}))
+	t.Cleanup(fake.Close)
+
+	s, err := NewServer(&config.StaticConfig{
+		WeComBotKey: "693a91f6-7abc-4bc4-97a0-0ec2cafe5aaa",
+		WeComAPI:    config.WeComAPI{BaseURL: fake.URL},
+	})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	defer s.Close()
+
+	for _, content := range []string{"deploy started", "deploy finished"} {
+		result, err := s.InvokeTool(context.Background(), "send_text", map[string]any{"content": content})
+		if err != nil || result.IsError {
+			t.Fatalf("failed to send %q: %v %v", content, err, result)
+		}
+	}
+
+	text, err := readResource(t, s, "wecom://bots/default/messages")
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	var list struct {
+		Bot      string `json:"bot"`
+		Messages []struct {
+			ID      string `json:"id"`
+			URI     string `json:"uri"`
+			Summary string `json:"summary"`
+		} `json:"messages"`
+	}
+	if err := json.Unmarshal([]byte(text), &list); err != nil {
+		t.Fatalf("failed to decode message list: %v", err)
+	}
+	if list.Bot != "default" || len(list.Messages) != 2 || list.Messages[0].Summary != "deploy finished" {
+		t.Fatalf("expected newest message first, got %s", text)
+	}
+
+	text, err = readResource(t, s, list.Messages[1].URI)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if !strings.Contains(text, `"content": "deploy started"`) {
+		t.Fatalf("expected message payload, got %s", text)
+	}
+
+	if _, err := readResource(t, s, "wecom://bots/other/messages"); err == nil || !strings.Contains(err.Error(), "unknown bot") {
+		t.Fatalf("expected unknown bot error, got %v", err)
+	}
+	if _, err := readResource(t, s, "wecom://messages/missing"); err == nil {
+		t.Fatal("expected error for unknown message id")
+	}
+
+	text, err = readResource(t, s, "wecom://media")
+	if err != nil || !strings.Contains(text, `"media": []`) {
+		t.Fatalf("expected empty media list, got %q %v", text, err)
+	}
+}
@@ -0,0 +1,184 @@
+package mcp
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"sync"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+
+	"github.com/futuretea/wecom-bot-mcp-server/pkg/core/logging"
+)
+
+// Resource subscription methods, which mcp-go v0.41.1 does not handle
+const (
+	methodSubscribe   = "resources/subscribe"
+	methodUnsubscribe = "resources/unsubscribe"
+)
+
+// maxSubscriptionRequestBytes is the size of the request prefix HandleSubscriptions
+// reads to detect a subscription request. Larger requests are passed on untouched.
+const maxSubscriptionRequestBytes = 64 << 10
+
+// subscriptions tracks the resources each session subscribed to.
+type subscriptions struct {
+	mu       sync.Mutex
+	sessions map[string]map[string]bool
+}
+
+// newSubscriptions creates an empty subscription registry
+func newSubscriptions() *subscriptions {
+	return &subscriptions{sessions: make(map[string]map[string]bool)}
+}
+
+// subscribe adds a subscription of a session to a resource
+func (s *subscriptions) subscribe(sessionID, uri string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.sessions[sessionID] == nil {
+		s.sessions[sessionID] = make(map[string]bool)
+	}
+	s.sessions[sessionID][uri] = true
+}
+
+// unsubscribe removes a subscription of a session to a resource
+func (s *subscriptions) unsubscribe(sessionID, uri string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.sessions[sessionID], uri)
+	if len(s.sessions[sessionID]) == 0 {
+		delete(s.sessions, sessionID)
+	}
+}
+
+// remove drops every subscription of a session that ended
+func (s *subscriptions) remove(sessionID string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.sessions, sessionID)
+}
+
+// subscribers returns the sessions subscribed to a resource, sorted
+func (s *subscriptions) subscribers(uri string) []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var ids []string
+	for id, uris := range s.sessions {
+		if uris[uri] {
+			ids = append(ids, id)
+		}
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+// subscriptionRequest is a subscribe or unsubscribe request passed from
+// HandleSubscriptions to the ping hook in the request context
+type subscriptionRequest struct {
+	method string
+	uri    string
+}
+
+type subscriptionRequestKey struct{}
+
+// hooks returns the hooks that record the subscription requests of
+// sessions and forget them when a session ends.
+func (s *subscriptions) hooks() *server.Hooks {
+	hooks := &server.Hooks{}
+	hooks.AddBeforePing(func(ctx context.Context, _ any, _ *mcp.PingRequest) {
+		req, ok := ctx.Value(subscriptionRequestKey{}).(subscriptionRequest)
+		session := server.ClientSessionFromContext(ctx)
+		if !ok || session == nil {
+			return
+		}
+		if req.method == methodSubscribe {
+			s.subscribe(session.SessionID(), req.uri)
+			logging.Debug("Session %s subscribed to %s", session.SessionID(), req.uri)
+		} else {
+			s.unsubscribe(session.SessionID(), req.uri)
+			logging.Debug("Session %s unsubscribed from %s", session.SessionID(), req.uri)
+		}
+	})
+	hooks.AddOnUnregisterSession(func(_ context.Context, session server.ClientSession) {
+		s.remove(session.SessionID())
+	})
+	return hooks
+}
+
+// HandleSubscriptions wraps an MCP endpoint to accept resources/subscribe and
+// resources/unsubscribe requests, which mcp-go v0.41.1 answers with "method
+// not found" and offers no way to handle. On the SSE message endpoint, the
+// request is passed on to the session as a ping with the same ID, so the
+// client gets the empty result it expects, and the ping hook records the
+// subscription for the session. Stateless streamable HTTP requests have no
+// session to notify and get an error. TestMCPGoDoesNotHandleSubscribe fails
+// once mcp-go handles these methods itself and this workaround can go.
+//
+// Only a prefix of the body is read; other requests are passed on unchanged.
+func (s *Server) HandleSubscriptions(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.Body == nil {
+			next.ServeHTTP(w, r)
+			return
+		}
+		prefix, err := io.ReadAll(io.LimitReader(r.Body, maxSubscriptionRequestBytes+1))
+		if err != nil {
+			http.Error(w, fmt.Sprintf("failed to read request body: %v", err), http.StatusBadRequest)
+			return
+		}
+		if len(prefix) > maxSubscriptionRequestBytes {
+			// Too large for a subscription request
+			r.Body = readCloser{io.MultiReader(bytes.NewReader(prefix), r.Body), r.Body}
+			next.ServeHTTP(w, r)
+			return
+		}
+		body := prefix
+
+		var msg struct {
+			ID     mcp.RequestId `json:"id"`
+			Method string        `json:"method"`
+			Params struct {
+				URI string `json:"uri"`
+			} `json:"params"`
+		}
+		if json.Unmarshal(body, &msg) == nil && !msg.ID.IsNil() && msg.Params.URI != "" &&
+			(msg.Method == methodSubscribe || msg.Method == methodUnsubscribe) {
+			if r.URL.Query().Get("sessionId") == "" {
+				w.Header().Set("Content-Type", "application/json")
+				_ = json.NewEncoder(w).Encode(mcp.NewJSONRPCError(msg.ID, mcp.INVALID_REQUEST,
+					"resource subscriptions need an SSE session; streamable HTTP clients should poll instead", nil))
+				return
+			}
+			body, _ = json.Marshal(map[string]any{"jsonrpc": mcp.JSONRPC_VERSION, "id": msg.ID, "method": mcp.MethodPing})
+			ctx := context.WithValue(r.Context(), subscriptionRequestKey{}, subscriptionRequest{method: msg.Method, uri: msg.Params.URI})
+			r = r.WithContext(ctx)
+		}
+
+		r.Body = io.NopCloser(bytes.NewReader(body))
+		r.ContentLength = int64(len(body))
+		next.ServeHTTP(w, r)
+	})
+}
+
+// notifyUpdated sends a resources/updated notification for uri to the
+// sessions subscribed to it.
+func (s *Server) notifyUpdated(uri string) {
+	for _, sessionID := range s.subscriptions.subscribers(uri) {
+		err := s.server.SendNotificationToSpecificClient(sessionID, mcp.MethodNotificationResourceUpdated, map[string]any{"uri": uri})
+		if err != nil {
+			logging.Debug("Failed to notify session %s of an update to %s: %v", sessionID, uri, err)
+		}
+	}
+}
+
+// readCloser reads from a reader and closes the original request body
+type readCloser struct {
+	io.Reader
+	io.Closer
+}
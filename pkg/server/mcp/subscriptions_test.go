@@ -0,0 +1,207 @@
+package mcp
+
+import (
+	"bytes"
+	"context"
+	"crypto/md5"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mark3labs/mcp-go/client"
+	mcpgo "github.com/mark3labs/mcp-go/mcp"
+
+	"github.com/futuretea/wecom-bot-mcp-server/pkg/core/config"
+	"github.com/futuretea/wecom-bot-mcp-server/pkg/fakewecom"
+	wecomToolset "github.com/futuretea/wecom-bot-mcp-server/pkg/toolset/wecom"
+)
+
+// connectSSE starts an SSE client and returns the URIs of the resources/updated
+// notifications it receives.
+func connectSSE(t *testing.T, url string) (*client.Client, <-chan string) {
+	t.Helper()
+	c, err := client.NewSSEMCPClient(url + "/sse")
+	if err != nil {
+		t.Fatalf("failed to create client: %v", err)
+	}
+	t.Cleanup(func() { _ = c.Close() })
+
+	updates := make(chan string, 10)
+	c.OnNotification(func(n mcpgo.JSONRPCNotification) {
+		if n.Method == mcpgo.MethodNotificationResourceUpdated {
+			uri, _ := n.Params.AdditionalFields["uri"].(string)
+			updates <- uri
+		}
+	})
+	ctx := context.Background()
+	if err := c.Start(ctx); err != nil {
+		t.Fatalf("failed to start client: %v", err)
+	}
+	init := mcpgo.InitializeRequest{}
+	init.Params.ProtocolVersion = mcpgo.LATEST_PROTOCOL_VERSION
+	result, err := c.Initialize(ctx, init)
+	if err != nil {
+		t.Fatalf("failed to initialize: %v", err)
+	}
+	if result.Capabilities.Resources == nil || !result.Capabilities.Resources.Subscribe {
+		t.Fatalf("expected the subscribe capability, got %+v", result.Capabilities.Resources)
+	}
+	return c, updates
+}
+
+// expectUpdate waits for a notification, or checks that none arrives when uri is empty
+func expectUpdate(t *testing.T, updates <-chan string, uri string) {
+	t.Helper()
+	timeout := 2 * time.Second
+	if uri == "" {
+		timeout = 200 * time.Millisecond
+	}
+	select {
+	case got := <-updates:
+		if got != uri {
+			t.Fatalf("expected an update of %q, got %q", uri, got)
+		}
+	case <-time.After(timeout):
+		if uri != "" {
+			t.Fatalf("expected an update of %q", uri)
+		}
+	}
+}
+
+func TestIncomingResourceSubscriptions(t *testing.T) {
+	s, err := NewServer(&config.StaticConfig{
+		WeComBotKey: "test-key",
+		Callback:    config.Callback{Enabled: true},
+	})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	defer s.Close()
+
+	mux := http.NewServeMux()
+	httpServer := httptest.NewServer(mux)
+	sse := s.ServeSse(httpServer.URL, nil)
+	// Registered first so that it runs after the clients are closed
+	t.Cleanup(func() {
+		_ = sse.Shutdown(context.Background())
+		httpServer.Close()
+	})
+	mux.Handle("/sse", sse)
+	mux.Handle("/message", s.HandleSubscriptions(sse))
+
+	uri := wecomToolset.BotIncomingURI(wecomToolset.DefaultBotName)
+	subscribed, updates := connectSSE(t, httpServer.URL)
+	_, otherUpdates := connectSSE(t, httpServer.URL)
+
+	subscribe := mcpgo.SubscribeRequest{}
+	subscribe.Params.URI = uri
+	if err := subscribed.Subscribe(context.Background(), subscribe); err != nil {
+		t.Fatalf("expected the subscription to succeed, got %v", err)
+	}
+
+	s.Inbox().Add(wecomToolset.IncomingMessage{MsgID: "m1", From: "alice", MsgType: "text", Content: "hi"})
+	expectUpdate(t, updates, uri)
+	expectUpdate(t, otherUpdates, "")
+
+	unsubscribe := mcpgo.UnsubscribeRequest{}
+	unsubscribe.Params.URI = uri
+	if err := subscribed.Unsubscribe(context.Background(), unsubscribe); err != nil {
+		t.Fatalf("expected unsubscribing to succeed, got %v", err)
+	}
+	s.Inbox().Add(wecomToolset.IncomingMessage{MsgID: "m2", From: "alice", MsgType: "text", Content: "again"})
+	expectUpdate(t, updates, "")
+
+	if got := s.subscriptions.subscribers(uri); len(got) != 0 {
+		t.Fatalf("expected no subscribers, got %v", got)
+	}
+}
+
+func TestHandleSubscriptions_WithoutSession(t *testing.T) {
+	s := &Server{subscriptions: newSubscriptions()}
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Fatal("expected the subscription request not to be passed on")
+	})
+	body := `{"jsonrpc":"2.0","id":7,"method":"resources/subscribe","params":{"uri":"wecom://bots/default/incoming"}}`
+	rec := httptest.NewRecorder()
+	s.HandleSubscriptions(next).ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/mcp", strings.NewReader(body)))
+	if !strings.Contains(rec.Body.String(), `"id":7`) || !strings.Contains(rec.Body.String(), "need an SSE session") {
+		t.Fatalf("expected a JSON-RPC error, got %s", rec.Body)
+	}
+}
+
+func TestHandleSubscriptions_LargeToolCall(t *testing.T) {
+	fake := httptest.NewServer(fakewecom.New(fakewecom.Options{}))
+	t.Cleanup(fake.Close)
+	s, err := NewServer(&config.StaticConfig{
+		WeComBotKey: "693a91f6-7abc-4bc4-97a0-0ec2cafe5aaa",
+		WeComAPI:    config.WeComAPI{BaseURL: fake.URL},
+	})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	defer s.Close()
+
+	// A 1.5 MB image is 2 MB once base64 encoded, well above the peeked prefix
+	image := append([]byte("\x89PNG\r\n\x1a\n"), bytes.Repeat([]byte{0x42}, 1500*1024)...)
+	sum := md5.Sum(image)
+	call, err := json.Marshal(map[string]any{
+		"jsonrpc": "2.0", "id": 1, "method": "tools/call",
+		"params": map[string]any{"name": "send_image", "arguments": map[string]any{
+			"base64": base64.StdEncoding.EncodeToString(image),
+			"md5":    hex.EncodeToString(sum[:]),
+		}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	req := httptest.NewRequest(http.MethodPost, "/mcp", bytes.NewReader(call))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/json, text/event-stream")
+	rec := httptest.NewRecorder()
+	s.HandleSubscriptions(s.ServeHTTP(nil)).ServeHTTP(rec, req)
+	if !strings.Contains(rec.Body.String(), "Image message sent successfully") {
+		t.Fatalf("expected the image to be sent, got %.300s", rec.Body)
+	}
+}
+
+// TestMCPGoDoesNotHandleSubscribe fails once mcp-go handles resource
+// subscriptions itself, so that HandleSubscriptions can be removed.
+func TestMCPGoDoesNotHandleSubscribe(t *testing.T) {
+	s, err := NewServer(&config.StaticConfig{WeComBotKey: "test-key"})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	defer s.Close()
+
+	for _, method := range []string{methodSubscribe, methodUnsubscribe} {
+		request := fmt.Sprintf(`{"jsonrpc":"2.0","id":1,"method":%q,"params":{"uri":"wecom://media"}}`, method)
+		response, ok := s.server.HandleMessage(context.Background(), json.RawMessage(request)).(mcpgo.JSONRPCError)
+		if !ok || response.Error.Code != mcpgo.METHOD_NOT_FOUND {
+			t.Fatalf("mcp-go now handles %s (%+v); replace the ping workaround in HandleSubscriptions", method, response)
+		}
+	}
+}
+
+func TestSubscriptions_Remove(t *testing.T) {
+	subs := newSubscriptions()
+	subs.subscribe("s2", "wecom://a")
+	subs.subscribe("s1", "wecom://a")
+	subs.subscribe("s1", "wecom://b")
+	if got := subs.subscribers("wecom://a"); len(got) != 2 || got[0] != "s1" {
+		t.Fatalf("expected both sessions, got %v", got)
+	}
+	subs.remove("s1")
+	if got := subs.subscribers("wecom://a"); len(got) != 1 || got[0] != "s2" {
+		t.Fatalf("expected the ended session to be dropped, got %v", got)
+	}
+	if got := subs.subscribers("wecom://b"); len(got) != 0 {
+		t.Fatalf("expected no subscribers, got %v", got)
+	}
+}
@@ -27,3 +27,34 @@ type ServerTool struct {
 
 // ToolHandler is the function signature for handling tool calls.
 type ToolHandler func(client any, params map[string]any) (string, error)
+
+// ResourceProvider is implemented by toolsets that also expose MCP resources.
+type ResourceProvider interface {
+	// GetResources returns the concrete resources provided by this toolset.
+	GetResources(client any) []ServerResource
+
+	// GetResourceTemplates returns the resource templates provided by this toolset.
+	GetResourceTemplates(client any) []ServerResourceTemplate
+}
+
+// ServerResource represents an MCP resource with its handler.
+type ServerResource struct {
+	// Resource is the MCP resource definition.
+	Resource mcp.Resource
+
+	// Handler is the function that reads the resource.
+	Handler ResourceHandler
+}
+
+// ServerResourceTemplate represents an MCP resource template with its handler.
+type ServerResourceTemplate struct {
+	// Template is the MCP resource template definition.
+	Template mcp.ResourceTemplate
+
+	// Handler is the function that reads resources matching the template.
+	Handler ResourceHandler
+}
+
+// ResourceHandler is the function signature for reading a resource. It
+// returns the resource content as JSON.
+type ResourceHandler func(client any, uri string) (string, error)
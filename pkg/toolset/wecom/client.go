@@ -0,0 +1,178 @@
+package wecom
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/futuretea/wecom-bot-mcp-server/pkg/core/logging"
+	"github.com/futuretea/wecom-bot-mcp-server/pkg/toolset"
+	"github.com/futuretea/wecom-bot-mcp-server/pkg/wecomapi"
+)
+
+// ConfirmFunc asks a human to approve a rendered message before it is sent.
+// kind describes the message (e.g. "text message") and preview is its final
+// JSON payload. It returns whether the message was approved.
+type ConfirmFunc func(kind, preview string) (bool, error)
+
+// Client bundles the WeCom bot with the send policies configured for it.
+type Client struct {
+	// Bot is the underlying WeCom bot webhook client.
+	Bot *wecomapi.Bot
+
+	// RequireConfirmation holds every outgoing message until a human approves it.
+	RequireConfirmation bool
+
+	// ContentFilter scans message parameters before every send. Nil disables it.
+	ContentFilter *ContentFilter
+
+	// URLPolicy validates links, images and card actions. Nil only enforces
+	// URL syntax and the http/https scheme.
+	URLPolicy *URLPolicy
+
+	// Directory resolves names, aliases and teams in mentions. Nil means
+	// mentions must be raw WeCom user IDs.
+	Directory *Directory
+
+	// QuietHours defers or rejects non-urgent messages outside delivery
+	// windows. Nil disables it.
+	QuietHours *QuietHours
+
+	// Digest buffers text and markdown messages into a single markdown
+	// message. Nil sends every message immediately.
+	Digest *Digest
+
+	// Scheduler defers messages to a later time or cron schedule. Nil disables
+	// the scheduling tools.
+	Scheduler *Scheduler
+
+	// History records every message sent through the client. Nil disables it.
+	History *History
+
+	// Inbox stores the messages users send to the bot through the smart bot
+	// callback. Nil disables get_incoming_messages.
+	Inbox *Inbox
+
+	// Media tracks uploaded files so identical uploads reuse their media_id.
+	// Nil uploads every file and disables list_media.
+	Media *MediaRegistry
+
+	// Confirm asks the user to approve a message interactively. When nil,
+	// messages that require confirmation are parked and released by confirm_send.
+	Confirm ConfirmFunc
+
+	pending *pendingStore
+	uploads *uploadStore
+}
+
+// NewClient creates a client for the given bot with no send policies enabled.
+func NewClient(bot *wecomapi.Bot) *Client {
+	return &Client{
+		Bot:     bot,
+		pending: newPendingStore(),
+		uploads: newUploadStore(),
+	}
+}
+
+// WithConfirm returns a shallow copy of the client that uses confirm for
+// interactive approval. Pending messages are shared with the original client.
+func (c *Client) WithConfirm(confirm ConfirmFunc) *Client {
+	clone := *c
+	clone.Confirm = confirm
+	return &clone
+}
+
+// outgoingMessage is a fully built message waiting to be delivered.
+type outgoingMessage struct {
+	// kind is the human-readable message kind used in results and errors.
+	kind string
+	// payload is the library message, rendered as JSON for previews.
+	payload any
+	// send delivers the payload through the bot.
+	send func() error
+	// success is the tool result returned once the message is sent.
+	success string
+	// preview, when set, is shown for confirmation instead of the payload,
+	// which is only complete once send has run.
+	preview any
+	// result, when set, builds the tool result after send instead of success.
+	result func() string
+}
+
+// dispatch sends the message and returns its success result.
+func (m outgoingMessage) dispatch() (string, error) {
+	if err := m.send(); err != nil {
+		return "", fmt.Errorf("failed to send %s: %w", m.kind, err)
+	}
+	if m.result != nil {
+		return m.result(), nil
+	}
+	return m.success, nil
+}
+
+// IsSendTool reports whether a tool posts a message to the group.
+func IsSendTool(name string) bool {
+	return strings.HasPrefix(name, "send_")
+}
+
+// withSendPolicies wraps a send tool handler with the policies that apply to
+// every send: the content policy runs first so that deferred and buffered
+// messages are stored already filtered, followed by quiet hours and, for text
+// and markdown messages, digest batching.
+func withSendPolicies(tool string, handler toolset.ToolHandler) toolset.ToolHandler {
+	if isDigestTool(tool) {
+		handler = withDigest(tool, handler)
+	}
+	return withContentPolicy(withQuietHours(tool, handler))
+}
+
+// getClient validates and returns the WeCom client from the generic client.
+// A bare bot is wrapped in a client without any send policies.
+func getClient(client any) (*Client, error) {
+	switch c := client.(type) {
+	case *Client:
+		if c != nil && c.Bot != nil {
+			return c, nil
+		}
+	case *wecomapi.Bot:
+		if c != nil {
+			return NewClient(c), nil
+		}
+	}
+	return nil, fmt.Errorf("weCom bot client is not configured")
+}
+
+// deliver sends the message, applying the client's send policies first.
+func (c *Client) deliver(msg outgoingMessage) (string, error) {
+	if c.RequireConfirmation {
+		return c.confirmAndSend(msg)
+	}
+	return c.dispatch(msg)
+}
+
+// dispatch sends the message and records it in the history. A history that
+// cannot be saved is logged but does not fail the send.
+func (c *Client) dispatch(msg outgoingMessage) (string, error) {
+	result, err := msg.dispatch()
+	if err != nil || c.History == nil {
+		return result, err
+	}
+	if _, err := c.History.Record(msg.kind, msg.payload); err != nil {
+		logging.Warn("Failed to record %s in history: %v", msg.kind, err)
+	}
+	return result, nil
+}
+
+// renderPreview renders a message payload as indented JSON. HTML escaping is
+// disabled so markup such as <@userid> mentions stays readable.
+func renderPreview(payload any) (string, error) {
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	encoder.SetEscapeHTML(false)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(payload); err != nil {
+		return "", fmt.Errorf("failed to render message preview: %w", err)
+	}
+	return strings.TrimRight(buf.String(), "\n"), nil
+}
@@ -0,0 +1,135 @@
+package wecom
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// pendingMessageTTL is how long a message awaiting confirmation can be released.
+const pendingMessageTTL = 10 * time.Minute
+
+// pendingMessage is a message parked until a human confirms it.
+type pendingMessage struct {
+	msg       outgoingMessage
+	expiresAt time.Time
+}
+
+// pendingStore keeps messages awaiting confirmation, keyed by pending ID.
+type pendingStore struct {
+	mu       sync.Mutex
+	messages map[string]pendingMessage
+	now      func() time.Time
+}
+
+func newPendingStore() *pendingStore {
+	return &pendingStore{
+		messages: make(map[string]pendingMessage),
+		now:      time.Now,
+	}
+}
+
+// add parks a message and returns its pending ID.
+func (p *pendingStore) add(msg outgoingMessage) (string, error) {
+	buf := make([]byte, 8)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("failed to generate pending_id: %w", err)
+	}
+	id := hex.EncodeToString(buf)
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.pruneLocked()
+	p.messages[id] = pendingMessage{
+		msg:       msg,
+		expiresAt: p.now().Add(pendingMessageTTL),
+	}
+	return id, nil
+}
+
+// take removes and returns the message with the given pending ID.
+func (p *pendingStore) take(id string) (outgoingMessage, bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.pruneLocked()
+	pending, ok := p.messages[id]
+	if !ok {
+		return outgoingMessage{}, false
+	}
+	delete(p.messages, id)
+	return pending.msg, true
+}
+
+// pruneLocked drops expired messages. The caller must hold p.mu.
+func (p *pendingStore) pruneLocked() {
+	now := p.now()
+	for id, pending := range p.messages {
+		if now.After(pending.expiresAt) {
+			delete(p.messages, id)
+		}
+	}
+}
+
+// confirmAndSend asks for approval through Confirm when available, and
+// otherwise parks the message until confirm_send is called with its ID.
+func (c *Client) confirmAndSend(msg outgoingMessage) (string, error) {
+	payload := msg.payload
+	if msg.preview != nil {
+		payload = msg.preview
+	}
+	preview, err := renderPreview(payload)
+	if err != nil {
+		return "", err
+	}
+
+	if c.Confirm != nil {
+		approved, err := c.Confirm(msg.kind, preview)
+		if err != nil {
+			return "", fmt.Errorf("failed to confirm %s: %w", msg.kind, err)
+		}
+		if !approved {
+			return "", fmt.Errorf("%s was not approved and has not been sent", msg.kind)
+		}
+		return c.dispatch(msg)
+	}
+
+	if c.pending == nil {
+		return "", fmt.Errorf("confirmation is required but no pending message store is configured")
+	}
+	id, err := c.pending.add(msg)
+	if err != nil {
+		return "", err
+	}
+
+	return fmt.Sprintf("Confirmation required: the %s has NOT been sent yet. "+
+		"Show the preview below to the user and call confirm_send with pending_id %q only after they explicitly approve it. "+
+		"The pending message expires in %s.\n\n%s",
+		msg.kind, id, pendingMessageTTL, preview), nil
+}
+
+// handleConfirmSend handles the confirm_send tool call.
+func handleConfirmSend(client any, params map[string]any) (string, error) {
+	c, err := getClient(client)
+	if err != nil {
+		return "", err
+	}
+
+	id := stringParam(params, "pending_id")
+	if id == "" {
+		return "", fmt.Errorf("pending_id is required")
+	}
+
+	if c.pending == nil {
+		return "", fmt.Errorf("no pending message with pending_id %q", id)
+	}
+	msg, ok := c.pending.take(id)
+	if !ok {
+		return "", fmt.Errorf("no pending message with pending_id %q (it may have expired or already been sent)", id)
+	}
+
+	return c.dispatch(msg)
+}
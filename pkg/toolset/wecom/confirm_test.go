@@ -0,0 +1,204 @@
+package wecom
+
+import (
+	"encoding/base64"
+	"errors"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+)
+
+var pendingIDPattern = regexp.MustCompile(`pending_id "([0-9a-f]+)"`)
+
+func newConfirmingClient(t *testing.T) *Client {
+	c := NewClient(newTestBot(t))
+	c.RequireConfirmation = true
+	return c
+}
+
+func countingMessage(sent *int) outgoingMessage {
+	return outgoingMessage{
+		kind:    "text message",
+		payload: map[string]any{"msgtype": "text"},
+		send: func() error {
+			*sent++
+			return nil
+		},
+		success: "Text message sent successfully",
+	}
+}
+
+func extractPendingID(t *testing.T, result string) string {
+	t.Helper()
+	match := pendingIDPattern.FindStringSubmatch(result)
+	if match == nil {
+		t.Fatalf("expected pending_id in result, got %q", result)
+	}
+	return match[1]
+}
+
+func TestDeliver_NoConfirmationSendsImmediately(t *testing.T) {
+	c := NewClient(newTestBot(t))
+	sent := 0
+	result, err := c.deliver(countingMessage(&sent))
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if sent != 1 || result != "Text message sent successfully" {
+		t.Fatalf("expected message to be sent, sent=%d result=%q", sent, result)
+	}
+}
+
+func TestDeliver_TwoPhaseConfirmation(t *testing.T) {
+	c := newConfirmingClient(t)
+	sent := 0
+	result, err := c.deliver(countingMessage(&sent))
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if sent != 0 {
+		t.Fatal("expected message to be held until confirmed")
+	}
+	if !strings.Contains(result, `"msgtype": "text"`) {
+		t.Fatalf("expected preview in result, got %q", result)
+	}
+
+	id := extractPendingID(t, result)
+	result, err = handleConfirmSend(c, map[string]any{"pending_id": id})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if sent != 1 || result != "Text message sent successfully" {
+		t.Fatalf("expected message to be sent, sent=%d result=%q", sent, result)
+	}
+
+	// A pending message can only be released once
+	if _, err := handleConfirmSend(c, map[string]any{"pending_id": id}); err == nil {
+		t.Fatal("expected error when confirming the same message twice")
+	}
+	if sent != 1 {
+		t.Fatalf("expected message to be sent once, got %d", sent)
+	}
+}
+
+func TestDeliver_InteractiveApproval(t *testing.T) {
+	var gotKind, gotPreview string
+	c := newConfirmingClient(t).WithConfirm(func(kind, preview string) (bool, error) {
+		gotKind, gotPreview = kind, preview
+		return true, nil
+	})
+	sent := 0
+	if _, err := c.deliver(countingMessage(&sent)); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if sent != 1 {
+		t.Fatal("expected approved message to be sent")
+	}
+	if gotKind != "text message" || !strings.Contains(gotPreview, "msgtype") {
+		t.Fatalf("unexpected confirmation request: kind=%q preview=%q", gotKind, gotPreview)
+	}
+}
+
+func TestDeliver_InteractiveDecline(t *testing.T) {
+	c := newConfirmingClient(t).WithConfirm(func(_, _ string) (bool, error) {
+		return false, nil
+	})
+	sent := 0
+	_, err := c.deliver(countingMessage(&sent))
+	if err == nil || !strings.Contains(err.Error(), "not approved") {
+		t.Fatalf("expected 'not approved' error, got %v", err)
+	}
+	if sent != 0 {
+		t.Fatal("expected declined message not to be sent")
+	}
+}
+
+func TestDeliver_InteractiveError(t *testing.T) {
+	c := newConfirmingClient(t).WithConfirm(func(_, _ string) (bool, error) {
+		return false, errors.New("client went away")
+	})
+	sent := 0
+	if _, err := c.deliver(countingMessage(&sent)); err == nil {
+		t.Fatal("expected error when confirmation fails")
+	}
+	if sent != 0 {
+		t.Fatal("expected message not to be sent when confirmation fails")
+	}
+}
+
+func TestPendingStore_Expiry(t *testing.T) {
+	store := newPendingStore()
+	now := time.Now()
+	store.now = func() time.Time { return now }
+
+	sent := 0
+	id, err := store.add(countingMessage(&sent))
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	now = now.Add(pendingMessageTTL + time.Second)
+	if _, ok := store.take(id); ok {
+		t.Fatal("expected expired message to be dropped")
+	}
+}
+
+func TestHandleConfirmSend_MissingID(t *testing.T) {
+	_, err := handleConfirmSend(newConfirmingClient(t), map[string]any{})
+	if err == nil || !strings.Contains(err.Error(), "pending_id is required") {
+		t.Fatalf("expected 'pending_id is required' error, got %v", err)
+	}
+}
+
+func TestHandleConfirmSend_UnknownID(t *testing.T) {
+	_, err := handleConfirmSend(newConfirmingClient(t), map[string]any{"pending_id": "deadbeef"})
+	if err == nil || !strings.Contains(err.Error(), "no pending message") {
+		t.Fatalf("expected 'no pending message' error, got %v", err)
+	}
+}
+
+func TestHandleSendText_HeldForConfirmation(t *testing.T) {
+	result, err := handleSendText(newConfirmingClient(t), map[string]any{"content": "hello"})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if !strings.Contains(result, "NOT been sent") || !strings.Contains(result, "hello") {
+		t.Fatalf("expected held message with preview, got %q", result)
+	}
+}
+
+func TestHandleSendFile_HeldForConfirmation(t *testing.T) {
+	bot, fake := newFakeBot(t)
+	c := NewClient(bot)
+	c.RequireConfirmation = true
+	data := []byte("name,value\nuptime,99.9\n")
+
+	result, err := handleSendFile(c, map[string]any{
+		"filename":    "report.csv",
+		"base64_data": base64.StdEncoding.EncodeToString(data),
+	})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if len(fake.Uploads()) != 0 {
+		t.Fatal("expected the file not to be uploaded before approval")
+	}
+	for _, want := range []string{`"filename": "report.csv"`, `"size": 23`, `"sha256": "` + sha256Hex(data) + `"`} {
+		if !strings.Contains(result, want) {
+			t.Fatalf("expected preview to contain %s, got %q", want, result)
+		}
+	}
+
+	result, err = handleConfirmSend(c, map[string]any{"pending_id": extractPendingID(t, result)})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	uploads := fake.Uploads()
+	if len(uploads) != 1 || result != "File message sent successfully. media_id: "+uploads[0].MediaID {
+		t.Fatalf("expected the file to be uploaded and sent, got %q, uploads %+v", result, uploads)
+	}
+	if msg := lastMessage(t, fake); messageBody(msg)["media_id"] != uploads[0].MediaID {
+		t.Fatalf("unexpected message: %+v", msg)
+	}
+}
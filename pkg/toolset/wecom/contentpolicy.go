@@ -0,0 +1,252 @@
+package wecom
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/futuretea/wecom-bot-mcp-server/pkg/core/config"
+	"github.com/futuretea/wecom-bot-mcp-server/pkg/toolset"
+)
+
+// builtinContentRules returns the detectors enabled by default. Webhook URLs
+// are detected on the public WeCom API and on the configured base URL, and the
+// configured bot key is detected on its own.
+func builtinContentRules(baseURL, botKey string) []config.ContentRule {
+	rules := []config.ContentRule{
+		{
+			Name:    "aws_access_key",
+			Pattern: `\b(?:AKIA|ASIA)[0-9A-Z]{16}\b`,
+			Action:  config.ContentActionRedact,
+		},
+		{
+			Name:    "jwt",
+			Pattern: `\beyJ[A-Za-z0-9_-]{4,}\.eyJ[A-Za-z0-9_-]{4,}\.[A-Za-z0-9_-]{8,}`,
+			Action:  config.ContentActionRedact,
+		},
+		{
+			Name:    "private_key",
+			Pattern: `-----BEGIN [A-Z0-9 ]*PRIVATE KEY-----`,
+			Action:  config.ContentActionReject,
+		},
+		{
+			Name:    "wecom_webhook_url",
+			Pattern: webhookURLPattern(baseURL),
+			Action:  config.ContentActionRedact,
+		},
+	}
+	if botKey != "" {
+		rules = append(rules, config.ContentRule{
+			Name:    "wecom_bot_key",
+			Pattern: regexp.QuoteMeta(botKey),
+			Action:  config.ContentActionRedact,
+		})
+	}
+	return rules
+}
+
+// webhookURLPattern matches webhook URLs with a key on the public WeCom API
+// and, when it differs, on baseURL. The scheme of baseURL is not required.
+func webhookURLPattern(baseURL string) string {
+	hosts := []string{regexp.QuoteMeta(strings.TrimPrefix(config.DefaultWeComBaseURL, "https://"))}
+	if u, err := url.Parse(strings.TrimSuffix(baseURL, "/")); err == nil && u.Host != "" {
+		if host := regexp.QuoteMeta(u.Host + u.Path); !slices.Contains(hosts, host) {
+			hosts = append(hosts, host)
+		}
+	}
+	return `https?://(?:` + strings.Join(hosts, "|") + `)/cgi-bin/webhook/\w+\?(?:[^\s?#]*&)?key=[A-Za-z0-9_-]+`
+}
+
+// binaryParams are parameters holding encoded binary data, which are never scanned.
+var binaryParams = []string{"base64", "md5", "base64_data"}
+
+// contentRule is a compiled content filter rule.
+type contentRule struct {
+	name        string
+	pattern     *regexp.Regexp
+	action      string
+	replacement string
+}
+
+// ContentFilter scans outgoing message parameters for secrets and blocked
+// patterns, redacting, rejecting or flagging them according to its rules.
+type ContentFilter struct {
+	rules []contentRule
+}
+
+// contentFinding records a rule that matched in a parameter.
+type contentFinding struct {
+	rule    string
+	action  string
+	field   string
+	matches int
+}
+
+// NewContentFilter compiles the built-in detectors and the custom rules of the
+// policy. baseURL and botKey are the WeCom API base URL and bot key to detect.
+// A custom rule named after a built-in detector overrides it, and may omit the
+// pattern to only change the action.
+func NewContentFilter(policy config.ContentPolicy, baseURL, botKey string) (*ContentFilter, error) {
+	specs := builtinContentRules(baseURL, botKey)
+	for _, rule := range policy.Rules {
+		idx := slices.IndexFunc(specs, func(r config.ContentRule) bool { return r.Name == rule.Name })
+		if idx < 0 {
+			if rule.Pattern == "" {
+				return nil, fmt.Errorf("content rule %q requires a pattern", rule.Name)
+			}
+			specs = append(specs, rule)
+			continue
+		}
+		if rule.Pattern == "" {
+			rule.Pattern = specs[idx].Pattern
+		}
+		specs[idx] = rule
+	}
+
+	filter := &ContentFilter{}
+	for _, spec := range specs {
+		if slices.Contains(policy.DisabledRules, spec.Name) {
+			continue
+		}
+		pattern, err := regexp.Compile(spec.Pattern)
+		if err != nil {
+			return nil, fmt.Errorf("content rule %q has an invalid pattern: %w", spec.Name, err)
+		}
+		replacement := spec.Replacement
+		if replacement == "" {
+			replacement = fmt.Sprintf("[REDACTED:%s]", spec.Name)
+		}
+		filter.rules = append(filter.rules, contentRule{
+			name:        spec.Name,
+			pattern:     pattern,
+			action:      spec.Action,
+			replacement: replacement,
+		})
+	}
+	return filter, nil
+}
+
+// Apply scans every string in params and returns a filtered copy along with a
+// report describing the rules that fired. It returns an error when a reject
+// rule matches.
+func (f *ContentFilter) Apply(params map[string]any) (map[string]any, string, error) {
+	var findings []contentFinding
+	filtered := f.filterValue("", params, &findings).(map[string]any)
+
+	var rejected []string
+	for _, finding := range findings {
+		if finding.action == config.ContentActionReject {
+			rejected = append(rejected, fmt.Sprintf("rule %q matched in %s", finding.rule, finding.field))
+		}
+	}
+	if len(rejected) > 0 {
+		return nil, "", fmt.Errorf("message rejected by content policy: %s", strings.Join(rejected, "; "))
+	}
+
+	return filtered, formatContentFindings(findings), nil
+}
+
+// filterValue returns a filtered copy of value, recording findings under path.
+func (f *ContentFilter) filterValue(path string, value any, findings *[]contentFinding) any {
+	switch v := value.(type) {
+	case string:
+		return f.filterString(path, v, findings)
+	case map[string]any:
+		result := make(map[string]any, len(v))
+		for key, item := range v {
+			if path == "" && slices.Contains(binaryParams, key) {
+				result[key] = item
+				continue
+			}
+			result[key] = f.filterValue(joinFieldPath(path, key), item, findings)
+		}
+		return result
+	case []any:
+		result := make([]any, len(v))
+		for i, item := range v {
+			result[i] = f.filterValue(fmt.Sprintf("%s[%d]", path, i), item, findings)
+		}
+		return result
+	case []string:
+		result := make([]string, len(v))
+		for i, item := range v {
+			result[i] = f.filterString(fmt.Sprintf("%s[%d]", path, i), item, findings)
+		}
+		return result
+	default:
+		return value
+	}
+}
+
+// filterString applies every rule to a single string.
+func (f *ContentFilter) filterString(path, value string, findings *[]contentFinding) string {
+	for _, rule := range f.rules {
+		matches := rule.pattern.FindAllStringIndex(value, -1)
+		if len(matches) == 0 {
+			continue
+		}
+		*findings = append(*findings, contentFinding{
+			rule:    rule.name,
+			action:  rule.action,
+			field:   path,
+			matches: len(matches),
+		})
+		if rule.action == config.ContentActionRedact {
+			value = rule.pattern.ReplaceAllLiteralString(value, rule.replacement)
+		}
+	}
+	return value
+}
+
+// joinFieldPath appends key to a dotted parameter path.
+func joinFieldPath(path, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
+
+// formatContentFindings renders findings as a human-readable report.
+func formatContentFindings(findings []contentFinding) string {
+	if len(findings) == 0 {
+		return ""
+	}
+
+	lines := make([]string, 0, len(findings))
+	for _, finding := range findings {
+		verb := "redacted"
+		if finding.action == config.ContentActionWarn {
+			verb = "warning:"
+		}
+		lines = append(lines, fmt.Sprintf("- %s %d match(es) of rule %q in %s", verb, finding.matches, finding.rule, finding.field))
+	}
+	sort.Strings(lines)
+	return "Content policy:\n" + strings.Join(lines, "\n")
+}
+
+// withContentPolicy wraps a send handler so the client's content filter runs
+// on its parameters before the message is built.
+func withContentPolicy(handler toolset.ToolHandler) toolset.ToolHandler {
+	return func(client any, params map[string]any) (string, error) {
+		c, err := getClient(client)
+		if err != nil {
+			return "", err
+		}
+		if c.ContentFilter == nil {
+			return handler(client, params)
+		}
+
+		filtered, report, err := c.ContentFilter.Apply(params)
+		if err != nil {
+			return "", err
+		}
+		result, err := handler(client, filtered)
+		if err != nil || report == "" {
+			return result, err
+		}
+		return result + "\n\n" + report, nil
+	}
+}
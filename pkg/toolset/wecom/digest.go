@@ -0,0 +1,228 @@
+package wecom
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/futuretea/wecom-bot-mcp-server/pkg/core/logging"
+	"github.com/futuretea/wecom-bot-mcp-server/pkg/toolset"
+)
+
+// digestSeparator separates buffered messages inside a digest.
+const digestSeparator = "\n\n"
+
+// Digest buffers text and markdown messages and sends them as a single
+// markdown message once the window that started with the first buffered
+// message has passed, or when flush_digest is called.
+type Digest struct {
+	window time.Duration
+	// client sends digests flushed by the timer.
+	client *Client
+	now    func() time.Time
+
+	mu      sync.Mutex
+	entries []string
+	timer   *time.Timer
+	dueAt   time.Time
+}
+
+// NewDigest creates a digest that sends through client when its window expires.
+func NewDigest(window time.Duration, client *Client) *Digest {
+	return &Digest{
+		window: window,
+		client: client,
+		now:    time.Now,
+	}
+}
+
+// Add buffers a rendered markdown entry and returns a description of when it
+// will be sent.
+func (d *Digest) Add(entry string) string {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.entries = append(d.entries, entry)
+	d.startTimerLocked()
+	return fmt.Sprintf("Message added to digest (%d message(s) buffered). The digest will be sent at %s, or call flush_digest to send it now.",
+		len(d.entries), d.dueAt.Format(time.RFC3339))
+}
+
+// startTimerLocked starts the flush timer unless one is already running.
+func (d *Digest) startTimerLocked() {
+	if d.timer != nil {
+		return
+	}
+	d.dueAt = d.now().Add(d.window)
+	d.timer = time.AfterFunc(d.window, d.flushOnTimer)
+}
+
+// flushOnTimer sends the digest when its window expires.
+func (d *Digest) flushOnTimer() {
+	result, err := d.Flush(d.client)
+	if err != nil {
+		logging.Error("Failed to send digest: %v", err)
+		return
+	}
+	logging.Info("%s", result)
+}
+
+// Flush sends every buffered message through via, splitting the digest into
+// several markdown messages when it exceeds the markdown size limit. Messages
+// that could not be sent are kept for the next flush.
+func (d *Digest) Flush(via *Client) (string, error) {
+	d.mu.Lock()
+	entries := d.entries
+	d.entries = nil
+	if d.timer != nil {
+		d.timer.Stop()
+		d.timer = nil
+	}
+	d.mu.Unlock()
+
+	if len(entries) == 0 {
+		return "Digest is empty, nothing to send", nil
+	}
+
+	parts := packDigest(entries, maxMarkdownContentBytes)
+	var results []string
+	for i, part := range parts {
+		result, err := handleSendMarkdown(via, map[string]any{"content": strings.Join(part, digestSeparator)})
+		if err != nil {
+			d.requeue(slices.Concat(parts[i:]...))
+			return "", fmt.Errorf("failed to send digest part %d of %d (%d message(s) kept for the next flush): %w",
+				i+1, len(parts), len(entries)-countEntries(parts[:i]), err)
+		}
+		results = append(results, fmt.Sprintf("- part %d: %s", i+1, result))
+	}
+	return fmt.Sprintf("Digest of %d message(s) sent as %d markdown message(s):\n%s",
+		len(entries), len(parts), strings.Join(results, "\n")), nil
+}
+
+// requeue puts unsent entries back at the front of the buffer.
+func (d *Digest) requeue(entries []string) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.entries = append(entries, d.entries...)
+	d.startTimerLocked()
+}
+
+// Stop flushes any buffered messages so they are not lost on shutdown.
+func (d *Digest) Stop() {
+	d.mu.Lock()
+	pending := len(d.entries)
+	d.mu.Unlock()
+	if pending > 0 {
+		d.flushOnTimer()
+	}
+}
+
+// packDigest groups entries, in order, so that each group joined with
+// digestSeparator fits in maxBytes. Every entry is expected to fit on its own.
+func packDigest(entries []string, maxBytes int) [][]string {
+	var parts [][]string
+	var current []string
+	size := 0
+	for _, entry := range entries {
+		added := len(entry)
+		if len(current) > 0 {
+			added += len(digestSeparator)
+		}
+		if len(current) > 0 && size+added > maxBytes {
+			parts = append(parts, current)
+			current, size = nil, 0
+			added = len(entry)
+		}
+		current = append(current, entry)
+		size += added
+	}
+	if len(current) > 0 {
+		parts = append(parts, current)
+	}
+	return parts
+}
+
+// countEntries returns the number of entries across parts.
+func countEntries(parts [][]string) int {
+	n := 0
+	for _, part := range parts {
+		n += len(part)
+	}
+	return n
+}
+
+// isDigestTool reports whether a send tool's messages can be merged into a digest.
+func isDigestTool(name string) bool {
+	return name == "send_text" || name == "send_markdown"
+}
+
+// withDigest wraps send_text and send_markdown so that, in digest mode,
+// messages are buffered instead of sent. Urgent messages and messages that
+// mention users by mobile number or @all are sent immediately, since markdown
+// cannot express those mentions.
+func withDigest(tool string, handler toolset.ToolHandler) toolset.ToolHandler {
+	return func(client any, params map[string]any) (string, error) {
+		c, err := getClient(client)
+		if err != nil {
+			return "", err
+		}
+		if c.Digest == nil || stringParam(params, "priority") == priorityUrgent {
+			return handler(client, params)
+		}
+
+		entry, ok, err := c.digestEntry(tool, params)
+		if err != nil {
+			return "", err
+		}
+		if !ok {
+			return handler(client, params)
+		}
+		return c.Digest.Add(entry), nil
+	}
+}
+
+// digestEntry renders a text or markdown message as a markdown digest entry.
+// It returns false when the message cannot be expressed in markdown.
+func (c *Client) digestEntry(tool string, params map[string]any) (string, bool, error) {
+	maxBytes := maxMarkdownContentBytes
+	if tool == "send_text" {
+		maxBytes = maxTextContentBytes
+	}
+
+	content := stringParam(params, "content")
+	if content == "" {
+		return "", false, fmt.Errorf("content is required")
+	}
+	if len(content) > maxBytes {
+		return "", false, fmt.Errorf("content exceeds maximum size of %d bytes", maxBytes)
+	}
+
+	userIDs, mobiles, err := c.resolveMentions(stringSliceParam(params, "mentioned_list"))
+	if err != nil {
+		return "", false, err
+	}
+	if len(mobiles) > 0 || len(stringSliceParam(params, "mentioned_mobile_list")) > 0 || slices.Contains(userIDs, mentionAll) {
+		return "", false, nil
+	}
+	if len(userIDs) > 0 {
+		content = appendMarkdownMentions(content, userIDs)
+		if len(content) > maxMarkdownContentBytes {
+			return "", false, fmt.Errorf("content with mentions exceeds maximum size of %d bytes", maxMarkdownContentBytes)
+		}
+	}
+	return content, true, nil
+}
+
+// handleFlushDigest handles the flush_digest tool call.
+func handleFlushDigest(client any, _ map[string]any) (string, error) {
+	c, err := getClient(client)
+	if err != nil {
+		return "", err
+	}
+	if c.Digest == nil {
+		return "", fmt.Errorf("digest mode is not enabled (set digest.window)")
+	}
+	return c.Digest.Flush(c)
+}
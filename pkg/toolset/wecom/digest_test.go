@@ -0,0 +1,145 @@
+package wecom
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+// newDigestClient returns a client in digest mode whose messages are parked for
+// confirmation instead of being sent, so no webhook is called.
+func newDigestClient(t *testing.T) *Client {
+	t.Helper()
+	c := NewClient(newTestBot(t))
+	c.RequireConfirmation = true
+	c.Digest = NewDigest(time.Hour, c)
+	t.Cleanup(func() {
+		c.Digest.mu.Lock()
+		defer c.Digest.mu.Unlock()
+		if c.Digest.timer != nil {
+			c.Digest.timer.Stop()
+		}
+	})
+	return c
+}
+
+func TestPackDigest(t *testing.T) {
+	entries := []string{strings.Repeat("a", 6), strings.Repeat("b", 6), strings.Repeat("c", 10), "d"}
+	parts := packDigest(entries, 14)
+	if len(parts) != 2 {
+		t.Fatalf("expected 2 parts, got %d: %v", len(parts), parts)
+	}
+	for i, part := range parts {
+		if size := len(strings.Join(part, digestSeparator)); size > 14 {
+			t.Fatalf("part %d is %d bytes, exceeds limit", i, size)
+		}
+	}
+	if countEntries(parts) != len(entries) {
+		t.Fatalf("expected %d entries across parts, got %d", len(entries), countEntries(parts))
+	}
+}
+
+func TestWithDigest_Buffers(t *testing.T) {
+	c := newDigestClient(t)
+	c.Directory = loadTestDirectory(t)
+	handler := withSendPolicies("send_text", handleSendText)
+
+	result, err := handler(c, map[string]any{"content": "item 1", "mentioned_list": []any{"san"}})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if !strings.Contains(result, "added to digest (1 message(s) buffered)") {
+		t.Fatalf("unexpected result: %q", result)
+	}
+
+	result, err = withSendPolicies("send_markdown", handleSendMarkdown)(c, map[string]any{"content": "**item 2**"})
+	if err != nil || !strings.Contains(result, "(2 message(s) buffered)") {
+		t.Fatalf("expected second message to be buffered, got %q, %v", result, err)
+	}
+
+	if got := c.Digest.entries; len(got) != 2 || got[0] != "item 1\n\n<@zhangsan>" {
+		t.Fatalf("unexpected buffered entries: %q", got)
+	}
+}
+
+func TestWithDigest_Bypass(t *testing.T) {
+	tests := []struct {
+		name   string
+		params map[string]any
+	}{
+		{name: "urgent", params: map[string]any{"content": "down", "priority": priorityUrgent}},
+		{name: "mobile mention", params: map[string]any{"content": "hi", "mentioned_mobile_list": []any{"13800138000"}}},
+		{name: "mention all", params: map[string]any{"content": "hi", "mentioned_list": []any{"@all"}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := newDigestClient(t)
+			result, err := withSendPolicies("send_text", handleSendText)(c, tt.params)
+			if err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+			if !strings.Contains(result, "Confirmation required") || len(c.Digest.entries) != 0 {
+				t.Fatalf("expected message to bypass the digest, got %q", result)
+			}
+		})
+	}
+}
+
+func TestWithDigest_Validation(t *testing.T) {
+	c := newDigestClient(t)
+	_, err := withSendPolicies("send_text", handleSendText)(c, map[string]any{"content": strings.Repeat("a", maxTextContentBytes+1)})
+	if err == nil || !strings.Contains(err.Error(), "exceeds maximum size") {
+		t.Fatalf("expected size error, got %v", err)
+	}
+	if len(c.Digest.entries) != 0 {
+		t.Fatal("expected invalid message not to be buffered")
+	}
+}
+
+func TestDigestFlush(t *testing.T) {
+	c := newDigestClient(t)
+	for i := 0; i < 3; i++ {
+		c.Digest.Add(strings.Repeat("x", 2000))
+	}
+
+	result, err := handleFlushDigest(c, nil)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if !strings.Contains(result, "Digest of 3 message(s) sent as 2 markdown message(s)") {
+		t.Fatalf("unexpected result: %q", result)
+	}
+	if len(c.Digest.entries) != 0 || c.Digest.timer != nil {
+		t.Fatal("expected digest to be empty after flush")
+	}
+
+	result, err = handleFlushDigest(c, nil)
+	if err != nil || !strings.Contains(result, "empty") {
+		t.Fatalf("expected empty digest result, got %q, %v", result, err)
+	}
+}
+
+func TestDigestFlush_FailureKeepsMessages(t *testing.T) {
+	c := newDigestClient(t)
+	c.Digest.Add("first")
+	c.Digest.Add("second")
+
+	failing := c.WithConfirm(func(kind, preview string) (bool, error) {
+		return false, errors.New("approval unavailable")
+	})
+	_, err := c.Digest.Flush(failing)
+	if err == nil || !strings.Contains(err.Error(), "2 message(s) kept") {
+		t.Fatalf("expected flush failure, got %v", err)
+	}
+	if len(c.Digest.entries) != 2 || c.Digest.timer == nil {
+		t.Fatalf("expected messages to be requeued, got %q", c.Digest.entries)
+	}
+}
+
+func TestHandleFlushDigest_Disabled(t *testing.T) {
+	_, err := handleFlushDigest(newTestBot(t), nil)
+	if err == nil || !strings.Contains(err.Error(), "digest mode is not enabled") {
+		t.Fatalf("expected disabled error, got %v", err)
+	}
+}
@@ -0,0 +1,282 @@
+package wecom
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// mentionAll is the special mention that notifies every group member.
+const mentionAll = "@all"
+
+// DirectoryUser is a person that can be @mentioned by name.
+type DirectoryUser struct {
+	Name    string   `yaml:"name"`
+	Aliases []string `yaml:"aliases"`
+	UserID  string   `yaml:"userid"`
+	Mobile  string   `yaml:"mobile"`
+	Team    string   `yaml:"team"`
+}
+
+// Directory resolves human names, aliases and team names to WeCom user IDs
+// and mobile numbers.
+type Directory struct {
+	users []DirectoryUser
+	// byKey maps normalized user IDs, names and aliases to users.
+	byKey map[string]*DirectoryUser
+	// teams maps normalized team names to their members.
+	teams map[string][]*DirectoryUser
+}
+
+// directoryFile is the YAML layout of a directory file.
+type directoryFile struct {
+	Users []DirectoryUser `yaml:"users"`
+}
+
+// LoadDirectory reads a directory from a YAML or CSV file, chosen by extension.
+func LoadDirectory(path string) (*Directory, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open directory file: %w", err)
+	}
+	defer f.Close()
+
+	var users []DirectoryUser
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".csv":
+		users, err = parseDirectoryCSV(f)
+	case ".yaml", ".yml":
+		users, err = parseDirectoryYAML(f)
+	default:
+		return nil, fmt.Errorf("unsupported directory file format %q, expected .yaml, .yml or .csv", filepath.Ext(path))
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse directory file %s: %w", path, err)
+	}
+	return NewDirectory(users)
+}
+
+// parseDirectoryYAML parses a directory in the form {users: [...]}.
+func parseDirectoryYAML(r io.Reader) ([]DirectoryUser, error) {
+	var file directoryFile
+	if err := yaml.NewDecoder(r).Decode(&file); err != nil && err != io.EOF {
+		return nil, err
+	}
+	return file.Users, nil
+}
+
+// parseDirectoryCSV parses a directory with a header row. Recognized columns
+// are name, aliases, userid, mobile and team; aliases are separated by "|".
+func parseDirectoryCSV(r io.Reader) ([]DirectoryUser, error) {
+	reader := csv.NewReader(r)
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read header: %w", err)
+	}
+	columns := make(map[string]int, len(header))
+	for i, name := range header {
+		columns[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+	if _, ok := columns["name"]; !ok {
+		return nil, fmt.Errorf("missing required column %q", "name")
+	}
+
+	field := func(record []string, column string) string {
+		idx, ok := columns[column]
+		if !ok || idx >= len(record) {
+			return ""
+		}
+		return strings.TrimSpace(record[idx])
+	}
+
+	var users []DirectoryUser
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		var aliases []string
+		for _, alias := range strings.Split(field(record, "aliases"), "|") {
+			if alias = strings.TrimSpace(alias); alias != "" {
+				aliases = append(aliases, alias)
+			}
+		}
+		users = append(users, DirectoryUser{
+			Name:    field(record, "name"),
+			Aliases: aliases,
+			UserID:  field(record, "userid"),
+			Mobile:  field(record, "mobile"),
+			Team:    field(record, "team"),
+		})
+	}
+	return users, nil
+}
+
+// NewDirectory builds a directory from users. Every user needs a name and at
+// least one of userid or mobile, and names and aliases must be unambiguous.
+func NewDirectory(users []DirectoryUser) (*Directory, error) {
+	d := &Directory{
+		users: users,
+		byKey: make(map[string]*DirectoryUser),
+		teams: make(map[string][]*DirectoryUser),
+	}
+
+	for i := range d.users {
+		user := &d.users[i]
+		if user.Name == "" {
+			return nil, fmt.Errorf("directory entry %d is missing a name", i)
+		}
+		if user.UserID == "" && user.Mobile == "" {
+			return nil, fmt.Errorf("directory entry %q needs a userid or mobile", user.Name)
+		}
+
+		keys := append([]string{user.UserID, user.Name}, user.Aliases...)
+		for _, key := range keys {
+			key = normalizeMention(key)
+			if key == "" {
+				continue
+			}
+			if existing, ok := d.byKey[key]; ok && existing != user {
+				return nil, fmt.Errorf("directory name %q is ambiguous between %q and %q", key, existing.Name, user.Name)
+			}
+			d.byKey[key] = user
+		}
+
+		if team := normalizeMention(user.Team); team != "" {
+			d.teams[team] = append(d.teams[team], user)
+		}
+	}
+	return d, nil
+}
+
+// normalizeMention lowercases a mention and strips a leading "@".
+func normalizeMention(mention string) string {
+	return strings.ToLower(strings.TrimPrefix(strings.TrimSpace(mention), "@"))
+}
+
+// ResolveMentions expands names, aliases and team names into WeCom user IDs,
+// falling back to mobile numbers for users without a user ID. "@all" and
+// known user IDs pass through unchanged. Unknown names are reported as an error.
+func (d *Directory) ResolveMentions(mentions []string) (userIDs, mobiles []string, err error) {
+	var unknown []string
+	seen := make(map[string]bool)
+	add := func(user *DirectoryUser) {
+		if user.UserID != "" {
+			if !seen["id:"+user.UserID] {
+				seen["id:"+user.UserID] = true
+				userIDs = append(userIDs, user.UserID)
+			}
+			return
+		}
+		if !seen["mobile:"+user.Mobile] {
+			seen["mobile:"+user.Mobile] = true
+			mobiles = append(mobiles, user.Mobile)
+		}
+	}
+
+	for _, mention := range mentions {
+		if mention == mentionAll {
+			if !seen[mentionAll] {
+				seen[mentionAll] = true
+				userIDs = append(userIDs, mentionAll)
+			}
+			continue
+		}
+
+		key := normalizeMention(mention)
+		if user, ok := d.byKey[key]; ok {
+			add(user)
+			continue
+		}
+		if members, ok := d.teams[key]; ok {
+			for _, user := range members {
+				add(user)
+			}
+			continue
+		}
+		unknown = append(unknown, mention)
+	}
+
+	if len(unknown) > 0 {
+		return nil, nil, fmt.Errorf("unknown mention(s) not found in directory: %s (use lookup_users to find valid names)",
+			strings.Join(unknown, ", "))
+	}
+	return userIDs, mobiles, nil
+}
+
+// Lookup returns users whose name, alias, user ID or team contains query,
+// case-insensitively. An empty query returns every user.
+func (d *Directory) Lookup(query string) []DirectoryUser {
+	query = normalizeMention(query)
+
+	var result []DirectoryUser
+	for _, user := range d.users {
+		candidates := append([]string{user.Name, user.UserID, user.Team}, user.Aliases...)
+		for _, candidate := range candidates {
+			if strings.Contains(strings.ToLower(candidate), query) {
+				result = append(result, user)
+				break
+			}
+		}
+	}
+	return result
+}
+
+// Teams returns the sorted team names in the directory.
+func (d *Directory) Teams() []string {
+	teams := make([]string, 0, len(d.teams))
+	for team := range d.teams {
+		teams = append(teams, team)
+	}
+	sort.Strings(teams)
+	return teams
+}
+
+// handleLookupUsers handles the lookup_users tool call.
+func handleLookupUsers(client any, params map[string]any) (string, error) {
+	c, err := getClient(client)
+	if err != nil {
+		return "", err
+	}
+	if c.Directory == nil {
+		return "", fmt.Errorf("no user directory is configured (set directory_file)")
+	}
+
+	query := stringParam(params, "query")
+	users := c.Directory.Lookup(query)
+	if len(users) == 0 {
+		return fmt.Sprintf("No users match %q. Known teams: %s", query, strings.Join(c.Directory.Teams(), ", ")), nil
+	}
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "Found %d user(s):\n", len(users))
+	for _, user := range users {
+		fmt.Fprintf(&sb, "- %s", user.Name)
+		if len(user.Aliases) > 0 {
+			fmt.Fprintf(&sb, " (aliases: %s)", strings.Join(user.Aliases, ", "))
+		}
+		if user.UserID != "" {
+			fmt.Fprintf(&sb, ", userid: %s", user.UserID)
+		}
+		if user.Mobile != "" {
+			fmt.Fprintf(&sb, ", mobile: %s", user.Mobile)
+		}
+		if user.Team != "" {
+			fmt.Fprintf(&sb, ", team: @%s", user.Team)
+		}
+		sb.WriteString("\n")
+	}
+	return strings.TrimRight(sb.String(), "\n"), nil
+}
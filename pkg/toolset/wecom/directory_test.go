@@ -0,0 +1,165 @@
+package wecom
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+const testDirectoryYAML = `users:
+  - name: Zhang San
+    aliases: [san, zs]
+    userid: zhangsan
+    mobile: "13800138000"
+    team: backend-oncall
+  - name: Li Si
+    userid: lisi
+    team: backend-oncall
+  - name: Wang Wu
+    mobile: "13900139000"
+    team: frontend
+`
+
+func writeDirectoryFile(t *testing.T, name, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatalf("failed to write directory file: %v", err)
+	}
+	return path
+}
+
+func loadTestDirectory(t *testing.T) *Directory {
+	t.Helper()
+	d, err := LoadDirectory(writeDirectoryFile(t, "directory.yaml", testDirectoryYAML))
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	return d
+}
+
+func TestLoadDirectory_CSV(t *testing.T) {
+	path := writeDirectoryFile(t, "directory.csv", "name,aliases,userid,mobile,team\n"+
+		"Zhang San,san|zs,zhangsan,13800138000,backend-oncall\n"+
+		"Wang Wu,,,13900139000,frontend\n")
+	d, err := LoadDirectory(path)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	ids, mobiles, err := d.ResolveMentions([]string{"zs", "Wang Wu"})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if !slices.Equal(ids, []string{"zhangsan"}) || !slices.Equal(mobiles, []string{"13900139000"}) {
+		t.Fatalf("unexpected resolution: ids=%v mobiles=%v", ids, mobiles)
+	}
+}
+
+func TestLoadDirectory_UnsupportedFormat(t *testing.T) {
+	_, err := LoadDirectory(writeDirectoryFile(t, "directory.json", "{}"))
+	if err == nil || !strings.Contains(err.Error(), "unsupported directory file format") {
+		t.Fatalf("expected unsupported format error, got %v", err)
+	}
+}
+
+func TestNewDirectory_AmbiguousAlias(t *testing.T) {
+	_, err := NewDirectory([]DirectoryUser{
+		{Name: "Zhang San", Aliases: []string{"zs"}, UserID: "zhangsan"},
+		{Name: "Zhao Si", Aliases: []string{"ZS"}, UserID: "zhaosi"},
+	})
+	if err == nil || !strings.Contains(err.Error(), "ambiguous") {
+		t.Fatalf("expected ambiguity error, got %v", err)
+	}
+}
+
+func TestNewDirectory_MissingContact(t *testing.T) {
+	_, err := NewDirectory([]DirectoryUser{{Name: "Nobody"}})
+	if err == nil || !strings.Contains(err.Error(), "needs a userid or mobile") {
+		t.Fatalf("expected missing contact error, got %v", err)
+	}
+}
+
+func TestResolveMentions_NamesAliasesAndIDs(t *testing.T) {
+	d := loadTestDirectory(t)
+	ids, mobiles, err := d.ResolveMentions([]string{"zhang san", "@san", "lisi", "@all"})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if !slices.Equal(ids, []string{"zhangsan", "lisi", "@all"}) {
+		t.Fatalf("unexpected user IDs: %v", ids)
+	}
+	if len(mobiles) != 0 {
+		t.Fatalf("expected no mobiles, got %v", mobiles)
+	}
+}
+
+func TestResolveMentions_Team(t *testing.T) {
+	d := loadTestDirectory(t)
+	ids, mobiles, err := d.ResolveMentions([]string{"@backend-oncall", "@frontend"})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if !slices.Equal(ids, []string{"zhangsan", "lisi"}) || !slices.Equal(mobiles, []string{"13900139000"}) {
+		t.Fatalf("unexpected resolution: ids=%v mobiles=%v", ids, mobiles)
+	}
+}
+
+func TestResolveMentions_Unknown(t *testing.T) {
+	d := loadTestDirectory(t)
+	_, _, err := d.ResolveMentions([]string{"san", "Nobody", "@ghost-team"})
+	if err == nil || !strings.Contains(err.Error(), "Nobody, @ghost-team") {
+		t.Fatalf("expected unknown mentions to be reported, got %v", err)
+	}
+}
+
+func TestDirectoryLookup(t *testing.T) {
+	d := loadTestDirectory(t)
+	if got := d.Lookup("backend"); len(got) != 2 {
+		t.Fatalf("expected 2 backend users, got %d", len(got))
+	}
+	if got := d.Lookup("@ZS"); len(got) != 1 || got[0].UserID != "zhangsan" {
+		t.Fatalf("expected alias lookup to find zhangsan, got %v", got)
+	}
+	if got := d.Lookup(""); len(got) != 3 {
+		t.Fatalf("expected empty query to list everyone, got %d", len(got))
+	}
+}
+
+func TestHandleLookupUsers_NoDirectory(t *testing.T) {
+	_, err := handleLookupUsers(newTestBot(t), map[string]any{"query": "san"})
+	if err == nil || !strings.Contains(err.Error(), "no user directory") {
+		t.Fatalf("expected no directory error, got %v", err)
+	}
+}
+
+func TestHandleLookupUsers(t *testing.T) {
+	c := NewClient(newTestBot(t))
+	c.Directory = loadTestDirectory(t)
+
+	result, err := handleLookupUsers(c, map[string]any{"query": "san"})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if !strings.Contains(result, "userid: zhangsan") || !strings.Contains(result, "team: @backend-oncall") {
+		t.Fatalf("unexpected result: %q", result)
+	}
+
+	result, err = handleLookupUsers(c, map[string]any{"query": "nobody"})
+	if err != nil || !strings.Contains(result, "Known teams: backend-oncall, frontend") {
+		t.Fatalf("expected known teams in empty result, got %q, %v", result, err)
+	}
+}
+
+func TestHandleSendText_UnknownMention(t *testing.T) {
+	c := NewClient(newTestBot(t))
+	c.Directory = loadTestDirectory(t)
+	_, err := handleSendText(c, map[string]any{
+		"content":        "hello",
+		"mentioned_list": []any{"Nobody"},
+	})
+	if err == nil || !strings.Contains(err.Error(), "unknown mention") {
+		t.Fatalf("expected unknown mention error, got %v", err)
+	}
+}
@@ -0,0 +1,297 @@
+package wecom
+
+import (
+	"crypto/md5"
+	"encoding/base64"
+	"encoding/hex"
+	"strings"
+	"testing"
+
+	"github.com/futuretea/wecom-bot-mcp-server/pkg/fakewecom"
+	"github.com/futuretea/wecom-bot-mcp-server/pkg/wecomapi"
+)
+
+// These tests send messages through the handlers to a fake WeCom server and
+// check what the server received.
+
+// lastMessage returns the only message received by the fake server
+func lastMessage(t *testing.T, fake *fakewecom.Server) fakewecom.Message {
+	t.Helper()
+	messages := fake.Messages()
+	if len(messages) != 1 {
+		t.Fatalf("expected 1 message, got %d: %+v", len(messages), messages)
+	}
+	return messages[0]
+}
+
+// messageBody returns the msgtype-specific object of a received message
+func messageBody(msg fakewecom.Message) map[string]any {
+	body, _ := msg.Payload[msg.MsgType].(map[string]any)
+	return body
+}
+
+func TestE2E_SendText(t *testing.T) {
+	bot, fake := newFakeBot(t)
+	result, err := handleSendText(bot, map[string]any{
+		"content":               "Deploy finished",
+		"mentioned_list":        []any{"zhangsan", "@all"},
+		"mentioned_mobile_list": []any{"13800001111"},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result != "Text message sent successfully" {
+		t.Fatalf("unexpected result: %q", result)
+	}
+
+	msg := lastMessage(t, fake)
+	body := messageBody(msg)
+	if msg.Key != testBotKey || msg.MsgType != "text" || body["content"] != "Deploy finished" {
+		t.Fatalf("unexpected message: %+v", msg)
+	}
+	if mentions, _ := body["mentioned_list"].([]any); len(mentions) != 2 || mentions[1] != "@all" {
+		t.Fatalf("unexpected mentions: %v", body["mentioned_list"])
+	}
+}
+
+func TestE2E_SendMarkdown(t *testing.T) {
+	bot, fake := newFakeBot(t)
+	if _, err := handleSendMarkdown(bot, map[string]any{"content": "# Report\n> all green"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	msg := lastMessage(t, fake)
+	if msg.MsgType != "markdown" || messageBody(msg)["content"] != "# Report\n> all green" {
+		t.Fatalf("unexpected message: %+v", msg)
+	}
+}
+
+func TestE2E_SendImage(t *testing.T) {
+	bot, fake := newFakeBot(t)
+	data := []byte("\x89PNG\r\n\x1a\nfake image data")
+	sum := md5.Sum(data)
+	params := map[string]any{
+		"base64": base64.StdEncoding.EncodeToString(data),
+		"md5":    hex.EncodeToString(sum[:]),
+	}
+	if _, err := handleSendImage(bot, params); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if msg := lastMessage(t, fake); msg.MsgType != "image" {
+		t.Fatalf("unexpected message: %+v", msg)
+	}
+
+	// WeCom rejects images whose checksum does not match
+	params["md5"] = strings.Repeat("0", 32)
+	_, err := handleSendImage(bot, params)
+	if err == nil || !strings.Contains(err.Error(), "40058") {
+		t.Fatalf("expected md5 mismatch error, got %v", err)
+	}
+}
+
+func TestE2E_SendChart(t *testing.T) {
+	bot, fake := newFakeBot(t)
+	result, err := handleSendChart(bot, map[string]any{
+		"type":   "bar",
+		"title":  "Errors per service",
+		"labels": []any{"api", "web"},
+		"series": []any{map[string]any{"name": "errors", "values": []any{3.0, 5.0}}},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result != "Chart image sent successfully" {
+		t.Fatalf("unexpected result: %q", result)
+	}
+
+	// The fake server checks the image size and MD5 checksum
+	body := messageBody(lastMessage(t, fake))
+	data, err := base64.StdEncoding.DecodeString(body["base64"].(string))
+	if err != nil || !strings.HasPrefix(string(data), "\x89PNG") {
+		t.Fatalf("expected a PNG image, got %v", err)
+	}
+
+	if _, err := handleSendChart(bot, map[string]any{"type": "pie", "labels": []any{"a"}, "series": "oops"}); err == nil || !strings.Contains(err.Error(), "invalid chart parameters") {
+		t.Fatalf("expected invalid parameters error, got %v", err)
+	}
+	if _, err := handleSendChart(bot, map[string]any{"type": "scatter"}); err == nil || !strings.Contains(err.Error(), "type must be one of") {
+		t.Fatalf("expected invalid type error, got %v", err)
+	}
+}
+
+func TestE2E_SendRendered(t *testing.T) {
+	bot, fake := newFakeBot(t)
+	result, err := handleSendRendered(bot, map[string]any{
+		"markdown": "# Report\n\n| Service | Status |\n|---|---|\n| api | ok |\n\n```go\nfmt.Println(\"hi\")\n```\n",
+		"summary":  "Daily report",
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result != "Rendered image and summary sent successfully" {
+		t.Fatalf("unexpected result: %q", result)
+	}
+
+	messages := fake.Messages()
+	if len(messages) != 2 || messages[0].MsgType != "image" || messages[1].MsgType != "text" {
+		t.Fatalf("expected an image followed by a text message, got %+v", messages)
+	}
+	data, err := base64.StdEncoding.DecodeString(messageBody(messages[0])["base64"].(string))
+	if err != nil || !strings.HasPrefix(string(data), "\x89PNG") {
+		t.Fatalf("expected a PNG image, got %v", err)
+	}
+	if content := messageBody(messages[1])["content"]; content != "Daily report" {
+		t.Fatalf("unexpected summary %v", content)
+	}
+
+	if _, err := handleSendRendered(bot, map[string]any{"markdown": "x", "width": 100.0}); err == nil || !strings.Contains(err.Error(), "width must be between") {
+		t.Fatalf("expected width error, got %v", err)
+	}
+	if _, err := handleSendRendered(bot, map[string]any{}); err == nil || !strings.Contains(err.Error(), "markdown is required") {
+		t.Fatalf("expected missing markdown error, got %v", err)
+	}
+}
+
+func TestE2E_SendNews(t *testing.T) {
+	bot, fake := newFakeBot(t)
+	result, err := handleSendNews(bot, map[string]any{
+		"articles": []any{
+			map[string]any{"title": "Release v1.2", "url": "https://example.com/v1.2"},
+			map[string]any{"title": "Changelog", "url": "https://example.com/changelog", "description": "What changed"},
+		},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !strings.Contains(result, "2 article(s)") {
+		t.Fatalf("unexpected result: %q", result)
+	}
+	articles, _ := messageBody(lastMessage(t, fake))["articles"].([]any)
+	if len(articles) != 2 {
+		t.Fatalf("expected 2 articles, got %v", articles)
+	}
+}
+
+func TestE2E_SendTextNoticeCard(t *testing.T) {
+	bot, fake := newFakeBot(t)
+	_, err := handleSendTextNoticeCard(bot, map[string]any{
+		"main_title":  "Build failed",
+		"card_action": map[string]any{"url": "https://ci.example.com/42"},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	msg := lastMessage(t, fake)
+	if msg.MsgType != "template_card" || messageBody(msg)["card_type"] != "text_notice" {
+		t.Fatalf("unexpected message: %+v", msg)
+	}
+}
+
+func TestE2E_UploadFile(t *testing.T) {
+	bot, fake := newFakeBot(t)
+	result, err := handleUploadFile(bot, map[string]any{
+		"filename":    "report.csv",
+		"base64_data": base64.StdEncoding.EncodeToString([]byte("name,value\nuptime,99.9\n")),
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	uploads := fake.Uploads()
+	if len(uploads) != 1 || uploads[0].Filename != "report.csv" || uploads[0].Type != "file" {
+		t.Fatalf("unexpected uploads: %+v", uploads)
+	}
+	if !strings.Contains(result, "media_id: "+uploads[0].MediaID) {
+		t.Fatalf("expected media_id in result, got %q", result)
+	}
+
+	// Files smaller than WeCom's minimum size are rejected
+	_, err = handleUploadFile(bot, map[string]any{
+		"filename":    "tiny.txt",
+		"base64_data": base64.StdEncoding.EncodeToString([]byte("hi")),
+	})
+	if err == nil || !strings.Contains(err.Error(), "40006") {
+		t.Fatalf("expected media size error, got %v", err)
+	}
+}
+
+func TestE2E_SendFile(t *testing.T) {
+	bot, fake := newFakeBot(t)
+	result, err := handleSendFile(bot, map[string]any{
+		"filename":    "report.csv",
+		"base64_data": base64.StdEncoding.EncodeToString([]byte("name,value\nuptime,99.9\n")),
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	uploads := fake.Uploads()
+	if len(uploads) != 1 || uploads[0].Filename != "report.csv" {
+		t.Fatalf("expected the file to be uploaded first, got %+v", uploads)
+	}
+	msg := lastMessage(t, fake)
+	if msg.MsgType != "file" || messageBody(msg)["media_id"] != uploads[0].MediaID {
+		t.Fatalf("unexpected message: %+v", msg)
+	}
+	if result != "File message sent successfully. media_id: "+uploads[0].MediaID {
+		t.Fatalf("unexpected result: %q", result)
+	}
+
+	// An earlier upload is sent by its media_id without uploading it again
+	if _, err := handleSendFile(bot, map[string]any{"media_id": uploads[0].MediaID}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if messages := fake.Messages(); len(messages) != 2 || len(fake.Uploads()) != 1 || messageBody(messages[1])["media_id"] != uploads[0].MediaID {
+		t.Fatalf("unexpected messages: %+v", messages)
+	}
+	if _, err := handleSendFile(bot, map[string]any{"media_id": "unknown"}); err == nil || !strings.Contains(err.Error(), "40007") {
+		t.Fatalf("expected an invalid media_id error, got %v", err)
+	}
+}
+
+func TestHandleSendFile_InvalidParams(t *testing.T) {
+	bot := newTestBot(t)
+	tests := []struct {
+		name   string
+		params map[string]any
+		want   string
+	}{
+		{"none", map[string]any{}, "is required"},
+		{"both", map[string]any{"media_id": "m1", "base64_data": "aGk="}, "mutually exclusive"},
+		{"no filename", map[string]any{"base64_data": "aGk="}, "filename is required"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := handleSendFile(bot, tt.params); err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Fatalf("expected an error containing %q, got %v", tt.want, err)
+			}
+		})
+	}
+}
+
+func TestE2E_InvalidKey(t *testing.T) {
+	api, fake := newFakeWeCom(t)
+	bot := api.Bot("00000000-0000-0000-0000-000000000000")
+	_, err := handleSendText(bot, map[string]any{"content": "hello"})
+	if err == nil || !strings.Contains(err.Error(), "93000") {
+		t.Fatalf("expected invalid key error, got %v", err)
+	}
+	if len(fake.Messages()) != 0 {
+		t.Fatal("expected no message to be recorded")
+	}
+}
+
+func TestE2E_Throttled(t *testing.T) {
+	bot, fake := newFakeBot(t)
+	fake.FailNext(wecomapi.ErrCodeRateLimited)
+
+	_, err := handleSendText(bot, map[string]any{"content": "first"})
+	if err == nil || !strings.Contains(err.Error(), "45009") {
+		t.Fatalf("expected throttling error, got %v", err)
+	}
+	if _, err := handleSendText(bot, map[string]any{"content": "second"}); err != nil {
+		t.Fatalf("expected the next message to succeed, got %v", err)
+	}
+	if msg := lastMessage(t, fake); messageBody(msg)["content"] != "second" {
+		t.Fatalf("unexpected message: %+v", msg)
+	}
+}
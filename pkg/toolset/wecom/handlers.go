@@ -1,15 +1,26 @@
 package wecom
 
 import (
+	"crypto/md5"
 	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
 	"fmt"
+	"math"
+	"slices"
+	"strings"
+	"time"
 
-	wecombot "github.com/futuretea/go-wecom-bot"
 	"github.com/futuretea/go-wecom-bot/image"
 	"github.com/futuretea/go-wecom-bot/markdown"
 	"github.com/futuretea/go-wecom-bot/news"
 	"github.com/futuretea/go-wecom-bot/templatecard"
 	"github.com/futuretea/go-wecom-bot/text"
+
+	"github.com/futuretea/wecom-bot-mcp-server/pkg/chart"
+	"github.com/futuretea/wecom-bot-mcp-server/pkg/core/logging"
+	"github.com/futuretea/wecom-bot-mcp-server/pkg/render"
+	"github.com/futuretea/wecom-bot-mcp-server/pkg/wecomapi"
 )
 
 // WeCom API limits
@@ -18,18 +29,19 @@ const (
 	maxMarkdownContentBytes = 4096
 	maxNewsArticles         = 8
 	maxUploadFileBytes      = 20 * 1024 * 1024 // 20MB
+	maxImageBytes           = 2 * 1024 * 1024  // 2MB
 
 	// defaultCardImageAspectRatio is the default aspect ratio for news notice card images.
 	defaultCardImageAspectRatio = 2.35
 )
 
 // getBot validates and returns the WeCom bot client from the generic client.
-func getBot(client any) (*wecombot.Bot, error) {
-	bot, ok := client.(*wecombot.Bot)
-	if !ok || bot == nil {
-		return nil, fmt.Errorf("weCom bot client is not configured")
+func getBot(client any) (*wecomapi.Bot, error) {
+	c, err := getClient(client)
+	if err != nil {
+		return nil, err
 	}
-	return bot, nil
+	return c.Bot, nil
 }
 
 // stringParam extracts a string parameter from the params map.
@@ -42,6 +54,24 @@ func stringParam(params map[string]any, key string) string {
 	return str
 }
 
+// intParam extracts an integer parameter from the params map. It reports
+// false when the parameter is missing or not a whole number.
+func intParam(params map[string]any, key string) (int, bool) {
+	switch value := params[key].(type) {
+	case float64:
+		if value != math.Trunc(value) || math.Abs(value) > math.MaxInt32 {
+			return 0, false
+		}
+		return int(value), true
+	case int:
+		return value, true
+	case int64:
+		return int(value), true
+	default:
+		return 0, false
+	}
+}
+
 // stringSliceParam extracts a string slice parameter from the params map.
 func stringSliceParam(params map[string]any, key string) []string {
 	value, exists := params[key]
@@ -120,7 +150,7 @@ func parseCardActionURL(params map[string]any) (string, error) {
 
 // handleSendText handles the send_text tool call.
 func handleSendText(client any, params map[string]any) (string, error) {
-	bot, err := getBot(client)
+	c, err := getClient(client)
 	if err != nil {
 		return "", err
 	}
@@ -133,26 +163,37 @@ func handleSendText(client any, params map[string]any) (string, error) {
 		return "", fmt.Errorf("content exceeds maximum size of %d bytes", maxTextContentBytes)
 	}
 
+	mentionedList := stringSliceParam(params, "mentioned_list")
+	mentionedMobileList := stringSliceParam(params, "mentioned_mobile_list")
+
+	// Resolve names, aliases and teams through the directory when configured
+	mentionedList, mobiles, err := c.resolveMentions(mentionedList)
+	if err != nil {
+		return "", err
+	}
+	mentionedMobileList = append(mentionedMobileList, mobiles...)
+
 	msg := text.New(content)
 
 	// Add mentions if specified
-	if mentionedList := stringSliceParam(params, "mentioned_list"); len(mentionedList) > 0 {
+	if len(mentionedList) > 0 {
 		msg.WithMention(mentionedList...)
 	}
-	if mentionedMobileList := stringSliceParam(params, "mentioned_mobile_list"); len(mentionedMobileList) > 0 {
+	if len(mentionedMobileList) > 0 {
 		msg.WithMentionMobile(mentionedMobileList...)
 	}
 
-	if err := bot.Send(msg); err != nil {
-		return "", fmt.Errorf("failed to send text message: %w", err)
-	}
-
-	return "Text message sent successfully", nil
+	return c.deliver(outgoingMessage{
+		kind:    "text message",
+		payload: msg,
+		send:    func() error { return c.Bot.Send(msg) },
+		success: "Text message sent successfully",
+	})
 }
 
 // handleSendMarkdown handles the send_markdown tool call.
 func handleSendMarkdown(client any, params map[string]any) (string, error) {
-	bot, err := getBot(client)
+	c, err := getClient(client)
 	if err != nil {
 		return "", err
 	}
@@ -165,17 +206,36 @@ func handleSendMarkdown(client any, params map[string]any) (string, error) {
 		return "", fmt.Errorf("content exceeds maximum size of %d bytes", maxMarkdownContentBytes)
 	}
 
-	msg := markdown.New(content)
-	if err := bot.Send(msg); err != nil {
-		return "", fmt.Errorf("failed to send markdown message: %w", err)
+	// Markdown messages can only mention user IDs, using the <@userid> syntax
+	userIDs, mobiles, err := c.resolveMentions(stringSliceParam(params, "mentioned_list"))
+	if err != nil {
+		return "", err
+	}
+	if len(mobiles) > 0 {
+		return "", fmt.Errorf("markdown messages can only mention user IDs; no user ID is known for mobile(s): %s", strings.Join(mobiles, ", "))
+	}
+	if slices.Contains(userIDs, mentionAll) {
+		return "", fmt.Errorf("markdown messages cannot mention %s; use send_text to notify everyone", mentionAll)
+	}
+	if len(userIDs) > 0 {
+		content = appendMarkdownMentions(content, userIDs)
+		if len(content) > maxMarkdownContentBytes {
+			return "", fmt.Errorf("content with mentions exceeds maximum size of %d bytes", maxMarkdownContentBytes)
+		}
 	}
 
-	return "Markdown message sent successfully", nil
+	msg := markdown.New(content)
+	return c.deliver(outgoingMessage{
+		kind:    "markdown message",
+		payload: msg,
+		send:    func() error { return c.Bot.Send(msg) },
+		success: "Markdown message sent successfully",
+	})
 }
 
 // handleSendImage handles the send_image tool call.
 func handleSendImage(client any, params map[string]any) (string, error) {
-	bot, err := getBot(client)
+	c, err := getClient(client)
 	if err != nil {
 		return "", err
 	}
@@ -191,16 +251,113 @@ func handleSendImage(client any, params map[string]any) (string, error) {
 	}
 
 	msg := image.New(encodedImage, md5Hash)
-	if err := bot.Send(msg); err != nil {
-		return "", fmt.Errorf("failed to send image message: %w", err)
+	return c.deliver(outgoingMessage{
+		kind:    "image message",
+		payload: msg,
+		send:    func() error { return c.Bot.Send(msg) },
+		success: "Image message sent successfully",
+	})
+}
+
+// handleSendChart handles the send_chart tool call.
+func handleSendChart(client any, params map[string]any) (string, error) {
+	c, err := getClient(client)
+	if err != nil {
+		return "", err
+	}
+
+	// The tool parameters mirror the chart spec, so decode them into it directly
+	encoded, err := json.Marshal(params)
+	if err != nil {
+		return "", fmt.Errorf("invalid chart parameters: %w", err)
+	}
+	var spec chart.Spec
+	if err := json.Unmarshal(encoded, &spec); err != nil {
+		return "", fmt.Errorf("invalid chart parameters: %w", err)
+	}
+
+	data, err := chart.Render(spec)
+	if err != nil {
+		return "", err
+	}
+	if len(data) > maxImageBytes {
+		return "", fmt.Errorf("rendered chart is %d bytes, more than the maximum image size of %d bytes; use a smaller size", len(data), maxImageBytes)
+	}
+
+	sum := md5.Sum(data)
+	msg := image.New(base64.StdEncoding.EncodeToString(data), hex.EncodeToString(sum[:]))
+	return c.deliver(outgoingMessage{
+		kind:    spec.Type + " chart image",
+		payload: msg,
+		send:    func() error { return c.Bot.Send(msg) },
+		success: "Chart image sent successfully",
+	})
+}
+
+// handleSendRendered handles the send_rendered tool call. The markdown is
+// rendered to an image, which is sent together with the optional summary as
+// one delivery, so that both are confirmed and deferred together.
+func handleSendRendered(client any, params map[string]any) (string, error) {
+	c, err := getClient(client)
+	if err != nil {
+		return "", err
+	}
+
+	source := stringParam(params, "markdown")
+	if source == "" {
+		return "", fmt.Errorf("markdown is required")
+	}
+	width := 0
+	if _, exists := params["width"]; exists {
+		var ok bool
+		if width, ok = intParam(params, "width"); !ok {
+			return "", fmt.Errorf("width must be a whole number")
+		}
+	}
+	summary := stringParam(params, "summary")
+	if len(summary) > maxTextContentBytes {
+		return "", fmt.Errorf("summary exceeds maximum size of %d bytes", maxTextContentBytes)
+	}
+
+	data, err := render.Markdown(source, width)
+	if err != nil {
+		return "", err
+	}
+	if len(data) > maxImageBytes {
+		return "", fmt.Errorf("rendered image is %d bytes, more than the maximum image size of %d bytes; split the content into smaller parts", len(data), maxImageBytes)
 	}
 
-	return "Image message sent successfully", nil
+	sum := md5.Sum(data)
+	msg := image.New(base64.StdEncoding.EncodeToString(data), hex.EncodeToString(sum[:]))
+	if summary == "" {
+		return c.deliver(outgoingMessage{
+			kind:    "rendered image",
+			payload: msg,
+			send:    func() error { return c.Bot.Send(msg) },
+			success: "Rendered image sent successfully",
+		})
+	}
+
+	summaryMsg := text.New(summary)
+	return c.deliver(outgoingMessage{
+		kind:    "rendered image",
+		payload: msg,
+		send: func() error {
+			if err := c.Bot.Send(msg); err != nil {
+				return err
+			}
+			if err := c.Bot.Send(summaryMsg); err != nil {
+				return fmt.Errorf("image sent, but the summary failed: %w", err)
+			}
+			return nil
+		},
+		success: "Rendered image and summary sent successfully",
+	})
 }
 
 // handleSendNews handles the send_news tool call.
 func handleSendNews(client any, params map[string]any) (string, error) {
-	bot, err := getBot(client)
+	c, err := getClient(client)
 	if err != nil {
 		return "", err
 	}
@@ -214,7 +371,8 @@ func handleSendNews(client any, params map[string]any) (string, error) {
 	}
 
 	msg := news.New()
-	for _, article := range articles {
+	urls := make([]urlField, 0, 2*len(articles))
+	for i, article := range articles {
 		title := stringParam(article, "title")
 		if title == "" {
 			return "", fmt.Errorf("each article must have a title")
@@ -223,19 +381,28 @@ func handleSendNews(client any, params map[string]any) (string, error) {
 		if url == "" {
 			return "", fmt.Errorf("each article must have a url")
 		}
-		msg.AddArticle(title, stringParam(article, "description"), url, stringParam(article, "picurl"))
-	}
-
-	if err := bot.Send(msg); err != nil {
-		return "", fmt.Errorf("failed to send news message: %w", err)
+		picURL := stringParam(article, "picurl")
+		urls = append(urls,
+			urlField{name: fmt.Sprintf("articles[%d].url", i), value: url},
+			urlField{name: fmt.Sprintf("articles[%d].picurl", i), value: picURL},
+		)
+		msg.AddArticle(title, stringParam(article, "description"), url, picURL)
+	}
+	if err := c.URLPolicy.checkURLs(urls...); err != nil {
+		return "", err
 	}
 
-	return fmt.Sprintf("News message sent successfully with %d article(s)", len(articles)), nil
+	return c.deliver(outgoingMessage{
+		kind:    "news message",
+		payload: msg,
+		send:    func() error { return c.Bot.Send(msg) },
+		success: fmt.Sprintf("News message sent successfully with %d article(s)", len(articles)),
+	})
 }
 
 // handleSendTextNoticeCard handles the send_text_notice_card tool call.
 func handleSendTextNoticeCard(client any, params map[string]any) (string, error) {
-	bot, err := getBot(client)
+	c, err := getClient(client)
 	if err != nil {
 		return "", err
 	}
@@ -248,9 +415,11 @@ func handleSendTextNoticeCard(client any, params map[string]any) (string, error)
 	card := templatecard.NewTextNotice().
 		WithMainTitle(mainTitle, stringParam(params, "main_title_desc"))
 
+	var urls []urlField
 	if iconURL, desc, ok := parseSource(params); ok {
 		// Use blue for text notice cards as a default visual distinction
 		card.WithSource(iconURL, desc, templatecard.SourceDescColorBlue)
+		urls = append(urls, urlField{name: "source.icon_url", value: iconURL})
 	}
 
 	if subTitle := stringParam(params, "sub_title"); subTitle != "" {
@@ -265,8 +434,10 @@ func handleSendTextNoticeCard(client any, params map[string]any) (string, error)
 		card.AddHorizontalContent(stringParam(content, "keyname"), stringParam(content, "value"), templatecard.HorizontalContentTypeText)
 	}
 
-	for _, jump := range mapSliceParam(params, "jump_list") {
-		card.AddJump(templatecard.JumpTypeURL, stringParam(jump, "title"), stringParam(jump, "url"))
+	for i, jump := range mapSliceParam(params, "jump_list") {
+		jumpURL := stringParam(jump, "url")
+		card.AddJump(templatecard.JumpTypeURL, stringParam(jump, "title"), jumpURL)
+		urls = append(urls, urlField{name: fmt.Sprintf("jump_list[%d].url", i), value: jumpURL})
 	}
 
 	actionURL, err := parseCardActionURL(params)
@@ -274,17 +445,23 @@ func handleSendTextNoticeCard(client any, params map[string]any) (string, error)
 		return "", err
 	}
 	card.WithCardAction(templatecard.ActionTypeURL, actionURL)
+	urls = append(urls, urlField{name: "card_action.url", value: actionURL})
 
-	if err := bot.Send(card); err != nil {
-		return "", fmt.Errorf("failed to send text notice card: %w", err)
+	if err := c.URLPolicy.checkURLs(urls...); err != nil {
+		return "", err
 	}
 
-	return "Text notice card sent successfully", nil
+	return c.deliver(outgoingMessage{
+		kind:    "text notice card",
+		payload: card,
+		send:    func() error { return c.Bot.Send(card) },
+		success: "Text notice card sent successfully",
+	})
 }
 
 // handleSendNewsNoticeCard handles the send_news_notice_card tool call.
 func handleSendNewsNoticeCard(client any, params map[string]any) (string, error) {
-	bot, err := getBot(client)
+	c, err := getClient(client)
 	if err != nil {
 		return "", err
 	}
@@ -303,9 +480,11 @@ func handleSendNewsNoticeCard(client any, params map[string]any) (string, error)
 		WithMainTitle(mainTitle, stringParam(params, "main_title_desc")).
 		WithCardImage(cardImageURL, defaultCardImageAspectRatio)
 
+	urls := []urlField{{name: "card_image_url", value: cardImageURL}}
 	if iconURL, desc, ok := parseSource(params); ok {
 		// Use green for news notice cards as a default visual distinction
 		card.WithSource(iconURL, desc, templatecard.SourceDescColorGreen)
+		urls = append(urls, urlField{name: "source.icon_url", value: iconURL})
 	}
 
 	actionURL, err := parseCardActionURL(params)
@@ -313,45 +492,178 @@ func handleSendNewsNoticeCard(client any, params map[string]any) (string, error)
 		return "", err
 	}
 	card.WithCardAction(templatecard.ActionTypeURL, actionURL)
+	urls = append(urls, urlField{name: "card_action.url", value: actionURL})
 
-	if err := bot.Send(card); err != nil {
-		return "", fmt.Errorf("failed to send news notice card: %w", err)
+	if err := c.URLPolicy.checkURLs(urls...); err != nil {
+		return "", err
 	}
 
-	return "News notice card sent successfully", nil
+	return c.deliver(outgoingMessage{
+		kind:    "news notice card",
+		payload: card,
+		send:    func() error { return c.Bot.Send(card) },
+		success: "News notice card sent successfully",
+	})
 }
 
 // handleUploadFile handles the upload_file tool call.
 func handleUploadFile(client any, params map[string]any) (string, error) {
-	bot, err := getBot(client)
+	c, err := getClient(client)
 	if err != nil {
 		return "", err
 	}
 
+	filename, data, err := decodeUploadParams(params)
+	if err != nil {
+		return "", err
+	}
+	return c.uploadFile(filename, data)
+}
+
+// uploadFile uploads a file, or reuses the media_id of an identical upload
+// that has not expired yet, and returns the tool result.
+func (c *Client) uploadFile(filename string, data []byte) (string, error) {
+	upload, err := c.upload(filename, data)
+	if err != nil {
+		return "", err
+	}
+
+	switch {
+	case upload.reused:
+		return fmt.Sprintf("File already uploaded, reusing its media_id. media_id: %s, type: %s, expires_at: %s",
+			upload.media.MediaID, upload.media.Type, upload.expiresAt.Format(time.RFC3339)), nil
+	case !upload.expiresAt.IsZero():
+		return fmt.Sprintf("File uploaded successfully. media_id: %s, type: %s, created_at: %s, expires_at: %s",
+			upload.media.MediaID, upload.media.Type, upload.media.CreatedAt, upload.expiresAt.Format(time.RFC3339)), nil
+	}
+	return fmt.Sprintf("File uploaded successfully. media_id: %s, type: %s, created_at: %s",
+		upload.media.MediaID, upload.media.Type, upload.media.CreatedAt), nil
+}
+
+// decodeUploadParams returns the filename and decoded content of the
+// filename and base64_data arguments.
+func decodeUploadParams(params map[string]any) (string, []byte, error) {
 	filename := stringParam(params, "filename")
 	if filename == "" {
-		return "", fmt.Errorf("filename is required")
+		return "", nil, fmt.Errorf("filename is required")
 	}
 
 	base64Data := stringParam(params, "base64_data")
 	if base64Data == "" {
-		return "", fmt.Errorf("base64_data is required")
+		return "", nil, fmt.Errorf("base64_data is required")
 	}
 
 	// Decode base64 data
 	data, err := base64.StdEncoding.DecodeString(base64Data)
 	if err != nil {
-		return "", fmt.Errorf("failed to decode base64 data: %w", err)
+		return "", nil, fmt.Errorf("failed to decode base64 data: %w", err)
 	}
 	if len(data) > maxUploadFileBytes {
-		return "", fmt.Errorf("file size exceeds maximum of %d bytes", maxUploadFileBytes)
+		return "", nil, fmt.Errorf("file size exceeds maximum of %d bytes", maxUploadFileBytes)
+	}
+	return filename, data, nil
+}
+
+// mediaUpload is an uploaded file and whether an earlier upload was reused.
+type mediaUpload struct {
+	media wecomapi.Media
+	// expiresAt is zero when no media registry tracks the upload.
+	expiresAt time.Time
+	reused    bool
+}
+
+// upload uploads a file, or reuses the media_id of an identical upload that
+// has not expired yet.
+func (c *Client) upload(filename string, data []byte) (mediaUpload, error) {
+	if c.Media != nil {
+		if record, ok := c.Media.Lookup(filename, data); ok {
+			return mediaUpload{
+				media:     wecomapi.Media{Type: record.Type, MediaID: record.MediaID},
+				expiresAt: record.ExpiresAt,
+				reused:    true,
+			}, nil
+		}
 	}
 
-	media, err := bot.UploadMedia(filename, data)
+	media, err := c.Bot.UploadMedia(filename, data)
 	if err != nil {
-		return "", fmt.Errorf("failed to upload file: %w", err)
+		return mediaUpload{}, fmt.Errorf("failed to upload file: %w", err)
 	}
 
-	return fmt.Sprintf("File uploaded successfully. media_id: %s, type: %s, created_at: %s",
-		media.MediaID, media.Type, media.CreatedAt), nil
+	upload := mediaUpload{media: *media}
+	if c.Media != nil {
+		record, err := c.Media.Add(filename, data, media)
+		if err != nil {
+			logging.Warn("Failed to record uploaded file %s: %v", filename, err)
+		}
+		upload.expiresAt = record.ExpiresAt
+	}
+	return upload, nil
+}
+
+// fileMessage is a file message, which the go-wecom-bot packages do not provide.
+type fileMessage struct {
+	MsgType string `json:"msgtype"`
+	File    struct {
+		MediaID string `json:"media_id"`
+	} `json:"file"`
+}
+
+// fileUploadPreview describes the file a send_file call uploads once the
+// message is approved, since its media_id does not exist before.
+type fileUploadPreview struct {
+	MsgType string `json:"msgtype"`
+	File    struct {
+		Filename string `json:"filename"`
+		Size     int    `json:"size"`
+		SHA256   string `json:"sha256"`
+	} `json:"file"`
+}
+
+// handleSendFile handles the send_file tool call. The file is given either by
+// the media_id of an earlier upload or by its content, which is uploaded when
+// the message is sent.
+func handleSendFile(client any, params map[string]any) (string, error) {
+	c, err := getClient(client)
+	if err != nil {
+		return "", err
+	}
+
+	msg := &fileMessage{MsgType: "file"}
+	msg.File.MediaID = stringParam(params, "media_id")
+	out := outgoingMessage{
+		kind:    "file message",
+		payload: msg,
+		send:    func() error { return c.Bot.Send(msg) },
+		result: func() string {
+			return fmt.Sprintf("File message sent successfully. media_id: %s", msg.File.MediaID)
+		},
+	}
+
+	_, hasData := params["base64_data"]
+	switch {
+	case msg.File.MediaID != "" && hasData:
+		return "", fmt.Errorf("media_id and base64_data are mutually exclusive")
+	case msg.File.MediaID == "" && !hasData:
+		return "", fmt.Errorf("media_id, or filename and base64_data, is required")
+	case hasData:
+		filename, data, err := decodeUploadParams(params)
+		if err != nil {
+			return "", err
+		}
+		preview := &fileUploadPreview{MsgType: "file"}
+		preview.File.Filename = filename
+		preview.File.Size = len(data)
+		preview.File.SHA256 = sha256Hex(data)
+		out.preview = preview
+		out.send = func() error {
+			upload, err := c.upload(filename, data)
+			if err != nil {
+				return err
+			}
+			msg.File.MediaID = upload.media.MediaID
+			return c.Bot.Send(msg)
+		}
+	}
+	return c.deliver(out)
 }
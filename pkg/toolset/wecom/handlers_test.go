@@ -2,16 +2,45 @@ package wecom
 
 import (
 	"encoding/base64"
+	"net/http/httptest"
 	"strings"
 	"testing"
 
-	wecombot "github.com/futuretea/go-wecom-bot"
+	"github.com/futuretea/wecom-bot-mcp-server/pkg/fakewecom"
+	"github.com/futuretea/wecom-bot-mcp-server/pkg/wecomapi"
 )
 
+// testBotKey is the webhook key accepted by the fake WeCom server in tests
+const testBotKey = "693a91f6-7abc-4bc4-97a0-0ec2cafe5aaa"
+
+// newFakeWeCom starts a fake WeCom server that accepts testBotKey and returns
+// an API client for it, along with the server to inspect received messages
+func newFakeWeCom(t *testing.T) (*wecomapi.Client, *fakewecom.Server) {
+	t.Helper()
+	fake := fakewecom.New(fakewecom.Options{Keys: []string{testBotKey}, RateLimit: -1})
+	server := httptest.NewServer(fake)
+	t.Cleanup(server.Close)
+	return wecomapi.NewClient(server.URL, server.Client()), fake
+}
+
+// newFakeBot returns a bot that sends to a fake WeCom server
+func newFakeBot(t *testing.T) (*wecomapi.Bot, *fakewecom.Server) {
+	t.Helper()
+	api, fake := newFakeWeCom(t)
+	return api.Bot(testBotKey), fake
+}
+
+// newTestBot returns a bot that sends to a fake WeCom server
+func newTestBot(t *testing.T) *wecomapi.Bot {
+	t.Helper()
+	bot, _ := newFakeBot(t)
+	return bot
+}
+
 // --- getBot tests ---
 
 func TestGetBot_Valid(t *testing.T) {
-	bot := wecombot.New("test-key")
+	bot := newTestBot(t)
 	result, err := getBot(bot)
 	if err != nil {
 		t.Fatalf("expected no error, got %v", err)
@@ -198,7 +227,7 @@ func TestParseCardActionURL_MissingURL(t *testing.T) {
 // These test parameter validation only; they do not call the WeCom API.
 
 func TestHandleSendText_EmptyContent(t *testing.T) {
-	bot := wecombot.New("test-key")
+	bot := newTestBot(t)
 	_, err := handleSendText(bot, map[string]any{})
 	if err == nil || !strings.Contains(err.Error(), "content is required") {
 		t.Fatalf("expected 'content is required' error, got %v", err)
@@ -206,7 +235,7 @@ func TestHandleSendText_EmptyContent(t *testing.T) {
 }
 
 func TestHandleSendText_ContentTooLong(t *testing.T) {
-	bot := wecombot.New("test-key")
+	bot := newTestBot(t)
 	longContent := strings.Repeat("a", maxTextContentBytes+1)
 	_, err := handleSendText(bot, map[string]any{"content": longContent})
 	if err == nil || !strings.Contains(err.Error(), "exceeds maximum size") {
@@ -222,7 +251,7 @@ func TestHandleSendText_InvalidClient(t *testing.T) {
 }
 
 func TestHandleSendMarkdown_EmptyContent(t *testing.T) {
-	bot := wecombot.New("test-key")
+	bot := newTestBot(t)
 	_, err := handleSendMarkdown(bot, map[string]any{})
 	if err == nil || !strings.Contains(err.Error(), "content is required") {
 		t.Fatalf("expected 'content is required' error, got %v", err)
@@ -230,7 +259,7 @@ func TestHandleSendMarkdown_EmptyContent(t *testing.T) {
 }
 
 func TestHandleSendMarkdown_ContentTooLong(t *testing.T) {
-	bot := wecombot.New("test-key")
+	bot := newTestBot(t)
 	longContent := strings.Repeat("a", maxMarkdownContentBytes+1)
 	_, err := handleSendMarkdown(bot, map[string]any{"content": longContent})
 	if err == nil || !strings.Contains(err.Error(), "exceeds maximum size") {
@@ -239,7 +268,7 @@ func TestHandleSendMarkdown_ContentTooLong(t *testing.T) {
 }
 
 func TestHandleSendImage_MissingParams(t *testing.T) {
-	bot := wecombot.New("test-key")
+	bot := newTestBot(t)
 	_, err := handleSendImage(bot, map[string]any{})
 	if err == nil || !strings.Contains(err.Error(), "base64 is required") {
 		t.Fatalf("expected 'base64 is required' error, got %v", err)
@@ -252,7 +281,7 @@ func TestHandleSendImage_MissingParams(t *testing.T) {
 }
 
 func TestHandleSendNews_EmptyArticles(t *testing.T) {
-	bot := wecombot.New("test-key")
+	bot := newTestBot(t)
 	_, err := handleSendNews(bot, map[string]any{})
 	if err == nil || !strings.Contains(err.Error(), "articles is required") {
 		t.Fatalf("expected 'articles is required' error, got %v", err)
@@ -260,7 +289,7 @@ func TestHandleSendNews_EmptyArticles(t *testing.T) {
 }
 
 func TestHandleSendNews_TooManyArticles(t *testing.T) {
-	bot := wecombot.New("test-key")
+	bot := newTestBot(t)
 	articles := make([]any, maxNewsArticles+1)
 	for i := range articles {
 		articles[i] = map[string]any{"title": "t", "url": "u"}
@@ -272,7 +301,7 @@ func TestHandleSendNews_TooManyArticles(t *testing.T) {
 }
 
 func TestHandleSendNews_ArticleMissingTitle(t *testing.T) {
-	bot := wecombot.New("test-key")
+	bot := newTestBot(t)
 	articles := []any{map[string]any{"url": "https://example.com"}}
 	_, err := handleSendNews(bot, map[string]any{"articles": articles})
 	if err == nil || !strings.Contains(err.Error(), "must have a title") {
@@ -281,7 +310,7 @@ func TestHandleSendNews_ArticleMissingTitle(t *testing.T) {
 }
 
 func TestHandleSendNews_ArticleMissingURL(t *testing.T) {
-	bot := wecombot.New("test-key")
+	bot := newTestBot(t)
 	articles := []any{map[string]any{"title": "Test"}}
 	_, err := handleSendNews(bot, map[string]any{"articles": articles})
 	if err == nil || !strings.Contains(err.Error(), "must have a url") {
@@ -290,7 +319,7 @@ func TestHandleSendNews_ArticleMissingURL(t *testing.T) {
 }
 
 func TestHandleSendTextNoticeCard_MissingTitle(t *testing.T) {
-	bot := wecombot.New("test-key")
+	bot := newTestBot(t)
 	_, err := handleSendTextNoticeCard(bot, map[string]any{})
 	if err == nil || !strings.Contains(err.Error(), "main_title is required") {
 		t.Fatalf("expected 'main_title is required' error, got %v", err)
@@ -298,7 +327,7 @@ func TestHandleSendTextNoticeCard_MissingTitle(t *testing.T) {
 }
 
 func TestHandleSendTextNoticeCard_MissingCardAction(t *testing.T) {
-	bot := wecombot.New("test-key")
+	bot := newTestBot(t)
 	_, err := handleSendTextNoticeCard(bot, map[string]any{"main_title": "Test"})
 	if err == nil || !strings.Contains(err.Error(), "card_action is required") {
 		t.Fatalf("expected 'card_action is required' error, got %v", err)
@@ -306,7 +335,7 @@ func TestHandleSendTextNoticeCard_MissingCardAction(t *testing.T) {
 }
 
 func TestHandleSendNewsNoticeCard_MissingFields(t *testing.T) {
-	bot := wecombot.New("test-key")
+	bot := newTestBot(t)
 	_, err := handleSendNewsNoticeCard(bot, map[string]any{})
 	if err == nil || !strings.Contains(err.Error(), "main_title is required") {
 		t.Fatalf("expected 'main_title is required' error, got %v", err)
@@ -319,7 +348,7 @@ func TestHandleSendNewsNoticeCard_MissingFields(t *testing.T) {
 }
 
 func TestHandleUploadFile_MissingParams(t *testing.T) {
-	bot := wecombot.New("test-key")
+	bot := newTestBot(t)
 	_, err := handleUploadFile(bot, map[string]any{})
 	if err == nil || !strings.Contains(err.Error(), "filename is required") {
 		t.Fatalf("expected 'filename is required' error, got %v", err)
@@ -332,7 +361,7 @@ func TestHandleUploadFile_MissingParams(t *testing.T) {
 }
 
 func TestHandleUploadFile_InvalidBase64(t *testing.T) {
-	bot := wecombot.New("test-key")
+	bot := newTestBot(t)
 	_, err := handleUploadFile(bot, map[string]any{
 		"filename":    "test.txt",
 		"base64_data": "not-valid-base64!!!",
@@ -343,7 +372,7 @@ func TestHandleUploadFile_InvalidBase64(t *testing.T) {
 }
 
 func TestHandleUploadFile_TooLarge(t *testing.T) {
-	bot := wecombot.New("test-key")
+	bot := newTestBot(t)
 	// Create data just over 20MB
 	largeData := make([]byte, maxUploadFileBytes+1)
 	encoded := base64.StdEncoding.EncodeToString(largeData)
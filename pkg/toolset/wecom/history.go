@@ -0,0 +1,229 @@
+package wecom
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+const (
+	// DefaultBotName names the configured bot in history entries and resource URIs.
+	DefaultBotName = "default"
+
+	// DefaultHistorySize is the number of sent messages kept when no size is configured.
+	DefaultHistorySize = 200
+
+	// maxSummaryRunes bounds the summary shown for each history entry.
+	maxSummaryRunes = 200
+)
+
+// HistoryEntry is a message that was sent to the group.
+type HistoryEntry struct {
+	ID      string `json:"id"`
+	Bot     string `json:"bot"`
+	Kind    string `json:"kind"`
+	MsgType string `json:"msgtype"`
+	// Summary is a short plain-text description of the message content.
+	Summary string `json:"summary"`
+	// Payload is the JSON payload posted to WeCom. Image data is omitted.
+	Payload map[string]any `json:"payload"`
+	SentAt  time.Time      `json:"sent_at"`
+}
+
+// History is a bounded store of sent messages, newest last. When a file is
+// configured, the history is persisted and survives restarts.
+type History struct {
+	mu      sync.Mutex
+	bot     string
+	size    int
+	path    string
+	entries []HistoryEntry
+	now     func() time.Time
+}
+
+// NewHistory creates a history for bot that keeps the last size messages
+// (DefaultHistorySize when size is zero), persists them to path (if
+// non-empty) and loads any messages saved there.
+func NewHistory(bot string, size int, path string) (*History, error) {
+	if size <= 0 {
+		size = DefaultHistorySize
+	}
+	h := &History{
+		bot:  bot,
+		size: size,
+		path: path,
+		now:  time.Now,
+	}
+	if err := h.load(); err != nil {
+		return nil, err
+	}
+	return h, nil
+}
+
+// Bot returns the name of the bot whose messages are recorded.
+func (h *History) Bot() string {
+	return h.bot
+}
+
+// Record adds a sent message to the history, evicting the oldest entry when
+// the history is full. The entry is kept in memory even if persisting fails.
+func (h *History) Record(kind string, payload any) (HistoryEntry, error) {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return HistoryEntry{}, fmt.Errorf("failed to encode message for history: %w", err)
+	}
+	var decoded map[string]any
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		return HistoryEntry{}, fmt.Errorf("failed to encode message for history: %w", err)
+	}
+	id, err := newRandomID()
+	if err != nil {
+		return HistoryEntry{}, fmt.Errorf("failed to generate message id: %w", err)
+	}
+
+	msgType, _ := decoded["msgtype"].(string)
+	omitImageData(decoded)
+	entry := HistoryEntry{
+		ID:      id,
+		Bot:     h.bot,
+		Kind:    kind,
+		MsgType: msgType,
+		Summary: summarizePayload(msgType, decoded),
+		Payload: decoded,
+		SentAt:  h.now().UTC(),
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.entries = append(h.entries, entry)
+	if len(h.entries) > h.size {
+		h.entries = append([]HistoryEntry(nil), h.entries[len(h.entries)-h.size:]...)
+	}
+	return entry, h.saveLocked()
+}
+
+// Recent returns up to limit messages, newest first. A limit of zero or less
+// returns every message.
+func (h *History) Recent(limit int) []HistoryEntry {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if limit <= 0 || limit > len(h.entries) {
+		limit = len(h.entries)
+	}
+	recent := make([]HistoryEntry, 0, limit)
+	for i := len(h.entries) - 1; i >= 0 && len(recent) < limit; i-- {
+		recent = append(recent, h.entries[i])
+	}
+	return recent
+}
+
+// Get returns the message with the given ID.
+func (h *History) Get(id string) (HistoryEntry, bool) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	for _, entry := range h.entries {
+		if entry.ID == id {
+			return entry, true
+		}
+	}
+	return HistoryEntry{}, false
+}
+
+// load reads persisted messages from the history file, if any.
+func (h *History) load() error {
+	if h.path == "" {
+		return nil
+	}
+	data, err := os.ReadFile(h.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read history file: %w", err)
+	}
+
+	var entries []HistoryEntry
+	if err := json.Unmarshal(data, &entries); err != nil {
+		return fmt.Errorf("failed to parse history file %s: %w", h.path, err)
+	}
+	if len(entries) > h.size {
+		entries = entries[len(entries)-h.size:]
+	}
+	h.entries = entries
+	return nil
+}
+
+// saveLocked atomically writes all messages to the history file. The caller must hold h.mu.
+func (h *History) saveLocked() error {
+	if h.path == "" {
+		return nil
+	}
+
+	data, err := json.MarshalIndent(h.entries, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode history: %w", err)
+	}
+	if err := writeFileAtomic(h.path, data); err != nil {
+		return fmt.Errorf("failed to save history file: %w", err)
+	}
+	return nil
+}
+
+// omitImageData replaces the base64 data of an image message with its size,
+// so the history stays small.
+func omitImageData(payload map[string]any) {
+	body, ok := payload["image"].(map[string]any)
+	if !ok {
+		return
+	}
+	if encoded, ok := body["base64"].(string); ok {
+		body["base64"] = fmt.Sprintf("(%d base64 characters omitted)", len(encoded))
+	}
+}
+
+// summarizePayload returns a short plain-text description of a message payload.
+func summarizePayload(msgType string, payload map[string]any) string {
+	body, _ := payload[msgType].(map[string]any)
+
+	var summary string
+	switch msgType {
+	case "text", "markdown":
+		summary, _ = body["content"].(string)
+	case "image":
+		md5Hash, _ := body["md5"].(string)
+		summary = "image " + md5Hash
+	case "file":
+		mediaID, _ := body["media_id"].(string)
+		summary = "file " + mediaID
+	case "news":
+		articles, _ := body["articles"].([]any)
+		titles := make([]string, 0, len(articles))
+		for _, item := range articles {
+			article, _ := item.(map[string]any)
+			if title, _ := article["title"].(string); title != "" {
+				titles = append(titles, title)
+			}
+		}
+		summary = strings.Join(titles, "; ")
+	case "template_card":
+		mainTitle, _ := body["main_title"].(map[string]any)
+		summary, _ = mainTitle["title"].(string)
+	}
+	return truncateRunes(strings.Join(strings.Fields(summary), " "), maxSummaryRunes)
+}
+
+// truncateRunes shortens s to at most n runes, marking the cut with an ellipsis.
+func truncateRunes(s string, n int) string {
+	if utf8.RuneCountInString(s) <= n {
+		return s
+	}
+	runes := []rune(s)
+	return string(runes[:n-1]) + "…"
+}
@@ -0,0 +1,95 @@
+package wecom
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/futuretea/go-wecom-bot/image"
+	"github.com/futuretea/go-wecom-bot/markdown"
+	"github.com/futuretea/go-wecom-bot/text"
+)
+
+func newTestHistory(t *testing.T, size int, path string) *History {
+	t.Helper()
+	h, err := NewHistory(DefaultBotName, size, path)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	return h
+}
+
+func TestHistory_RecentNewestFirstAndBounded(t *testing.T) {
+	h := newTestHistory(t, 2, "")
+	for _, content := range []string{"one", "two", "three"} {
+		if _, err := h.Record("text message", text.New(content)); err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+	}
+
+	recent := h.Recent(0)
+	if len(recent) != 2 || recent[0].Summary != "three" || recent[1].Summary != "two" {
+		t.Fatalf("expected the two newest messages, newest first, got %+v", recent)
+	}
+	if got := h.Recent(1); len(got) != 1 || got[0].Summary != "three" {
+		t.Fatalf("expected limit to apply, got %+v", got)
+	}
+
+	entry, ok := h.Get(recent[1].ID)
+	if !ok || entry.MsgType != "text" || entry.Bot != DefaultBotName {
+		t.Fatalf("expected to find entry by id, got %+v", entry)
+	}
+}
+
+func TestHistory_SummaryAndImageData(t *testing.T) {
+	h := newTestHistory(t, 0, "")
+
+	entry, err := h.Record("markdown message", markdown.New("# Deploy\n\n"+strings.Repeat("x", 300)))
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if !strings.HasPrefix(entry.Summary, "# Deploy x") || len([]rune(entry.Summary)) != maxSummaryRunes {
+		t.Fatalf("expected a truncated single-line summary, got %q", entry.Summary)
+	}
+
+	entry, err = h.Record("image message", image.New("aGVsbG8=", "5d41402abc4b2a76b9719d911017c592"))
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	body := entry.Payload["image"].(map[string]any)
+	if body["base64"] != "(8 base64 characters omitted)" {
+		t.Fatalf("expected image data to be omitted, got %v", body["base64"])
+	}
+}
+
+func TestHistory_Persistence(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "history.json")
+	h := newTestHistory(t, 10, path)
+	sent, err := h.Record("text message", text.New("persisted"))
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	restarted := newTestHistory(t, 10, path)
+	entry, ok := restarted.Get(sent.ID)
+	if !ok || entry.Summary != "persisted" || !entry.SentAt.Equal(sent.SentAt) {
+		t.Fatalf("expected entry to survive a restart, got %+v", entry)
+	}
+}
+
+func TestClient_RecordsSentMessages(t *testing.T) {
+	c := NewClient(newTestBot(t))
+	c.History = newTestHistory(t, 0, "")
+
+	if _, err := handleSendText(c, map[string]any{"content": "hello"}); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if _, err := handleSendText(c, map[string]any{}); err == nil {
+		t.Fatal("expected error for missing content")
+	}
+
+	recent := c.History.Recent(0)
+	if len(recent) != 1 || recent[0].Kind != "text message" || recent[0].Summary != "hello" {
+		t.Fatalf("expected only the sent message to be recorded, got %+v", recent)
+	}
+}
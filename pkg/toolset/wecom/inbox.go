@@ -0,0 +1,176 @@
+package wecom
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// DefaultInboxSize is the number of received messages kept when no size is configured.
+	DefaultInboxSize = 200
+
+	// defaultIncomingLimit and maxIncomingLimit bound the messages returned by get_incoming_messages.
+	defaultIncomingLimit = 20
+	maxIncomingLimit     = 100
+)
+
+// IncomingMessage is a message a user sent to the bot, received through the
+// smart bot callback.
+type IncomingMessage struct {
+	// ID increases with every received message, so callers can ask for newer messages.
+	ID int64 `json:"id"`
+	// MsgID is the WeCom message ID, used to drop redelivered messages.
+	MsgID    string `json:"msgid"`
+	Bot      string `json:"bot"`
+	ChatID   string `json:"chatid,omitempty"`
+	ChatType string `json:"chattype"`
+	From     string `json:"from"`
+	MsgType  string `json:"msgtype"`
+	// Content is the text of the message. Images and files are given by URL.
+	Content    string    `json:"content"`
+	ReceivedAt time.Time `json:"received_at"`
+}
+
+// Inbox is a bounded store of received messages, oldest first.
+type Inbox struct {
+	mu       sync.Mutex
+	bot      string
+	size     int
+	nextID   int64
+	messages []IncomingMessage
+	listener func(IncomingMessage)
+	now      func() time.Time
+}
+
+// NewInbox creates an inbox for bot that keeps the last size messages
+// (DefaultInboxSize when size is zero).
+func NewInbox(bot string, size int) *Inbox {
+	if size <= 0 {
+		size = DefaultInboxSize
+	}
+	return &Inbox{bot: bot, size: size, nextID: 1, now: time.Now}
+}
+
+// Bot returns the name of the bot whose messages are received.
+func (b *Inbox) Bot() string {
+	return b.bot
+}
+
+// OnMessage sets a function called after each new message is stored. It must
+// be set before messages are added.
+func (b *Inbox) OnMessage(listener func(IncomingMessage)) {
+	b.listener = listener
+}
+
+// Add stores a received message, assigning its ID and receive time, and
+// evicts the oldest message when the inbox is full. It reports false and
+// stores nothing when a message with the same MsgID was already received.
+func (b *Inbox) Add(msg IncomingMessage) (IncomingMessage, bool) {
+	b.mu.Lock()
+	if msg.MsgID != "" {
+		for _, stored := range b.messages {
+			if stored.MsgID == msg.MsgID {
+				b.mu.Unlock()
+				return stored, false
+			}
+		}
+	}
+	msg.ID = b.nextID
+	b.nextID++
+	msg.Bot = b.bot
+	msg.ReceivedAt = b.now().UTC()
+	b.messages = append(b.messages, msg)
+	if len(b.messages) > b.size {
+		b.messages = append([]IncomingMessage(nil), b.messages[len(b.messages)-b.size:]...)
+	}
+	b.mu.Unlock()
+
+	if b.listener != nil {
+		b.listener(msg)
+	}
+	return msg, true
+}
+
+// Since returns up to limit messages with an ID above afterID, oldest first,
+// optionally only those of one chat. Without afterID, the newest messages are
+// returned. It also reports whether more messages match than were returned.
+// A limit of zero or less returns every message.
+func (b *Inbox) Since(afterID int64, chatID string, limit int) ([]IncomingMessage, bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	var matched []IncomingMessage
+	for _, msg := range b.messages {
+		if msg.ID > afterID && (chatID == "" || msg.ChatID == chatID) {
+			matched = append(matched, msg)
+		}
+	}
+	if limit <= 0 || limit >= len(matched) {
+		return matched, false
+	}
+	if afterID <= 0 {
+		return matched[len(matched)-limit:], true
+	}
+	return matched[:limit], true
+}
+
+// handleGetIncomingMessages lists messages users sent to the bot.
+func handleGetIncomingMessages(client any, params map[string]any) (string, error) {
+	c, err := getClient(client)
+	if err != nil {
+		return "", err
+	}
+	if c.Inbox == nil {
+		return "", fmt.Errorf("incoming messages are not enabled (configure the callback section and run in HTTP mode)")
+	}
+
+	var afterID int64
+	if _, ok := params["after_id"]; ok {
+		id, ok := intParam(params, "after_id")
+		if !ok || id < 0 {
+			return "", fmt.Errorf("after_id must be a non-negative whole number")
+		}
+		afterID = int64(id)
+	}
+	limit := defaultIncomingLimit
+	if _, ok := params["limit"]; ok {
+		n, ok := intParam(params, "limit")
+		if !ok || n < 1 || n > maxIncomingLimit {
+			return "", fmt.Errorf("limit must be a whole number between 1 and %d", maxIncomingLimit)
+		}
+		limit = n
+	}
+
+	messages, more := c.Inbox.Since(afterID, stringParam(params, "chat_id"), limit)
+	if len(messages) == 0 {
+		if afterID > 0 {
+			return fmt.Sprintf("No messages received after id %d", afterID), nil
+		}
+		return "No messages received yet", nil
+	}
+
+	lines := make([]string, 0, len(messages)+2)
+	lines = append(lines, fmt.Sprintf("%d message(s), oldest first:", len(messages)))
+	for _, msg := range messages {
+		lines = append(lines, formatIncomingMessage(msg))
+	}
+	last := messages[len(messages)-1].ID
+	if more && afterID > 0 {
+		lines = append(lines, fmt.Sprintf("More messages are waiting; call again with after_id=%d.", last))
+	} else {
+		lines = append(lines, fmt.Sprintf("Pass after_id=%d to get only newer messages.", last))
+	}
+	return strings.Join(lines, "\n"), nil
+}
+
+// formatIncomingMessage renders a received message as one list item.
+func formatIncomingMessage(msg IncomingMessage) string {
+	place := "a direct chat"
+	if msg.ChatType == "group" {
+		place = "group chat " + msg.ChatID
+	}
+	return fmt.Sprintf("- [id %d] %s from %s in %s: %s",
+		msg.ID, msg.ReceivedAt.Format(time.RFC3339), msg.From, place, msg.Content)
+}
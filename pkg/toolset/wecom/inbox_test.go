@@ -0,0 +1,80 @@
+package wecom
+
+import (
+	"strings"
+	"testing"
+)
+
+func addMessages(inbox *Inbox, chatID string, contents ...string) {
+	for _, content := range contents {
+		inbox.Add(IncomingMessage{MsgID: chatID + content, ChatID: chatID, ChatType: "group", From: "alice", MsgType: "text", Content: content})
+	}
+}
+
+func TestInbox_SinceAndBounded(t *testing.T) {
+	inbox := NewInbox(DefaultBotName, 3)
+	addMessages(inbox, "c1", "one", "two", "three", "four")
+
+	all, more := inbox.Since(0, "", 0)
+	if more || len(all) != 3 || all[0].Content != "two" || all[2].ID != 4 {
+		t.Fatalf("expected the three newest messages, oldest first, got %+v", all)
+	}
+	if got, more := inbox.Since(0, "", 1); !more || len(got) != 1 || got[0].Content != "four" {
+		t.Fatalf("expected the newest message without after_id, got %+v", got)
+	}
+	if got, more := inbox.Since(2, "", 1); !more || len(got) != 1 || got[0].Content != "three" {
+		t.Fatalf("expected the message after id 2, got %+v", got)
+	}
+
+	addMessages(inbox, "c2", "other")
+	if got, _ := inbox.Since(0, "c2", 0); len(got) != 1 || got[0].Content != "other" || got[0].Bot != DefaultBotName {
+		t.Fatalf("expected messages of chat c2, got %+v", got)
+	}
+}
+
+func TestInbox_DropsRedeliveredMessages(t *testing.T) {
+	inbox := NewInbox(DefaultBotName, 0)
+	var notified []int64
+	inbox.OnMessage(func(msg IncomingMessage) { notified = append(notified, msg.ID) })
+
+	first, added := inbox.Add(IncomingMessage{MsgID: "m1", Content: "hi"})
+	if !added || first.ID != 1 {
+		t.Fatalf("expected the message to be added, got %+v", first)
+	}
+	if again, added := inbox.Add(IncomingMessage{MsgID: "m1", Content: "hi"}); added || again.ID != first.ID {
+		t.Fatalf("expected the redelivered message to be dropped, got %+v", again)
+	}
+	if len(notified) != 1 {
+		t.Fatalf("expected one notification, got %v", notified)
+	}
+}
+
+func TestHandleGetIncomingMessages(t *testing.T) {
+	c := NewClient(newTestBot(t))
+	if _, err := handleGetIncomingMessages(c, map[string]any{}); err == nil || !strings.Contains(err.Error(), "not enabled") {
+		t.Fatalf("expected an error without an inbox, got %v", err)
+	}
+
+	c.Inbox = NewInbox(DefaultBotName, 0)
+	result, err := handleGetIncomingMessages(c, map[string]any{})
+	if err != nil || result != "No messages received yet" {
+		t.Fatalf("unexpected result %q, %v", result, err)
+	}
+
+	addMessages(c.Inbox, "wrc1", "@bot deploy api", "@bot status")
+	result, err = handleGetIncomingMessages(c, map[string]any{"after_id": float64(1)})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if !strings.Contains(result, "[id 2]") || strings.Contains(result, "[id 1]") || !strings.Contains(result, "alice in group chat wrc1: @bot status") || !strings.Contains(result, "after_id=2") {
+		t.Fatalf("unexpected result %q", result)
+	}
+
+	result, err = handleGetIncomingMessages(c, map[string]any{"after_id": float64(2)})
+	if err != nil || result != "No messages received after id 2" {
+		t.Fatalf("unexpected result %q, %v", result, err)
+	}
+	if _, err := handleGetIncomingMessages(c, map[string]any{"limit": float64(500)}); err == nil {
+		t.Fatal("expected an error for a limit above the maximum")
+	}
+}
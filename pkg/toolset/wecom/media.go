@@ -0,0 +1,221 @@
+package wecom
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/futuretea/wecom-bot-mcp-server/pkg/core/logging"
+	"github.com/futuretea/wecom-bot-mcp-server/pkg/wecomapi"
+)
+
+const (
+	// mediaLifetime is how long WeCom keeps an uploaded media_id valid.
+	mediaLifetime = 3 * 24 * time.Hour
+
+	// mediaReuseMargin is the minimum remaining lifetime of a media_id that is
+	// reused for a repeated upload, leaving time to send the file message.
+	mediaReuseMargin = time.Hour
+)
+
+// MediaRecord is a file uploaded to WeCom and the media_id it was given.
+type MediaRecord struct {
+	MediaID   string    `json:"media_id"`
+	Type      string    `json:"type"`
+	Filename  string    `json:"filename"`
+	Size      int       `json:"size"`
+	SHA256    string    `json:"sha256"`
+	CreatedAt time.Time `json:"created_at"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// MediaRegistry tracks uploaded media until their media_ids expire, so that
+// uploading the same file again reuses its media_id. When a file is
+// configured, the registry is persisted and survives restarts.
+type MediaRegistry struct {
+	mu      sync.Mutex
+	path    string
+	records []MediaRecord
+	now     func() time.Time
+}
+
+// NewMediaRegistry creates a registry that persists uploads to path (if
+// non-empty) and loads any uploads saved there.
+func NewMediaRegistry(path string) (*MediaRegistry, error) {
+	r := &MediaRegistry{
+		path: path,
+		now:  time.Now,
+	}
+	if err := r.load(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// Lookup returns the record of a previous upload of the same file content
+// under the same name whose media_id is still valid for at least an hour.
+func (r *MediaRegistry) Lookup(filename string, data []byte) (MediaRecord, bool) {
+	checksum := sha256Hex(data)
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	reusableUntil := r.now().Add(mediaReuseMargin)
+	for i := len(r.records) - 1; i >= 0; i-- {
+		record := r.records[i]
+		if record.SHA256 == checksum && record.Filename == filename && record.ExpiresAt.After(reusableUntil) {
+			return record, true
+		}
+	}
+	return MediaRecord{}, false
+}
+
+// Add records an upload of data and returns its record. Expired records are
+// dropped. The record is kept in memory even if persisting fails.
+func (r *MediaRegistry) Add(filename string, data []byte, media *wecomapi.Media) (MediaRecord, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := r.now()
+	createdAt := parseMediaCreatedAt(media.CreatedAt, now)
+	record := MediaRecord{
+		MediaID:   media.MediaID,
+		Type:      media.Type,
+		Filename:  filename,
+		Size:      len(data),
+		SHA256:    sha256Hex(data),
+		CreatedAt: createdAt,
+		ExpiresAt: createdAt.Add(mediaLifetime),
+	}
+	r.pruneLocked(now)
+	r.records = append(r.records, record)
+	return record, r.saveLocked()
+}
+
+// List returns the uploads whose media_ids have not expired, newest first.
+func (r *MediaRegistry) List() []MediaRecord {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := r.now()
+	records := make([]MediaRecord, 0, len(r.records))
+	for _, record := range r.records {
+		if record.ExpiresAt.After(now) {
+			records = append(records, record)
+		}
+	}
+	sort.SliceStable(records, func(i, j int) bool { return records[i].CreatedAt.After(records[j].CreatedAt) })
+	return records
+}
+
+// pruneLocked drops expired records. The caller must hold r.mu.
+func (r *MediaRegistry) pruneLocked(now time.Time) {
+	kept := r.records[:0]
+	for _, record := range r.records {
+		if record.ExpiresAt.After(now) {
+			kept = append(kept, record)
+		}
+	}
+	r.records = kept
+}
+
+// load reads persisted uploads from the registry file, if any.
+func (r *MediaRegistry) load() error {
+	if r.path == "" {
+		return nil
+	}
+	data, err := os.ReadFile(r.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read media file: %w", err)
+	}
+
+	if err := json.Unmarshal(data, &r.records); err != nil {
+		return fmt.Errorf("failed to parse media file %s: %w", r.path, err)
+	}
+	r.pruneLocked(r.now())
+	logging.Info("Loaded %d uploaded media record(s) from %s", len(r.records), r.path)
+	return nil
+}
+
+// saveLocked atomically writes all records to the registry file. The caller must hold r.mu.
+func (r *MediaRegistry) saveLocked() error {
+	if r.path == "" {
+		return nil
+	}
+
+	data, err := json.MarshalIndent(r.records, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode media records: %w", err)
+	}
+	if err := writeFileAtomic(r.path, data); err != nil {
+		return fmt.Errorf("failed to save media file: %w", err)
+	}
+	return nil
+}
+
+// parseMediaCreatedAt parses the Unix timestamp returned by the upload API,
+// falling back to now when it is missing or malformed.
+func parseMediaCreatedAt(createdAt string, now time.Time) time.Time {
+	seconds, err := strconv.ParseInt(createdAt, 10, 64)
+	if err != nil || seconds <= 0 {
+		return now.UTC()
+	}
+	return time.Unix(seconds, 0).UTC()
+}
+
+// sha256Hex returns the hexadecimal SHA-256 checksum of data.
+func sha256Hex(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// formatMediaRecord renders a media record as a single human-readable line.
+func formatMediaRecord(record MediaRecord) string {
+	return fmt.Sprintf("- %s: %s (%d bytes, type %s, sha256 %s), expires at %s",
+		record.MediaID, record.Filename, record.Size, record.Type, record.SHA256, record.ExpiresAt.Format(time.RFC3339))
+}
+
+// handleListMedia handles the list_media tool call.
+func handleListMedia(client any, _ map[string]any) (string, error) {
+	c, err := getClient(client)
+	if err != nil {
+		return "", err
+	}
+	if c.Media == nil {
+		return "", fmt.Errorf("the media registry is not available")
+	}
+
+	records := c.Media.List()
+	if len(records) == 0 {
+		return "No uploaded media with a valid media_id", nil
+	}
+
+	lines := make([]string, 0, len(records)+1)
+	lines = append(lines, fmt.Sprintf("%d uploaded file(s) with a valid media_id:", len(records)))
+	for _, record := range records {
+		lines = append(lines, formatMediaRecord(record))
+	}
+	return strings.Join(lines, "\n"), nil
+}
+
+// handleReadMedia reads wecom://media.
+func handleReadMedia(client any, _ string) (string, error) {
+	c, err := getClient(client)
+	if err != nil {
+		return "", err
+	}
+	if c.Media == nil {
+		return "", fmt.Errorf("the media registry is not available")
+	}
+	return marshalResource(map[string]any{"media": c.Media.List()})
+}
@@ -0,0 +1,117 @@
+package wecom
+
+import (
+	"encoding/base64"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/futuretea/wecom-bot-mcp-server/pkg/wecomapi"
+)
+
+func newTestMediaRegistry(t *testing.T, path string, now *time.Time) *MediaRegistry {
+	t.Helper()
+	r, err := NewMediaRegistry(path)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	r.now = func() time.Time { return *now }
+	return r
+}
+
+func TestMediaRegistry_LookupAndExpiry(t *testing.T) {
+	now := time.Date(2025, 1, 6, 9, 0, 0, 0, time.UTC)
+	r := newTestMediaRegistry(t, "", &now)
+	data := []byte("quarterly report")
+
+	media := &wecomapi.Media{Type: "file", MediaID: "media-1", CreatedAt: "1736154000"}
+	record, err := r.Add("report.pdf", data, media)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if !record.ExpiresAt.Equal(now.Add(3*24*time.Hour)) || record.Size != len(data) || len(record.SHA256) != 64 {
+		t.Fatalf("unexpected record: %+v", record)
+	}
+
+	if got, ok := r.Lookup("report.pdf", data); !ok || got.MediaID != "media-1" {
+		t.Fatalf("expected identical upload to be reused, got %+v %v", got, ok)
+	}
+	if _, ok := r.Lookup("report.pdf", []byte("other content")); ok {
+		t.Fatal("expected different content not to be reused")
+	}
+	if _, ok := r.Lookup("renamed.pdf", data); ok {
+		t.Fatal("expected a different filename not to be reused")
+	}
+
+	// Within the last hour a media_id is no longer reused, but still listed
+	now = now.Add(3*24*time.Hour - 30*time.Minute)
+	if _, ok := r.Lookup("report.pdf", data); ok {
+		t.Fatal("expected a media_id about to expire not to be reused")
+	}
+	if len(r.List()) != 1 {
+		t.Fatalf("expected the media to still be listed, got %+v", r.List())
+	}
+
+	now = now.Add(time.Hour)
+	if len(r.List()) != 0 {
+		t.Fatalf("expected expired media not to be listed, got %+v", r.List())
+	}
+}
+
+func TestMediaRegistry_Persistence(t *testing.T) {
+	// Records are pruned against the wall clock when the file is loaded
+	now := time.Now().UTC()
+	path := filepath.Join(t.TempDir(), "media.json")
+	r := newTestMediaRegistry(t, path, &now)
+	if _, err := r.Add("a.txt", []byte("hello world"), &wecomapi.Media{Type: "file", MediaID: "media-1"}); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	restarted := newTestMediaRegistry(t, path, &now)
+	if got, ok := restarted.Lookup("a.txt", []byte("hello world")); !ok || got.MediaID != "media-1" {
+		t.Fatalf("expected media to survive a restart, got %+v %v", got, ok)
+	}
+}
+
+func TestUploadFile_ReusesMediaID(t *testing.T) {
+	bot, fake := newFakeBot(t)
+	c := NewClient(bot)
+	media, err := NewMediaRegistry("")
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	c.Media = media
+
+	params := map[string]any{
+		"filename":    "notes.txt",
+		"base64_data": base64.StdEncoding.EncodeToString([]byte("meeting notes")),
+	}
+	first, err := handleUploadFile(c, params)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	second, err := handleUploadFile(c, params)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if len(fake.Uploads()) != 1 {
+		t.Fatalf("expected a single upload, got %d", len(fake.Uploads()))
+	}
+	if !strings.Contains(first, "media_id: fake-media-1") || !strings.Contains(second, "reusing its media_id. media_id: fake-media-1") {
+		t.Fatalf("unexpected results: %q, %q", first, second)
+	}
+
+	list, err := handleListMedia(c, nil)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if !strings.Contains(list, "1 uploaded file(s)") || !strings.Contains(list, "fake-media-1: notes.txt (13 bytes") {
+		t.Fatalf("unexpected list_media result: %q", list)
+	}
+
+	if _, err := handleListMedia(NewClient(bot), nil); err == nil {
+		t.Fatal("expected error without a media registry")
+	}
+}
@@ -0,0 +1,65 @@
+package wecom
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// resolveMentions validates the mentioned user list and, when a directory is
+// configured, expands names, aliases and teams. Directory users without a user
+// ID are returned as mobile numbers.
+func (c *Client) resolveMentions(mentions []string) (userIDs, mobiles []string, err error) {
+	if len(mentions) == 0 {
+		return nil, nil, nil
+	}
+	if c.Directory != nil {
+		userIDs, mobiles, err = c.Directory.ResolveMentions(mentions)
+		if err != nil {
+			return nil, nil, err
+		}
+	} else {
+		userIDs = mentions
+	}
+
+	for _, id := range userIDs {
+		if err := validateUserID(id); err != nil {
+			return nil, nil, err
+		}
+	}
+	return userIDs, mobiles, nil
+}
+
+// validateUserID rejects values that cannot be WeCom user IDs, which would
+// otherwise be silently ignored or break the markdown mention syntax.
+func validateUserID(id string) error {
+	if id == "" {
+		return fmt.Errorf("mentioned user ID must not be empty")
+	}
+	if strings.ContainsAny(id, "<> \t\r\n") {
+		return fmt.Errorf("invalid mentioned user ID %q: must not contain whitespace or angle brackets", id)
+	}
+	return nil
+}
+
+// markdownMention renders a WeCom markdown mention for a user ID. Markdown has
+// no syntax for @all, which callers must reject.
+func markdownMention(userID string) string {
+	return "<@" + userID + ">"
+}
+
+// appendMarkdownMentions appends a mention line for every user ID that is not
+// already mentioned inline with the <@userid> syntax.
+func appendMarkdownMentions(content string, userIDs []string) string {
+	var missing []string
+	for _, id := range userIDs {
+		mention := markdownMention(id)
+		if !strings.Contains(content, mention) && !slices.Contains(missing, mention) {
+			missing = append(missing, mention)
+		}
+	}
+	if len(missing) == 0 {
+		return content
+	}
+	return strings.TrimRight(content, "\n") + "\n\n" + strings.Join(missing, " ")
+}
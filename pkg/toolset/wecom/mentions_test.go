@@ -0,0 +1,94 @@
+package wecom
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestAppendMarkdownMentions(t *testing.T) {
+	got := appendMarkdownMentions("# Alert\nping <@lisi>\n", []string{"zhangsan", "lisi", "zhangsan", "wangwu"})
+	want := "# Alert\nping <@lisi>\n\n<@zhangsan> <@wangwu>"
+	if got != want {
+		t.Fatalf("expected %q, got %q", want, got)
+	}
+}
+
+func TestAppendMarkdownMentions_AllInline(t *testing.T) {
+	content := "hi <@zhangsan>"
+	if got := appendMarkdownMentions(content, []string{"zhangsan"}); got != content {
+		t.Fatalf("expected content unchanged, got %q", got)
+	}
+}
+
+func TestValidateUserID(t *testing.T) {
+	for _, id := range []string{"zhangsan", "@all", "user.name-1"} {
+		if err := validateUserID(id); err != nil {
+			t.Errorf("validateUserID(%q): expected no error, got %v", id, err)
+		}
+	}
+	for _, id := range []string{"", "zhang san", "evil><@all"} {
+		if err := validateUserID(id); err == nil {
+			t.Errorf("validateUserID(%q): expected error", id)
+		}
+	}
+}
+
+func TestHandleSendMarkdown_InvalidMention(t *testing.T) {
+	bot := newTestBot(t)
+	_, err := handleSendMarkdown(bot, map[string]any{
+		"content":        "hello",
+		"mentioned_list": []any{"Zhang San"},
+	})
+	if err == nil || !strings.Contains(err.Error(), "invalid mentioned user ID") {
+		t.Fatalf("expected invalid user ID error, got %v", err)
+	}
+}
+
+func TestHandleSendMarkdown_RejectsMentionAll(t *testing.T) {
+	_, err := handleSendMarkdown(NewClient(newTestBot(t)), map[string]any{
+		"content":        "hello",
+		"mentioned_list": []any{"zhangsan", "@all"},
+	})
+	if err == nil || !strings.Contains(err.Error(), "cannot mention @all") {
+		t.Fatalf("expected @all to be rejected, got %v", err)
+	}
+}
+
+func TestHandleSendMarkdown_MobileOnlyMention(t *testing.T) {
+	c := NewClient(newTestBot(t))
+	c.Directory = loadTestDirectory(t)
+	_, err := handleSendMarkdown(c, map[string]any{
+		"content":        "hello",
+		"mentioned_list": []any{"Wang Wu"},
+	})
+	if err == nil || !strings.Contains(err.Error(), "can only mention user IDs") {
+		t.Fatalf("expected mobile-only mention error, got %v", err)
+	}
+}
+
+func TestHandleSendMarkdown_MentionsExceedLimit(t *testing.T) {
+	bot := newTestBot(t)
+	_, err := handleSendMarkdown(bot, map[string]any{
+		"content":        strings.Repeat("a", maxMarkdownContentBytes-5),
+		"mentioned_list": []any{"zhangsan"},
+	})
+	if err == nil || !strings.Contains(err.Error(), "content with mentions exceeds") {
+		t.Fatalf("expected size limit error, got %v", err)
+	}
+}
+
+func TestHandleSendMarkdown_MentionsInPreview(t *testing.T) {
+	c := NewClient(newTestBot(t))
+	c.RequireConfirmation = true
+	c.Directory = loadTestDirectory(t)
+	result, err := handleSendMarkdown(c, map[string]any{
+		"content":        "deploy failed",
+		"mentioned_list": []any{"@backend-oncall"},
+	})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if !strings.Contains(result, "<@zhangsan> <@lisi>") {
+		t.Fatalf("expected mention line in preview, got %q", result)
+	}
+}
@@ -0,0 +1,280 @@
+package wecom
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/futuretea/wecom-bot-mcp-server/pkg/core/config"
+	"github.com/futuretea/wecom-bot-mcp-server/pkg/toolset"
+)
+
+// Message priorities
+const (
+	priorityNormal = "normal"
+	priorityUrgent = "urgent"
+)
+
+// maxQuietHoursSearch bounds the search for the next delivery window.
+const maxQuietHoursSearch = 31 * 24 * time.Hour
+
+// weekdayNames maps day abbreviations to weekdays.
+var weekdayNames = map[string]time.Weekday{
+	"sun": time.Sunday,
+	"mon": time.Monday,
+	"tue": time.Tuesday,
+	"wed": time.Wednesday,
+	"thu": time.Thursday,
+	"fri": time.Friday,
+	"sat": time.Saturday,
+}
+
+// quietWindow is a recurring period during which non-urgent messages are held.
+// A window whose end is not after its start wraps past midnight into the next day.
+type quietWindow struct {
+	days  [7]bool
+	start int // minutes since midnight
+	end   int // minutes since midnight, up to 24*60
+}
+
+// QuietHours decides whether a non-urgent message may be delivered now.
+type QuietHours struct {
+	location *time.Location
+	windows  []quietWindow
+	holidays map[string]bool
+	action   string
+	now      func() time.Time
+}
+
+// NewQuietHours builds quiet hours from configuration, loading the holiday
+// file if one is configured.
+func NewQuietHours(cfg config.QuietHours) (*QuietHours, error) {
+	q := &QuietHours{
+		location: time.Local,
+		holidays: make(map[string]bool),
+		action:   cfg.Action,
+		now:      time.Now,
+	}
+	if q.action == "" {
+		q.action = config.QuietHoursActionDefer
+	}
+
+	if cfg.Timezone != "" {
+		location, err := time.LoadLocation(cfg.Timezone)
+		if err != nil {
+			return nil, fmt.Errorf("invalid quiet_hours.timezone %q: %w", cfg.Timezone, err)
+		}
+		q.location = location
+	}
+
+	for i, window := range cfg.Windows {
+		parsed, err := parseQuietWindow(window)
+		if err != nil {
+			return nil, fmt.Errorf("invalid quiet_hours.windows[%d]: %w", i, err)
+		}
+		q.windows = append(q.windows, parsed)
+	}
+
+	if cfg.HolidaysFile != "" {
+		if err := q.loadHolidays(cfg.HolidaysFile); err != nil {
+			return nil, err
+		}
+	}
+	return q, nil
+}
+
+// parseQuietWindow parses a configured window.
+func parseQuietWindow(cfg config.QuietWindow) (quietWindow, error) {
+	var window quietWindow
+	days, err := parseWeekdays(cfg.Days)
+	if err != nil {
+		return window, err
+	}
+	window.days = days
+
+	if window.start, err = parseClock(cfg.Start); err != nil {
+		return window, fmt.Errorf("start: %w", err)
+	}
+	if window.end, err = parseClock(cfg.End); err != nil {
+		return window, fmt.Errorf("end: %w", err)
+	}
+	if window.start == window.end {
+		return window, fmt.Errorf("start and end must differ")
+	}
+	return window, nil
+}
+
+// parseWeekdays parses a comma-separated list of days or day ranges, such as
+// "mon-fri", "sat,sun" or "daily". Empty means every day.
+func parseWeekdays(spec string) ([7]bool, error) {
+	var days [7]bool
+	spec = strings.ToLower(strings.TrimSpace(spec))
+	if spec == "" || spec == "daily" || spec == "*" {
+		for i := range days {
+			days[i] = true
+		}
+		return days, nil
+	}
+
+	for _, part := range strings.Split(spec, ",") {
+		part = strings.TrimSpace(part)
+		from, to, isRange := strings.Cut(part, "-")
+		start, ok := weekdayNames[strings.TrimSpace(from)]
+		if !ok {
+			return days, fmt.Errorf("unknown day %q, expected one of sun, mon, tue, wed, thu, fri, sat", from)
+		}
+		end := start
+		if isRange {
+			if end, ok = weekdayNames[strings.TrimSpace(to)]; !ok {
+				return days, fmt.Errorf("unknown day %q, expected one of sun, mon, tue, wed, thu, fri, sat", to)
+			}
+		}
+		for d := start; ; d = (d + 1) % 7 {
+			days[d] = true
+			if d == end {
+				break
+			}
+		}
+	}
+	return days, nil
+}
+
+// parseClock parses an "HH:MM" time of day, allowing "24:00" as end of day.
+func parseClock(value string) (int, error) {
+	hours, minutes, ok := strings.Cut(strings.TrimSpace(value), ":")
+	if !ok {
+		return 0, fmt.Errorf("expected HH:MM, got %q", value)
+	}
+	h, errH := strconv.Atoi(hours)
+	m, errM := strconv.Atoi(minutes)
+	if errH != nil || errM != nil || h < 0 || m < 0 || m > 59 || h > 24 || (h == 24 && m != 0) {
+		return 0, fmt.Errorf("expected HH:MM, got %q", value)
+	}
+	return h*60 + m, nil
+}
+
+// loadHolidays reads a holiday file with one YYYY-MM-DD date per line.
+// Blank lines and lines starting with "#" are ignored.
+func (q *QuietHours) loadHolidays(path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("failed to open holidays file: %w", err)
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	line := 0
+	for scanner.Scan() {
+		line++
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		// Allow trailing comments such as "2025-01-01 New Year's Day"
+		date, _, _ := strings.Cut(text, " ")
+		if _, err := time.Parse(time.DateOnly, date); err != nil {
+			return fmt.Errorf("invalid date %q on line %d of holidays file %s", date, line, path)
+		}
+		q.holidays[date] = true
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("failed to read holidays file: %w", err)
+	}
+	return nil
+}
+
+// IsQuiet reports whether t falls on a holiday or inside a quiet window.
+func (q *QuietHours) IsQuiet(t time.Time) bool {
+	local := t.In(q.location)
+	if q.holidays[local.Format(time.DateOnly)] {
+		return true
+	}
+
+	minute := local.Hour()*60 + local.Minute()
+	today := local.Weekday()
+	yesterday := (today + 6) % 7
+	for _, window := range q.windows {
+		if window.start < window.end {
+			if window.days[today] && minute >= window.start && minute < window.end {
+				return true
+			}
+			continue
+		}
+		// Wrapping window: quiet from start until midnight on a listed day,
+		// and from midnight until end on the following day.
+		if (window.days[today] && minute >= window.start) || (window.days[yesterday] && minute < window.end) {
+			return true
+		}
+	}
+	return false
+}
+
+// NextAllowed returns the earliest time at or after t that is not quiet.
+func (q *QuietHours) NextAllowed(t time.Time) (time.Time, error) {
+	if !q.IsQuiet(t) {
+		return t, nil
+	}
+	candidate := t.Truncate(time.Minute)
+	limit := t.Add(maxQuietHoursSearch)
+	for candidate.Before(limit) {
+		candidate = candidate.Add(time.Minute)
+		if !q.IsQuiet(candidate) {
+			return candidate, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("no delivery window in the next %s; check the quiet_hours configuration", maxQuietHoursSearch)
+}
+
+// withQuietHours wraps a send handler so that non-urgent messages sent during
+// quiet hours are deferred to the next delivery window or rejected.
+func withQuietHours(tool string, handler toolset.ToolHandler) toolset.ToolHandler {
+	return func(client any, params map[string]any) (string, error) {
+		c, err := getClient(client)
+		if err != nil {
+			return "", err
+		}
+
+		priority := stringParam(params, "priority")
+		if priority != "" && priority != priorityNormal && priority != priorityUrgent {
+			return "", fmt.Errorf("priority must be %q or %q, got %q", priorityNormal, priorityUrgent, priority)
+		}
+		if c.QuietHours == nil {
+			return handler(client, params)
+		}
+
+		now := c.QuietHours.now()
+		if !c.QuietHours.IsQuiet(now) {
+			return handler(client, params)
+		}
+
+		if priority == priorityUrgent {
+			result, err := handler(client, params)
+			if err != nil {
+				return "", err
+			}
+			return result + "\n\nQuiet hours: in effect, bypassed because priority is urgent", nil
+		}
+
+		next, err := c.QuietHours.NextAllowed(now)
+		if err != nil {
+			return "", err
+		}
+		nextLocal := next.In(c.QuietHours.location).Format(time.RFC3339)
+
+		if c.QuietHours.action == config.QuietHoursActionReject || c.Scheduler == nil {
+			return "", fmt.Errorf("quiet hours: non-urgent message not sent; the next delivery window opens at %s. "+
+				"Retry then, or set priority to %q if this cannot wait", nextLocal, priorityUrgent)
+		}
+
+		job, err := c.Scheduler.Add(tool, params, next, "")
+		if err != nil {
+			return "", fmt.Errorf("quiet hours: failed to defer message: %w", err)
+		}
+		return fmt.Sprintf("Quiet hours: in effect, message deferred to %s (job_id: %s). "+
+			"Use cancel_scheduled to drop it, or resend with priority %q to deliver now.",
+			nextLocal, job.ID, priorityUrgent), nil
+	}
+}
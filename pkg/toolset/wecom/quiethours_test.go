@@ -0,0 +1,207 @@
+package wecom
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/futuretea/wecom-bot-mcp-server/pkg/core/config"
+)
+
+func newTestQuietHours(t *testing.T, cfg config.QuietHours, now time.Time) *QuietHours {
+	t.Helper()
+	if cfg.Timezone == "" {
+		cfg.Timezone = "UTC"
+	}
+	q, err := NewQuietHours(cfg)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	q.now = func() time.Time { return now }
+	return q
+}
+
+var nightsAndWeekends = config.QuietHours{
+	Windows: []config.QuietWindow{
+		{Days: "daily", Start: "22:00", End: "08:00"},
+		{Days: "sat,sun", Start: "00:00", End: "24:00"},
+	},
+}
+
+func TestNewQuietHours_Invalid(t *testing.T) {
+	tests := []struct {
+		name    string
+		cfg     config.QuietHours
+		wantErr string
+	}{
+		{name: "bad timezone", cfg: config.QuietHours{Timezone: "Mars/Olympus"}, wantErr: "invalid quiet_hours.timezone"},
+		{name: "bad day", cfg: config.QuietHours{Windows: []config.QuietWindow{{Days: "funday", Start: "01:00", End: "02:00"}}}, wantErr: "unknown day"},
+		{name: "bad time", cfg: config.QuietHours{Windows: []config.QuietWindow{{Start: "25:00", End: "02:00"}}}, wantErr: "expected HH:MM"},
+		{name: "empty window", cfg: config.QuietHours{Windows: []config.QuietWindow{{Start: "02:00", End: "02:00"}}}, wantErr: "must differ"},
+		{name: "missing holidays", cfg: config.QuietHours{HolidaysFile: "/nonexistent/holidays.txt"}, wantErr: "failed to open holidays file"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewQuietHours(tt.cfg)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("expected error containing %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestQuietHours_IsQuiet(t *testing.T) {
+	q := newTestQuietHours(t, nightsAndWeekends, time.Time{})
+
+	tests := []struct {
+		name string
+		at   time.Time
+		want bool
+	}{
+		{name: "weekday working hours", at: time.Date(2025, 1, 8, 10, 0, 0, 0, time.UTC), want: false},
+		{name: "weekday late evening", at: time.Date(2025, 1, 8, 23, 0, 0, 0, time.UTC), want: true},
+		{name: "weekday early morning", at: time.Date(2025, 1, 8, 3, 0, 0, 0, time.UTC), want: true},
+		{name: "window end is exclusive", at: time.Date(2025, 1, 8, 8, 0, 0, 0, time.UTC), want: false},
+		{name: "monday morning after sunday night", at: time.Date(2025, 1, 6, 7, 0, 0, 0, time.UTC), want: true},
+		{name: "saturday morning after friday night", at: time.Date(2025, 1, 11, 7, 0, 0, 0, time.UTC), want: true},
+		{name: "sunday afternoon", at: time.Date(2025, 1, 12, 15, 0, 0, 0, time.UTC), want: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := q.IsQuiet(tt.at); got != tt.want {
+				t.Fatalf("IsQuiet(%s) = %v, want %v", tt.at.Format(time.RFC3339), got, tt.want)
+			}
+		})
+	}
+}
+
+func TestQuietHours_Timezone(t *testing.T) {
+	q := newTestQuietHours(t, config.QuietHours{
+		Timezone: "Asia/Shanghai",
+		Windows:  []config.QuietWindow{{Start: "22:00", End: "08:00"}},
+	}, time.Time{})
+
+	// 15:00 UTC is 23:00 in Shanghai
+	if !q.IsQuiet(time.Date(2025, 1, 8, 15, 0, 0, 0, time.UTC)) {
+		t.Fatal("expected 23:00 Shanghai time to be quiet")
+	}
+	// 02:00 UTC is 10:00 in Shanghai
+	if q.IsQuiet(time.Date(2025, 1, 8, 2, 0, 0, 0, time.UTC)) {
+		t.Fatal("expected 10:00 Shanghai time not to be quiet")
+	}
+}
+
+func TestQuietHours_Holidays(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "holidays.txt")
+	content := "# public holidays\n\n2025-01-01 New Year's Day\n"
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatalf("failed to write holidays file: %v", err)
+	}
+	q := newTestQuietHours(t, config.QuietHours{HolidaysFile: path}, time.Time{})
+
+	if !q.IsQuiet(time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)) {
+		t.Fatal("expected holiday to be quiet")
+	}
+	next, err := q.NextAllowed(time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC))
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if want := time.Date(2025, 1, 2, 0, 0, 0, 0, time.UTC); !next.Equal(want) {
+		t.Fatalf("expected next window at %s, got %s", want, next)
+	}
+
+	if err := os.WriteFile(path, []byte("01/01/2025\n"), 0o600); err != nil {
+		t.Fatalf("failed to write holidays file: %v", err)
+	}
+	if _, err := NewQuietHours(config.QuietHours{HolidaysFile: path}); err == nil || !strings.Contains(err.Error(), "line 1") {
+		t.Fatalf("expected invalid date error, got %v", err)
+	}
+}
+
+func TestQuietHours_NextAllowed(t *testing.T) {
+	q := newTestQuietHours(t, nightsAndWeekends, time.Time{})
+
+	// Friday 23:30 is quiet through the weekend until Monday 08:00
+	next, err := q.NextAllowed(time.Date(2025, 1, 10, 23, 30, 15, 0, time.UTC))
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if want := time.Date(2025, 1, 13, 8, 0, 0, 0, time.UTC); !next.Equal(want) {
+		t.Fatalf("expected next window at %s, got %s", want, next)
+	}
+
+	always := newTestQuietHours(t, config.QuietHours{
+		Windows: []config.QuietWindow{{Start: "00:00", End: "24:00"}},
+	}, time.Time{})
+	if _, err := always.NextAllowed(time.Date(2025, 1, 10, 12, 0, 0, 0, time.UTC)); err == nil {
+		t.Fatal("expected error when quiet hours never end")
+	}
+}
+
+func TestWithQuietHours(t *testing.T) {
+	now := time.Date(2025, 1, 8, 23, 0, 0, 0, time.UTC)
+	sent := 0
+	handler := func(client any, params map[string]any) (string, error) {
+		sent++
+		return "Text message sent successfully", nil
+	}
+
+	newClient := func(action string) *Client {
+		cfg := nightsAndWeekends
+		cfg.Action = action
+		c := NewClient(newTestBot(t))
+		c.QuietHours = newTestQuietHours(t, cfg, now)
+		c.Scheduler = newTestScheduler(t, "", &fakeDispatcher{}, &now)
+		return c
+	}
+
+	t.Run("invalid priority", func(t *testing.T) {
+		_, err := withQuietHours("send_text", handler)(newClient(""), map[string]any{"content": "hi", "priority": "high"})
+		if err == nil || !strings.Contains(err.Error(), "priority must be") {
+			t.Fatalf("expected priority error, got %v", err)
+		}
+	})
+
+	t.Run("defer", func(t *testing.T) {
+		sent = 0
+		c := newClient("")
+		result, err := withQuietHours("send_text", handler)(c, map[string]any{"content": "hi"})
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		if sent != 0 {
+			t.Fatal("expected message not to be sent during quiet hours")
+		}
+		if !strings.Contains(result, "deferred to 2025-01-09T08:00:00Z") {
+			t.Fatalf("unexpected result: %q", result)
+		}
+		jobs := c.Scheduler.List()
+		if len(jobs) != 1 || jobs[0].Tool != "send_text" {
+			t.Fatalf("expected one deferred send_text job, got %v", jobs)
+		}
+	})
+
+	t.Run("reject", func(t *testing.T) {
+		sent = 0
+		_, err := withQuietHours("send_text", handler)(newClient(config.QuietHoursActionReject), map[string]any{"content": "hi"})
+		if err == nil || !strings.Contains(err.Error(), "next delivery window opens at 2025-01-09T08:00:00Z") {
+			t.Fatalf("expected rejection naming the next window, got %v", err)
+		}
+		if sent != 0 {
+			t.Fatal("expected message not to be sent during quiet hours")
+		}
+	})
+
+	t.Run("urgent bypasses", func(t *testing.T) {
+		sent = 0
+		result, err := withQuietHours("send_text", handler)(newClient(""), map[string]any{"content": "hi", "priority": priorityUrgent})
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		if sent != 1 || !strings.Contains(result, "bypassed because priority is urgent") {
+			t.Fatalf("expected urgent message to be sent and reported, got %q", result)
+		}
+	})
+}
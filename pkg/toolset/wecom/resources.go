@@ -0,0 +1,200 @@
+package wecom
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+
+	"github.com/futuretea/wecom-bot-mcp-server/pkg/toolset"
+)
+
+// Compile-time interface check
+var _ toolset.ResourceProvider = (*Toolset)(nil)
+
+// Resource URIs, prefixes and templates
+const (
+	MediaURI = "wecom://media"
+
+	botsURIPrefix     = "wecom://bots/"
+	messagesURIPrefix = "wecom://messages/"
+	messagesURISuffix = "/messages"
+	incomingURISuffix = "/incoming"
+
+	BotMessagesURITemplate = botsURIPrefix + "{name}" + messagesURISuffix
+	MessageURITemplate     = messagesURIPrefix + "{id}"
+)
+
+// jsonMIMEType is the MIME type of every WeCom resource.
+const jsonMIMEType = "application/json"
+
+// historyListItem is a history entry as listed in a bot's message resource.
+type historyListItem struct {
+	ID      string    `json:"id"`
+	URI     string    `json:"uri"`
+	Kind    string    `json:"kind"`
+	MsgType string    `json:"msgtype"`
+	Summary string    `json:"summary"`
+	SentAt  time.Time `json:"sent_at"`
+}
+
+// GetResources returns the message history of the configured bot, the uploaded
+// media and, when the callback is enabled, the messages the bot received.
+func (t *Toolset) GetResources(client any) []toolset.ServerResource {
+	bot := DefaultBotName
+	if c, err := getClient(client); err == nil && c.History != nil {
+		bot = c.History.Bot()
+	}
+
+	resources := []toolset.ServerResource{
+		{
+			Resource: mcp.NewResource(botMessagesURI(bot), fmt.Sprintf("Messages sent by the %s bot", bot),
+				mcp.WithResourceDescription("Recently sent messages of the bot, newest first. Read it before notifying to avoid repeating a message."),
+				mcp.WithMIMEType(jsonMIMEType),
+			),
+			Handler: handleReadBotMessages,
+		},
+		{
+			Resource: mcp.NewResource(MediaURI, "Uploaded media",
+				mcp.WithResourceDescription("Files uploaded with upload_file whose media_id has not expired yet, newest first, with size, SHA-256 checksum and expiry."),
+				mcp.WithMIMEType(jsonMIMEType),
+			),
+			Handler: handleReadMedia,
+		},
+	}
+	if c, err := getClient(client); err == nil && c.Inbox != nil {
+		resources = append(resources, toolset.ServerResource{
+			Resource: mcp.NewResource(BotIncomingURI(c.Inbox.Bot()), fmt.Sprintf("Messages received by the %s bot", c.Inbox.Bot()),
+				mcp.WithResourceDescription("Messages users sent to the bot, oldest first. A resource updated notification is sent when a message arrives."),
+				mcp.WithMIMEType(jsonMIMEType),
+			),
+			Handler: handleReadIncoming,
+		})
+	}
+	return resources
+}
+
+// GetResourceTemplates returns the message history resource templates.
+func (t *Toolset) GetResourceTemplates(_ any) []toolset.ServerResourceTemplate {
+	return []toolset.ServerResourceTemplate{
+		{
+			Template: mcp.NewResourceTemplate(BotMessagesURITemplate, "Bot message history",
+				mcp.WithTemplateDescription("Recently sent messages of a bot, newest first, with a summary of each. Read it before notifying to avoid repeating a message."),
+				mcp.WithTemplateMIMEType(jsonMIMEType),
+			),
+			Handler: handleReadBotMessages,
+		},
+		{
+			Template: mcp.NewResourceTemplate(MessageURITemplate, "Sent message",
+				mcp.WithTemplateDescription("A sent message from the history, including the payload posted to WeCom."),
+				mcp.WithTemplateMIMEType(jsonMIMEType),
+			),
+			Handler: handleReadMessage,
+		},
+	}
+}
+
+// botMessagesURI returns the URI of a bot's message history.
+func botMessagesURI(bot string) string {
+	return botsURIPrefix + bot + messagesURISuffix
+}
+
+// BotIncomingURI returns the URI of the messages received by a bot.
+func BotIncomingURI(bot string) string {
+	return botsURIPrefix + bot + incomingURISuffix
+}
+
+// messageURI returns the URI of a sent message.
+func messageURI(id string) string {
+	return messagesURIPrefix + id
+}
+
+// getHistory returns the message history of the generic client.
+func getHistory(client any) (*History, error) {
+	c, err := getClient(client)
+	if err != nil {
+		return nil, err
+	}
+	if c.History == nil {
+		return nil, fmt.Errorf("message history is not enabled")
+	}
+	return c.History, nil
+}
+
+// handleReadBotMessages reads wecom://bots/{name}/messages.
+func handleReadBotMessages(client any, uri string) (string, error) {
+	history, err := getHistory(client)
+	if err != nil {
+		return "", err
+	}
+
+	name, ok := strings.CutPrefix(uri, botsURIPrefix)
+	if ok {
+		name, ok = strings.CutSuffix(name, messagesURISuffix)
+	}
+	if !ok || name == "" || strings.Contains(name, "/") {
+		return "", fmt.Errorf("invalid bot messages URI %q", uri)
+	}
+	if name != history.Bot() {
+		return "", fmt.Errorf("unknown bot %q; available bots: %s", name, history.Bot())
+	}
+
+	entries := history.Recent(0)
+	items := make([]historyListItem, 0, len(entries))
+	for _, entry := range entries {
+		items = append(items, historyListItem{
+			ID:      entry.ID,
+			URI:     messageURI(entry.ID),
+			Kind:    entry.Kind,
+			MsgType: entry.MsgType,
+			Summary: entry.Summary,
+			SentAt:  entry.SentAt,
+		})
+	}
+	return marshalResource(map[string]any{"bot": name, "messages": items})
+}
+
+// handleReadMessage reads wecom://messages/{id}.
+func handleReadMessage(client any, uri string) (string, error) {
+	history, err := getHistory(client)
+	if err != nil {
+		return "", err
+	}
+
+	id, ok := strings.CutPrefix(uri, messagesURIPrefix)
+	if !ok || id == "" {
+		return "", fmt.Errorf("invalid message URI %q", uri)
+	}
+	entry, ok := history.Get(id)
+	if !ok {
+		return "", fmt.Errorf("no message with id %q (it may have been evicted from the history)", id)
+	}
+	return marshalResource(entry)
+}
+
+// handleReadIncoming reads wecom://bots/{name}/incoming.
+func handleReadIncoming(client any, _ string) (string, error) {
+	c, err := getClient(client)
+	if err != nil {
+		return "", err
+	}
+	if c.Inbox == nil {
+		return "", fmt.Errorf("incoming messages are not enabled")
+	}
+	messages, _ := c.Inbox.Since(0, "", 0)
+	if messages == nil {
+		messages = []IncomingMessage{}
+	}
+	return marshalResource(map[string]any{"bot": c.Inbox.Bot(), "messages": messages})
+}
+
+// marshalResource renders resource content as indented JSON.
+func marshalResource(v any) (string, error) {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return "", fmt.Errorf("failed to encode resource: %w", err)
+	}
+	return string(data), nil
+}
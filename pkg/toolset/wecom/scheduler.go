@@ -0,0 +1,514 @@
+package wecom
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/robfig/cron/v3"
+
+	"github.com/futuretea/wecom-bot-mcp-server/pkg/core/logging"
+)
+
+const (
+	// maxScheduledJobs bounds the number of pending jobs.
+	maxScheduledJobs = 100
+
+	// schedulerTickInterval is how often the scheduler checks for due jobs.
+	schedulerTickInterval = time.Second
+
+	// finishedJobRetention is how long completed one-shot jobs stay listed.
+	finishedJobRetention = 24 * time.Hour
+)
+
+// Scheduled job statuses
+const (
+	JobStatusScheduled = "scheduled"
+	JobStatusDone      = "done"
+	JobStatusFailed    = "failed"
+)
+
+// Dispatcher runs tools on behalf of the scheduler.
+type Dispatcher interface {
+	// HasTool reports whether the tool is registered and enabled.
+	HasTool(name string) bool
+
+	// CallTool runs the tool with the given arguments.
+	CallTool(name string, params map[string]any) (string, error)
+}
+
+// ScheduledJob is a message send deferred to a point in time or a cron schedule.
+type ScheduledJob struct {
+	ID        string         `json:"id"`
+	Tool      string         `json:"tool"`
+	Arguments map[string]any `json:"arguments"`
+
+	// At is the delivery time of a one-shot job.
+	At time.Time `json:"at,omitempty"`
+	// Cron is the schedule of a recurring job.
+	Cron string `json:"cron,omitempty"`
+
+	Status     string    `json:"status"`
+	NextRun    time.Time `json:"next_run,omitempty"`
+	LastRun    time.Time `json:"last_run,omitempty"`
+	LastResult string    `json:"last_result,omitempty"`
+	LastError  string    `json:"last_error,omitempty"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+// Scheduler keeps pending message jobs and runs them when they are due.
+// When a state file is configured, jobs are persisted and survive restarts;
+// jobs that fell due while the server was down run on the next start.
+type Scheduler struct {
+	mu         sync.Mutex
+	jobs       map[string]*ScheduledJob
+	schedules  map[string]cron.Schedule
+	path       string
+	dispatcher Dispatcher
+	now        func() time.Time
+
+	stop chan struct{}
+	done chan struct{}
+}
+
+// NewScheduler creates a scheduler that persists jobs to path (if non-empty)
+// and loads any jobs saved there.
+func NewScheduler(path string, dispatcher Dispatcher) (*Scheduler, error) {
+	s := &Scheduler{
+		jobs:       make(map[string]*ScheduledJob),
+		schedules:  make(map[string]cron.Schedule),
+		path:       path,
+		dispatcher: dispatcher,
+		now:        time.Now,
+	}
+	if err := s.load(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// Start runs the scheduler loop in the background until Stop is called.
+func (s *Scheduler) Start() {
+	s.stop = make(chan struct{})
+	s.done = make(chan struct{})
+	go func() {
+		defer close(s.done)
+		ticker := time.NewTicker(schedulerTickInterval)
+		defer ticker.Stop()
+
+		s.runDue()
+		for {
+			select {
+			case <-ticker.C:
+				s.runDue()
+			case <-s.stop:
+				return
+			}
+		}
+	}()
+}
+
+// Stop stops the scheduler loop and waits for it to exit.
+func (s *Scheduler) Stop() {
+	if s.stop == nil {
+		return
+	}
+	close(s.stop)
+	<-s.done
+	s.stop = nil
+}
+
+// parseCronSchedule parses a standard 5-field cron expression or descriptor
+// (e.g. "@daily"), evaluated in the given IANA time zone when non-empty.
+func parseCronSchedule(expr, timezone string) (string, cron.Schedule, error) {
+	expr = strings.TrimSpace(expr)
+	if timezone != "" && !strings.HasPrefix(expr, "CRON_TZ=") && !strings.HasPrefix(expr, "TZ=") {
+		if _, err := time.LoadLocation(timezone); err != nil {
+			return "", nil, fmt.Errorf("invalid timezone %q: %w", timezone, err)
+		}
+		expr = "CRON_TZ=" + timezone + " " + expr
+	}
+	schedule, err := cron.ParseStandard(expr)
+	if err != nil {
+		return "", nil, fmt.Errorf("invalid cron expression %q: %w", expr, err)
+	}
+	return expr, schedule, nil
+}
+
+// Add schedules a tool call either once at the given time or on a cron schedule.
+func (s *Scheduler) Add(tool string, arguments map[string]any, at time.Time, cronExpr string) (*ScheduledJob, error) {
+	if !IsSendTool(tool) {
+		return nil, fmt.Errorf("only send_* tools can be scheduled, got %q", tool)
+	}
+	if !s.dispatcher.HasTool(tool) {
+		return nil, fmt.Errorf("tool %q is not available", tool)
+	}
+
+	now := s.now()
+	job := &ScheduledJob{
+		Tool:      tool,
+		Arguments: arguments,
+		Status:    JobStatusScheduled,
+		CreatedAt: now,
+	}
+
+	var schedule cron.Schedule
+	switch {
+	case cronExpr != "" && !at.IsZero():
+		return nil, fmt.Errorf("specify either at or cron, not both")
+	case cronExpr != "":
+		var err error
+		if job.Cron, schedule, err = parseCronSchedule(cronExpr, ""); err != nil {
+			return nil, err
+		}
+		job.NextRun = schedule.Next(now)
+		if job.NextRun.IsZero() {
+			return nil, fmt.Errorf("cron expression %q never fires", cronExpr)
+		}
+	case !at.IsZero():
+		if !at.After(now) {
+			return nil, fmt.Errorf("at must be in the future, got %s", at.Format(time.RFC3339))
+		}
+		job.At = at
+		job.NextRun = at
+	default:
+		return nil, fmt.Errorf("either at or cron is required")
+	}
+
+	id, err := newJobID()
+	if err != nil {
+		return nil, err
+	}
+	job.ID = id
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.pendingCountLocked() >= maxScheduledJobs {
+		return nil, fmt.Errorf("too many scheduled jobs (maximum %d)", maxScheduledJobs)
+	}
+	s.jobs[job.ID] = job
+	if schedule != nil {
+		s.schedules[job.ID] = schedule
+	}
+	if err := s.saveLocked(); err != nil {
+		delete(s.jobs, job.ID)
+		delete(s.schedules, job.ID)
+		return nil, err
+	}
+
+	clone := *job
+	return &clone, nil
+}
+
+// List returns all jobs ordered by next run time.
+func (s *Scheduler) List() []ScheduledJob {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	jobs := make([]ScheduledJob, 0, len(s.jobs))
+	for _, job := range s.jobs {
+		jobs = append(jobs, *job)
+	}
+	sort.Slice(jobs, func(i, j int) bool {
+		if jobs[i].NextRun.Equal(jobs[j].NextRun) {
+			return jobs[i].CreatedAt.Before(jobs[j].CreatedAt)
+		}
+		return jobs[i].NextRun.Before(jobs[j].NextRun)
+	})
+	return jobs
+}
+
+// Cancel removes a job.
+func (s *Scheduler) Cancel(id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.jobs[id]; !ok {
+		return fmt.Errorf("no scheduled job with id %q", id)
+	}
+	delete(s.jobs, id)
+	delete(s.schedules, id)
+	return s.saveLocked()
+}
+
+// runDue runs every job whose next run time has passed.
+func (s *Scheduler) runDue() {
+	now := s.now()
+
+	s.mu.Lock()
+	var due []ScheduledJob
+	for id, job := range s.jobs {
+		if job.Status != JobStatusScheduled {
+			if now.Sub(job.LastRun) > finishedJobRetention {
+				delete(s.jobs, id)
+			}
+			continue
+		}
+		if !job.NextRun.After(now) {
+			due = append(due, *job)
+		}
+	}
+	s.mu.Unlock()
+
+	sort.Slice(due, func(i, j int) bool { return due[i].NextRun.Before(due[j].NextRun) })
+	for _, job := range due {
+		result, err := s.dispatcher.CallTool(job.Tool, job.Arguments)
+		if err != nil {
+			logging.Error("Scheduled job %s (%s) failed: %v", job.ID, job.Tool, err)
+		} else {
+			logging.Info("Scheduled job %s (%s) ran: %s", job.ID, job.Tool, result)
+		}
+		s.finish(job.ID, now, result, err)
+	}
+
+	if len(due) > 0 {
+		s.mu.Lock()
+		if err := s.saveLocked(); err != nil {
+			logging.Error("Failed to save scheduled jobs: %v", err)
+		}
+		s.mu.Unlock()
+	}
+}
+
+// finish records the outcome of a run and computes the next run time.
+func (s *Scheduler) finish(id string, ranAt time.Time, result string, runErr error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	job, ok := s.jobs[id]
+	if !ok {
+		// Cancelled while running
+		return
+	}
+	job.LastRun = ranAt
+	job.LastResult = result
+	job.LastError = ""
+	if runErr != nil {
+		job.LastError = runErr.Error()
+	}
+
+	if schedule, ok := s.schedules[id]; ok {
+		job.NextRun = schedule.Next(ranAt)
+		return
+	}
+
+	job.NextRun = time.Time{}
+	job.Status = JobStatusDone
+	if runErr != nil {
+		job.Status = JobStatusFailed
+	}
+}
+
+// pendingCountLocked counts jobs that have not finished. The caller must hold s.mu.
+func (s *Scheduler) pendingCountLocked() int {
+	count := 0
+	for _, job := range s.jobs {
+		if job.Status == JobStatusScheduled {
+			count++
+		}
+	}
+	return count
+}
+
+// load reads persisted jobs from the state file, if any.
+func (s *Scheduler) load() error {
+	if s.path == "" {
+		return nil
+	}
+	data, err := os.ReadFile(s.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read schedule file: %w", err)
+	}
+
+	var jobs []*ScheduledJob
+	if err := json.Unmarshal(data, &jobs); err != nil {
+		return fmt.Errorf("failed to parse schedule file %s: %w", s.path, err)
+	}
+	for _, job := range jobs {
+		if job.Cron != "" {
+			_, schedule, err := parseCronSchedule(job.Cron, "")
+			if err != nil {
+				return fmt.Errorf("scheduled job %s: %w", job.ID, err)
+			}
+			s.schedules[job.ID] = schedule
+		}
+		s.jobs[job.ID] = job
+	}
+	logging.Info("Loaded %d scheduled job(s) from %s", len(jobs), s.path)
+	return nil
+}
+
+// saveLocked atomically writes all jobs to the state file. The caller must hold s.mu.
+func (s *Scheduler) saveLocked() error {
+	if s.path == "" {
+		return nil
+	}
+
+	jobs := make([]*ScheduledJob, 0, len(s.jobs))
+	for _, job := range s.jobs {
+		jobs = append(jobs, job)
+	}
+	sort.Slice(jobs, func(i, j int) bool { return jobs[i].CreatedAt.Before(jobs[j].CreatedAt) })
+
+	data, err := json.MarshalIndent(jobs, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode scheduled jobs: %w", err)
+	}
+
+	if err := writeFileAtomic(s.path, data); err != nil {
+		return fmt.Errorf("failed to save schedule file: %w", err)
+	}
+	return nil
+}
+
+// writeFileAtomic writes data to a temporary file next to path and renames it
+// into place, so readers never see a partially written file.
+func writeFileAtomic(path string, data []byte) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// newJobID returns a random job identifier.
+func newJobID() (string, error) {
+	id, err := newRandomID()
+	if err != nil {
+		return "", fmt.Errorf("failed to generate job id: %w", err)
+	}
+	return id, nil
+}
+
+// newRandomID returns 12 random hexadecimal digits.
+func newRandomID() (string, error) {
+	buf := make([]byte, 6)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(buf), nil
+}
+
+// formatJob renders a job as a single human-readable line.
+func formatJob(job ScheduledJob) string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "- %s: %s", job.ID, job.Tool)
+	if job.Cron != "" {
+		fmt.Fprintf(&sb, ", cron %q", job.Cron)
+	} else {
+		fmt.Fprintf(&sb, ", at %s", job.At.Format(time.RFC3339))
+	}
+	fmt.Fprintf(&sb, ", status: %s", job.Status)
+	if !job.NextRun.IsZero() {
+		fmt.Fprintf(&sb, ", next run: %s", job.NextRun.Format(time.RFC3339))
+	}
+	if job.LastError != "" {
+		fmt.Fprintf(&sb, ", last error: %s", job.LastError)
+	} else if job.LastResult != "" {
+		fmt.Fprintf(&sb, ", last result: %s", job.LastResult)
+	}
+	return sb.String()
+}
+
+// handleScheduleMessage handles the schedule_message tool call.
+func handleScheduleMessage(client any, params map[string]any) (string, error) {
+	c, err := getClient(client)
+	if err != nil {
+		return "", err
+	}
+	if c.Scheduler == nil {
+		return "", fmt.Errorf("message scheduling is not available")
+	}
+
+	tool := stringParam(params, "tool")
+	if tool == "" {
+		return "", fmt.Errorf("tool is required")
+	}
+	arguments := mapParam(params, "arguments")
+	if arguments == nil {
+		return "", fmt.Errorf("arguments is required")
+	}
+
+	var at time.Time
+	if raw := stringParam(params, "at"); raw != "" {
+		if at, err = time.Parse(time.RFC3339, raw); err != nil {
+			return "", fmt.Errorf("at must be an RFC3339 time (e.g. 2025-01-02T17:00:00+08:00): %w", err)
+		}
+	}
+
+	cronExpr := stringParam(params, "cron")
+	if cronExpr != "" {
+		if cronExpr, _, err = parseCronSchedule(cronExpr, stringParam(params, "timezone")); err != nil {
+			return "", err
+		}
+	}
+
+	job, err := c.Scheduler.Add(tool, arguments, at, cronExpr)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("Message scheduled. job_id: %s, next run: %s", job.ID, job.NextRun.Format(time.RFC3339)), nil
+}
+
+// handleListScheduled handles the list_scheduled tool call.
+func handleListScheduled(client any, _ map[string]any) (string, error) {
+	c, err := getClient(client)
+	if err != nil {
+		return "", err
+	}
+	if c.Scheduler == nil {
+		return "", fmt.Errorf("message scheduling is not available")
+	}
+
+	jobs := c.Scheduler.List()
+	if len(jobs) == 0 {
+		return "No scheduled messages", nil
+	}
+
+	lines := make([]string, 0, len(jobs)+1)
+	lines = append(lines, fmt.Sprintf("%d scheduled message(s):", len(jobs)))
+	for _, job := range jobs {
+		lines = append(lines, formatJob(job))
+	}
+	return strings.Join(lines, "\n"), nil
+}
+
+// handleCancelScheduled handles the cancel_scheduled tool call.
+func handleCancelScheduled(client any, params map[string]any) (string, error) {
+	c, err := getClient(client)
+	if err != nil {
+		return "", err
+	}
+	if c.Scheduler == nil {
+		return "", fmt.Errorf("message scheduling is not available")
+	}
+
+	id := stringParam(params, "job_id")
+	if id == "" {
+		return "", fmt.Errorf("job_id is required")
+	}
+	if err := c.Scheduler.Cancel(id); err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("Scheduled job %s cancelled", id), nil
+}
@@ -0,0 +1,227 @@
+package wecom
+
+import (
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+type fakeDispatcher struct {
+	calls []string
+	err   error
+}
+
+func (d *fakeDispatcher) HasTool(name string) bool {
+	return name == "send_text" || name == "send_markdown"
+}
+
+func (d *fakeDispatcher) CallTool(name string, params map[string]any) (string, error) {
+	d.calls = append(d.calls, name+":"+stringParam(params, "content"))
+	if d.err != nil {
+		return "", d.err
+	}
+	return "sent", nil
+}
+
+func newTestScheduler(t *testing.T, path string, dispatcher Dispatcher, now *time.Time) *Scheduler {
+	t.Helper()
+	s, err := NewScheduler(path, dispatcher)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	s.now = func() time.Time { return *now }
+	return s
+}
+
+func TestSchedulerAdd_Validation(t *testing.T) {
+	now := time.Date(2025, 1, 2, 9, 0, 0, 0, time.UTC)
+	s := newTestScheduler(t, "", &fakeDispatcher{}, &now)
+	args := map[string]any{"content": "hi"}
+
+	tests := []struct {
+		name    string
+		tool    string
+		at      time.Time
+		cron    string
+		wantErr string
+	}{
+		{name: "not a send tool", tool: "upload_file", at: now.Add(time.Hour), wantErr: "only send_* tools"},
+		{name: "disabled tool", tool: "send_image", at: now.Add(time.Hour), wantErr: "not available"},
+		{name: "no schedule", tool: "send_text", wantErr: "either at or cron is required"},
+		{name: "both", tool: "send_text", at: now.Add(time.Hour), cron: "@daily", wantErr: "not both"},
+		{name: "past", tool: "send_text", at: now.Add(-time.Minute), wantErr: "must be in the future"},
+		{name: "bad cron", tool: "send_text", cron: "not a cron", wantErr: "invalid cron expression"},
+	}
+	for _, tt := range tests {
+		_, err := s.Add(tt.tool, args, tt.at, tt.cron)
+		if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+			t.Errorf("%s: expected error containing %q, got %v", tt.name, tt.wantErr, err)
+		}
+	}
+}
+
+func TestScheduler_OneShotRunsOnce(t *testing.T) {
+	now := time.Date(2025, 1, 2, 9, 0, 0, 0, time.UTC)
+	dispatcher := &fakeDispatcher{}
+	s := newTestScheduler(t, "", dispatcher, &now)
+
+	job, err := s.Add("send_text", map[string]any{"content": "standup"}, now.Add(time.Hour), "")
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	s.runDue()
+	if len(dispatcher.calls) != 0 {
+		t.Fatal("expected job not to run before it is due")
+	}
+
+	now = now.Add(time.Hour)
+	s.runDue()
+	s.runDue()
+	if len(dispatcher.calls) != 1 || dispatcher.calls[0] != "send_text:standup" {
+		t.Fatalf("expected job to run exactly once, got %v", dispatcher.calls)
+	}
+
+	jobs := s.List()
+	if len(jobs) != 1 || jobs[0].ID != job.ID || jobs[0].Status != JobStatusDone || jobs[0].LastResult != "sent" {
+		t.Fatalf("unexpected job state: %+v", jobs)
+	}
+
+	now = now.Add(finishedJobRetention + time.Minute)
+	s.runDue()
+	if len(s.List()) != 0 {
+		t.Fatal("expected finished job to be pruned after retention")
+	}
+}
+
+func TestScheduler_FailedRunIsRecorded(t *testing.T) {
+	now := time.Date(2025, 1, 2, 9, 0, 0, 0, time.UTC)
+	dispatcher := &fakeDispatcher{err: errors.New("boom")}
+	s := newTestScheduler(t, "", dispatcher, &now)
+
+	if _, err := s.Add("send_text", map[string]any{"content": "x"}, now.Add(time.Minute), ""); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	now = now.Add(time.Minute)
+	s.runDue()
+
+	jobs := s.List()
+	if jobs[0].Status != JobStatusFailed || jobs[0].LastError != "boom" {
+		t.Fatalf("expected failed job with error, got %+v", jobs[0])
+	}
+}
+
+func TestScheduler_CronRecurs(t *testing.T) {
+	now := time.Date(2025, 1, 2, 9, 0, 0, 0, time.UTC)
+	dispatcher := &fakeDispatcher{}
+	s := newTestScheduler(t, "", dispatcher, &now)
+
+	job, err := s.Add("send_text", map[string]any{"content": "tick"}, time.Time{}, "CRON_TZ=UTC 0 17 * * *")
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if want := time.Date(2025, 1, 2, 17, 0, 0, 0, time.UTC); !job.NextRun.Equal(want) {
+		t.Fatalf("expected next run %s, got %s", want, job.NextRun)
+	}
+
+	now = time.Date(2025, 1, 2, 17, 0, 0, 0, time.UTC)
+	s.runDue()
+	jobs := s.List()
+	if len(dispatcher.calls) != 1 || jobs[0].Status != JobStatusScheduled {
+		t.Fatalf("expected recurring job to stay scheduled, calls=%v jobs=%+v", dispatcher.calls, jobs)
+	}
+	if want := time.Date(2025, 1, 3, 17, 0, 0, 0, time.UTC); !jobs[0].NextRun.Equal(want) {
+		t.Fatalf("expected next run %s, got %s", want, jobs[0].NextRun)
+	}
+}
+
+func TestScheduler_PersistsAcrossRestarts(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "schedule.json")
+	now := time.Date(2025, 1, 2, 9, 0, 0, 0, time.UTC)
+
+	s := newTestScheduler(t, path, &fakeDispatcher{}, &now)
+	job, err := s.Add("send_text", map[string]any{"content": "later"}, now.Add(time.Hour), "")
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if _, err := s.Add("send_markdown", map[string]any{"content": "daily"}, time.Time{}, "@daily"); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	// The server was down when the one-shot job fell due; it runs on restart
+	now = now.Add(2 * time.Hour)
+	dispatcher := &fakeDispatcher{}
+	restarted := newTestScheduler(t, path, dispatcher, &now)
+	if got := len(restarted.List()); got != 2 {
+		t.Fatalf("expected 2 persisted jobs, got %d", got)
+	}
+	restarted.runDue()
+	if len(dispatcher.calls) != 1 || dispatcher.calls[0] != "send_text:later" {
+		t.Fatalf("expected overdue job to run after restart, got %v", dispatcher.calls)
+	}
+
+	if err := restarted.Cancel(job.ID); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	again := newTestScheduler(t, path, dispatcher, &now)
+	if got := len(again.List()); got != 1 {
+		t.Fatalf("expected cancellation to be persisted, got %d jobs", got)
+	}
+}
+
+func TestHandleScheduleMessage(t *testing.T) {
+	now := time.Now()
+	c := NewClient(newTestBot(t))
+	c.Scheduler = newTestScheduler(t, "", &fakeDispatcher{}, &now)
+
+	_, err := handleScheduleMessage(c, map[string]any{
+		"tool":      "send_text",
+		"arguments": map[string]any{"content": "hi"},
+		"at":        "tomorrow at five",
+	})
+	if err == nil || !strings.Contains(err.Error(), "RFC3339") {
+		t.Fatalf("expected RFC3339 error, got %v", err)
+	}
+
+	_, err = handleScheduleMessage(c, map[string]any{
+		"tool":      "send_text",
+		"arguments": map[string]any{"content": "hi"},
+		"cron":      "0 17 * * 1-5",
+		"timezone":  "Mars/Olympus",
+	})
+	if err == nil || !strings.Contains(err.Error(), "invalid timezone") {
+		t.Fatalf("expected timezone error, got %v", err)
+	}
+
+	result, err := handleScheduleMessage(c, map[string]any{
+		"tool":      "send_text",
+		"arguments": map[string]any{"content": "hi"},
+		"cron":      "0 17 * * 1-5",
+		"timezone":  "Asia/Shanghai",
+	})
+	if err != nil || !strings.Contains(result, "job_id:") {
+		t.Fatalf("expected job to be scheduled, got %q, %v", result, err)
+	}
+
+	list, err := handleListScheduled(c, nil)
+	if err != nil || !strings.Contains(list, `cron "CRON_TZ=Asia/Shanghai 0 17 * * 1-5"`) {
+		t.Fatalf("unexpected list result: %q, %v", list, err)
+	}
+
+	id := c.Scheduler.List()[0].ID
+	if _, err := handleCancelScheduled(c, map[string]any{"job_id": id}); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if list, _ := handleListScheduled(c, nil); list != "No scheduled messages" {
+		t.Fatalf("expected empty list, got %q", list)
+	}
+}
+
+func TestHandleScheduleMessage_NotAvailable(t *testing.T) {
+	_, err := handleScheduleMessage(newTestBot(t), map[string]any{"tool": "send_text"})
+	if err == nil || !strings.Contains(err.Error(), "not available") {
+		t.Fatalf("expected not available error, got %v", err)
+	}
+}
@@ -24,32 +24,38 @@ func (t *Toolset) GetDescription() string {
 
 // GetTools returns all WeCom bot tools.
 func (t *Toolset) GetTools(_ any) []toolset.ServerTool {
-	return []toolset.ServerTool{
+	tools := []toolset.ServerTool{
 		{
 			Tool: mcp.NewTool("send_text",
-				mcp.WithDescription("Send a text message through a WeCom bot webhook. Supports @mentioning users by ID or mobile number."),
+				mcp.WithDescription("Send a text message through a WeCom bot webhook. Supports @mentioning users by ID or mobile number, or by name, alias or @team when a user directory is configured."),
 				mcp.WithString("content",
 					mcp.Required(),
 					mcp.Description("The text content to send. Maximum 2048 bytes."),
 				),
 				mcp.WithArray("mentioned_list",
-					mcp.Description("List of user IDs to @mention. Use \"@all\" to mention everyone."),
+					mcp.Description("List of users to @mention. Accepts user IDs, or names, aliases and team names (e.g. \"@backend-oncall\") when a user directory is configured; use lookup_users to find them. Use \"@all\" to mention everyone."),
 					mcp.Items(map[string]any{"type": "string"}),
 				),
 				mcp.WithArray("mentioned_mobile_list",
 					mcp.Description("List of mobile numbers to @mention. Use \"@all\" to mention everyone."),
 					mcp.Items(map[string]any{"type": "string"}),
 				),
+				withPriority(),
 			),
 			Handler: handleSendText,
 		},
 		{
 			Tool: mcp.NewTool("send_markdown",
-				mcp.WithDescription("Send a Markdown message through a WeCom bot webhook. Supports headings, bold, links, quotes, etc."),
+				mcp.WithDescription("Send a Markdown message through a WeCom bot webhook. Supports headings, bold, links, quotes, etc., and @mentions with the <@userid> syntax."),
 				mcp.WithString("content",
 					mcp.Required(),
 					mcp.Description("The markdown content to send. Maximum 4096 bytes."),
 				),
+				mcp.WithArray("mentioned_list",
+					mcp.Description("List of users to @mention. Accepts user IDs, or names, aliases and team names when a user directory is configured. Users not already mentioned inline with <@userid> are mentioned on a line appended to the content. \"@all\" is not supported; use send_text to mention everyone."),
+					mcp.Items(map[string]any{"type": "string"}),
+				),
+				withPriority(),
 			),
 			Handler: handleSendMarkdown,
 		},
@@ -64,9 +70,81 @@ func (t *Toolset) GetTools(_ any) []toolset.ServerTool {
 					mcp.Required(),
 					mcp.Description("MD5 hash of the original image content (before base64 encoding)."),
 				),
+				withPriority(),
 			),
 			Handler: handleSendImage,
 		},
+		{
+			Tool: mcp.NewTool("send_chart",
+				mcp.WithDescription("Render a line, bar or pie chart, or a table, to a PNG image on the server and send it as an image message. Text is drawn in an ASCII bitmap font; other characters are shown as a placeholder."),
+				mcp.WithString("type",
+					mcp.Required(),
+					mcp.Enum("line", "bar", "pie", "table"),
+					mcp.Description("Chart type. line, bar and pie use labels and series; table uses columns and rows."),
+				),
+				mcp.WithString("title",
+					mcp.Description("Title drawn above the chart (optional, up to 100 characters)."),
+				),
+				mcp.WithArray("labels",
+					mcp.Description("X-axis categories of line and bar charts, or slice names of a pie chart (1-100 items)."),
+					mcp.Items(map[string]any{"type": "string"}),
+				),
+				mcp.WithArray("series",
+					mcp.Description("Data series of line, bar and pie charts (1-8 series; a pie chart takes exactly one). Each series has one value per label."),
+					mcp.Items(map[string]any{
+						"type": "object",
+						"properties": map[string]any{
+							"name": map[string]any{
+								"type":        "string",
+								"description": "Series name shown in the legend (optional).",
+							},
+							"values": map[string]any{
+								"type":        "array",
+								"items":       map[string]any{"type": "number"},
+								"description": "Values, one per label (required). Pie chart values must not be negative.",
+							},
+						},
+						"required": []string{"values"},
+					}),
+				),
+				mcp.WithArray("columns",
+					mcp.Description("Table column headers (1-12 items)."),
+					mcp.Items(map[string]any{"type": "string"}),
+				),
+				mcp.WithArray("rows",
+					mcp.Description("Table rows (1-50 rows), each an array of cell strings."),
+					mcp.Items(map[string]any{
+						"type":  "array",
+						"items": map[string]any{"type": "string"},
+					}),
+				),
+				mcp.WithNumber("width",
+					mcp.Description("Image width in pixels (200-2000). Defaults to 800; tables are sized to fit."),
+				),
+				mcp.WithNumber("height",
+					mcp.Description("Image height in pixels (200-2000). Defaults to 480; tables are sized to fit."),
+				),
+				withPriority(),
+			),
+			Handler: handleSendChart,
+		},
+		{
+			Tool: mcp.NewTool("send_rendered",
+				mcp.WithDescription("Render markdown to a PNG image on the server and send it as an image message, optionally followed by a short text summary. Use it for content WeCom markdown displays poorly, such as long tables and code blocks. Supports GitHub-flavored markdown: headings, emphasis, links, lists, task lists, block quotes, tables and fenced code blocks with syntax highlighting. Raw HTML is shown as source and images as their alt text. Text covers Latin, Greek and Cyrillic; other characters such as CJK are shown as a placeholder box."),
+				mcp.WithString("markdown",
+					mcp.Required(),
+					mcp.Description("Markdown to render (up to 32KB)."),
+				),
+				mcp.WithNumber("width",
+					mcp.Description("Image width in pixels (300-2000). Defaults to 800; the height fits the content, up to 8000 pixels."),
+				),
+				mcp.WithString("summary",
+					mcp.Description("Short text message sent after the image (optional, up to 2048 bytes)."),
+				),
+				withPriority(),
+			),
+			Handler: handleSendRendered,
+		},
 		{
 			Tool: mcp.NewTool("send_news",
 				mcp.WithDescription("Send a news message (article list) through a WeCom bot webhook. Accepts 1-8 articles."),
@@ -96,6 +174,7 @@ func (t *Toolset) GetTools(_ any) []toolset.ServerTool {
 						"required": []string{"title", "url"},
 					}),
 				),
+				withPriority(),
 			),
 			Handler: handleSendNews,
 		},
@@ -181,7 +260,9 @@ func (t *Toolset) GetTools(_ any) []toolset.ServerTool {
 							"description": "URL to open when the card is clicked (required).",
 						},
 					}),
+					requiredProperties("url"),
 				),
+				withPriority(),
 			),
 			Handler: handleSendTextNoticeCard,
 		},
@@ -221,13 +302,31 @@ func (t *Toolset) GetTools(_ any) []toolset.ServerTool {
 							"description": "URL to open when the card is clicked (required).",
 						},
 					}),
+					requiredProperties("url"),
 				),
+				withPriority(),
 			),
 			Handler: handleSendNewsNoticeCard,
 		},
+		{
+			Tool: mcp.NewTool("send_file",
+				mcp.WithDescription("Send a file message through a WeCom bot webhook. Give either the media_id of a file uploaded with upload_file or finish_upload, or the file itself, which is uploaded first."),
+				mcp.WithString("media_id",
+					mcp.Description("media_id of an uploaded file. Mutually exclusive with base64_data."),
+				),
+				mcp.WithString("filename",
+					mcp.Description("Name of the file to upload and send. Required with base64_data."),
+				),
+				mcp.WithString("base64_data",
+					mcp.Description("Base64-encoded file content to upload and send. Max file size: 20MB."),
+				),
+				withPriority(),
+			),
+			Handler: handleSendFile,
+		},
 		{
 			Tool: mcp.NewTool("upload_file",
-				mcp.WithDescription("Upload a file to the WeCom server (up to 20MB). Returns a media_id you can use to send file messages."),
+				mcp.WithDescription("Upload a file to the WeCom server (up to 20MB). Returns a media_id you can use to send file messages. Uploading the same file again reuses its media_id while it is valid."),
 				mcp.WithString("filename",
 					mcp.Required(),
 					mcp.Description("Name of the file to upload."),
@@ -239,5 +338,159 @@ func (t *Toolset) GetTools(_ any) []toolset.ServerTool {
 			),
 			Handler: handleUploadFile,
 		},
+		{
+			Tool: mcp.NewTool("begin_upload",
+				mcp.WithDescription("Start a chunked upload of a file to the WeCom server (up to 20MB), for files too large to pass to upload_file in one argument. Send the content with upload_chunk, then call finish_upload to verify the checksum and get the media_id."),
+				mcp.WithString("filename",
+					mcp.Required(),
+					mcp.Description("Name of the file to upload."),
+				),
+				mcp.WithNumber("size",
+					mcp.Required(),
+					mcp.Description("Size of the file in bytes. Max file size: 20MB."),
+				),
+				mcp.WithString("sha256",
+					mcp.Required(),
+					mcp.Description("Hexadecimal SHA-256 checksum of the whole file, verified by finish_upload."),
+				),
+			),
+			Handler: handleBeginUpload,
+		},
+		{
+			Tool: mcp.NewTool("upload_chunk",
+				mcp.WithDescription("Send the next chunk of a file started with begin_upload. Chunks must be contiguous; a chunk can be retried by sending it again at the same offset."),
+				mcp.WithString("upload_id",
+					mcp.Required(),
+					mcp.Description("The upload_id returned by begin_upload."),
+				),
+				mcp.WithNumber("offset",
+					mcp.Required(),
+					mcp.Description("Byte offset of the chunk in the file, starting at 0. Use the next offset reported by the previous call."),
+				),
+				mcp.WithString("base64_data",
+					mcp.Required(),
+					mcp.Description("Base64-encoded chunk content. Max chunk size: 1MB before encoding."),
+				),
+			),
+			Handler: handleUploadChunk,
+		},
+		{
+			Tool: mcp.NewTool("finish_upload",
+				mcp.WithDescription("Complete a chunked upload: verify the size and SHA-256 checksum of the received file and upload it. Returns a media_id you can use to send file messages."),
+				mcp.WithString("upload_id",
+					mcp.Required(),
+					mcp.Description("The upload_id returned by begin_upload."),
+				),
+			),
+			Handler: handleFinishUpload,
+		},
+		{
+			Tool: mcp.NewTool("list_media",
+				mcp.WithDescription("List files uploaded with upload_file whose media_id has not expired yet. WeCom media_ids are valid for 3 days."),
+			),
+			Handler: handleListMedia,
+		},
+		{
+			Tool: mcp.NewTool("schedule_message",
+				mcp.WithDescription("Schedule a message to be sent later, either once at an RFC3339 time or repeatedly on a cron schedule. The message is described by the send_* tool to call and its arguments."),
+				mcp.WithString("tool",
+					mcp.Required(),
+					mcp.Description("Name of the send tool to call, e.g. \"send_text\" or \"send_markdown\"."),
+				),
+				mcp.WithObject("arguments",
+					mcp.Required(),
+					mcp.Description("Arguments for the send tool, exactly as it would be called directly."),
+				),
+				mcp.WithString("at",
+					mcp.Description("RFC3339 time to send the message once, e.g. \"2025-01-02T17:00:00+08:00\". Mutually exclusive with cron."),
+				),
+				mcp.WithString("cron",
+					mcp.Description("Standard 5-field cron expression (e.g. \"0 17 * * 1-5\") or descriptor (e.g. \"@daily\") for recurring messages. Mutually exclusive with at."),
+				),
+				mcp.WithString("timezone",
+					mcp.Description("IANA time zone for the cron expression, e.g. \"Asia/Shanghai\". Defaults to the server's local time zone."),
+				),
+			),
+			Handler: handleScheduleMessage,
+		},
+		{
+			Tool: mcp.NewTool("list_scheduled",
+				mcp.WithDescription("List scheduled messages with their next run time and the result of their last run."),
+			),
+			Handler: handleListScheduled,
+		},
+		{
+			Tool: mcp.NewTool("cancel_scheduled",
+				mcp.WithDescription("Cancel a scheduled message."),
+				mcp.WithString("job_id",
+					mcp.Required(),
+					mcp.Description("The job_id returned by schedule_message."),
+				),
+			),
+			Handler: handleCancelScheduled,
+		},
+		{
+			Tool: mcp.NewTool("flush_digest",
+				mcp.WithDescription("Send all text and markdown messages buffered in digest mode now, instead of waiting for the digest window to expire."),
+			),
+			Handler: handleFlushDigest,
+		},
+		{
+			Tool: mcp.NewTool("lookup_users",
+				mcp.WithDescription("Look up users in the configured user directory by name, alias, user ID or team. Use it to find who can be @mentioned."),
+				mcp.WithString("query",
+					mcp.Description("Case-insensitive text to search for. Leave empty to list everyone."),
+				),
+			),
+			Handler: handleLookupUsers,
+		},
+		{
+			Tool: mcp.NewTool("get_incoming_messages",
+				mcp.WithDescription("Get the messages users sent to the bot by @-mentioning it in a group or chatting with it directly, oldest first. Poll with after_id set to the last id seen to follow a conversation."),
+				mcp.WithNumber("after_id",
+					mcp.Description("Only return messages with an id greater than this. Omit to get the most recent messages."),
+				),
+				mcp.WithString("chat_id",
+					mcp.Description("Only return messages from this group chat."),
+				),
+				mcp.WithNumber("limit",
+					mcp.Description("Maximum number of messages to return, from 1 to 100. Defaults to 20."),
+				),
+			),
+			Handler: handleGetIncomingMessages,
+		},
+		{
+			Tool: mcp.NewTool("confirm_send",
+				mcp.WithDescription("Send a message that is waiting for human approval (only used when require_confirmation is enabled). Call this ONLY after the user has explicitly approved the previewed message."),
+				mcp.WithString("pending_id",
+					mcp.Required(),
+					mcp.Description("The pending_id returned when the message was held for confirmation."),
+				),
+			),
+			Handler: handleConfirmSend,
+		},
 	}
+
+	// Every send tool runs through the same send policies
+	for i, tool := range tools {
+		if IsSendTool(tool.Tool.Name) {
+			tools[i].Handler = withSendPolicies(tool.Tool.Name, tool.Handler)
+		}
+	}
+	return tools
+}
+
+// requiredProperties marks properties of an object argument as required.
+func requiredProperties(names ...string) mcp.PropertyOption {
+	return func(schema map[string]any) {
+		schema["required"] = names
+	}
+}
+
+// withPriority adds the priority argument shared by all send tools.
+func withPriority() mcp.ToolOption {
+	return mcp.WithString("priority",
+		mcp.Enum(priorityNormal, priorityUrgent),
+		mcp.Description("Message priority. \"urgent\" messages bypass quiet hours and digest batching. Defaults to \"normal\"."),
+	)
 }
@@ -0,0 +1,242 @@
+package wecom
+
+import (
+	"encoding/base64"
+	"fmt"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// uploadSessionTTL is how long a chunked upload stays open after its last chunk.
+	uploadSessionTTL = 30 * time.Minute
+
+	// maxUploadSessions bounds the number of open chunked uploads, and with
+	// it the memory held by partially received files.
+	maxUploadSessions = 5
+
+	// maxUploadChunkBytes is the largest decoded chunk accepted by upload_chunk.
+	maxUploadChunkBytes = 1024 * 1024
+)
+
+// sha256Pattern matches a hexadecimal SHA-256 checksum.
+var sha256Pattern = regexp.MustCompile(`^[0-9a-fA-F]{64}$`)
+
+// uploadSession is a file being received in chunks.
+type uploadSession struct {
+	filename  string
+	size      int
+	sha256    string
+	data      []byte
+	expiresAt time.Time
+}
+
+// uploadStore keeps open chunked uploads, keyed by upload ID.
+type uploadStore struct {
+	mu       sync.Mutex
+	sessions map[string]*uploadSession
+	now      func() time.Time
+}
+
+func newUploadStore() *uploadStore {
+	return &uploadStore{
+		sessions: make(map[string]*uploadSession),
+		now:      time.Now,
+	}
+}
+
+// begin opens a chunked upload and returns its upload ID.
+func (u *uploadStore) begin(filename string, size int, checksum string) (string, error) {
+	id, err := newRandomID()
+	if err != nil {
+		return "", fmt.Errorf("failed to generate upload_id: %w", err)
+	}
+
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	u.pruneLocked()
+	if len(u.sessions) >= maxUploadSessions {
+		return "", fmt.Errorf("too many uploads in progress (maximum %d); finish them or wait %s for them to expire", maxUploadSessions, uploadSessionTTL)
+	}
+	u.sessions[id] = &uploadSession{
+		filename:  filename,
+		size:      size,
+		sha256:    strings.ToLower(checksum),
+		data:      make([]byte, 0, size),
+		expiresAt: u.now().Add(uploadSessionTTL),
+	}
+	return id, nil
+}
+
+// write stores a chunk at offset and returns the number of bytes received so
+// far. Chunks must be contiguous: offset may repeat earlier bytes, e.g. when a
+// chunk is retried, but must not leave a gap.
+func (u *uploadStore) write(id string, offset int, chunk []byte) (received, size int, err error) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	u.pruneLocked()
+	session, ok := u.sessions[id]
+	if !ok {
+		return 0, 0, fmt.Errorf("no upload with upload_id %q (it may have expired or already been finished)", id)
+	}
+	if offset < 0 || offset > len(session.data) {
+		return 0, 0, fmt.Errorf("offset must be between 0 and %d (the number of bytes received so far), got %d", len(session.data), offset)
+	}
+	end := offset + len(chunk)
+	if end > session.size {
+		return 0, 0, fmt.Errorf("chunk ends at byte %d, beyond the declared size of %d bytes", end, session.size)
+	}
+
+	if end > len(session.data) {
+		session.data = session.data[:end]
+	}
+	copy(session.data[offset:], chunk)
+	session.expiresAt = u.now().Add(uploadSessionTTL)
+	return len(session.data), session.size, nil
+}
+
+// complete returns the upload with the given ID once all of its bytes have
+// been received.
+func (u *uploadStore) complete(id string) (*uploadSession, error) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	u.pruneLocked()
+	session, ok := u.sessions[id]
+	if !ok {
+		return nil, fmt.Errorf("no upload with upload_id %q (it may have expired or already been finished)", id)
+	}
+	if len(session.data) != session.size {
+		return nil, fmt.Errorf("upload is incomplete: received %d of %d bytes; continue with upload_chunk at offset %d",
+			len(session.data), session.size, len(session.data))
+	}
+	return session, nil
+}
+
+// remove closes the upload with the given ID.
+func (u *uploadStore) remove(id string) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	delete(u.sessions, id)
+}
+
+// pruneLocked drops expired uploads. The caller must hold u.mu.
+func (u *uploadStore) pruneLocked() {
+	now := u.now()
+	for id, session := range u.sessions {
+		if now.After(session.expiresAt) {
+			delete(u.sessions, id)
+		}
+	}
+}
+
+// handleBeginUpload handles the begin_upload tool call.
+func handleBeginUpload(client any, params map[string]any) (string, error) {
+	c, err := getClient(client)
+	if err != nil {
+		return "", err
+	}
+	if c.uploads == nil {
+		return "", fmt.Errorf("chunked uploads are not available")
+	}
+
+	filename := stringParam(params, "filename")
+	if filename == "" {
+		return "", fmt.Errorf("filename is required")
+	}
+	size, ok := intParam(params, "size")
+	if !ok {
+		return "", fmt.Errorf("size is required and must be a whole number of bytes")
+	}
+	if size <= 0 || size > maxUploadFileBytes {
+		return "", fmt.Errorf("size must be between 1 and %d bytes, got %d", maxUploadFileBytes, size)
+	}
+	checksum := stringParam(params, "sha256")
+	if !sha256Pattern.MatchString(checksum) {
+		return "", fmt.Errorf("sha256 must be the hexadecimal SHA-256 checksum of the file")
+	}
+
+	id, err := c.uploads.begin(filename, size, checksum)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("Upload started. upload_id: %s. Send the file with upload_chunk in base64 chunks of at most %d bytes each (before encoding), starting at offset 0, then call finish_upload. The upload expires after %s without chunks.",
+		id, maxUploadChunkBytes, uploadSessionTTL), nil
+}
+
+// handleUploadChunk handles the upload_chunk tool call.
+func handleUploadChunk(client any, params map[string]any) (string, error) {
+	c, err := getClient(client)
+	if err != nil {
+		return "", err
+	}
+	if c.uploads == nil {
+		return "", fmt.Errorf("chunked uploads are not available")
+	}
+
+	id := stringParam(params, "upload_id")
+	if id == "" {
+		return "", fmt.Errorf("upload_id is required")
+	}
+	offset, ok := intParam(params, "offset")
+	if !ok {
+		return "", fmt.Errorf("offset is required and must be a whole number of bytes")
+	}
+	encoded := stringParam(params, "base64_data")
+	if encoded == "" {
+		return "", fmt.Errorf("base64_data is required")
+	}
+	chunk, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return "", fmt.Errorf("failed to decode base64 data: %w", err)
+	}
+	if len(chunk) > maxUploadChunkBytes {
+		return "", fmt.Errorf("chunk size exceeds maximum of %d bytes", maxUploadChunkBytes)
+	}
+
+	received, size, err := c.uploads.write(id, offset, chunk)
+	if err != nil {
+		return "", err
+	}
+	if received == size {
+		return fmt.Sprintf("Chunk received: %d of %d bytes. All bytes received; call finish_upload with upload_id %s.", received, size, id), nil
+	}
+	return fmt.Sprintf("Chunk received: %d of %d bytes. Next offset: %d.", received, size, received), nil
+}
+
+// handleFinishUpload handles the finish_upload tool call.
+func handleFinishUpload(client any, params map[string]any) (string, error) {
+	c, err := getClient(client)
+	if err != nil {
+		return "", err
+	}
+	if c.uploads == nil {
+		return "", fmt.Errorf("chunked uploads are not available")
+	}
+
+	id := stringParam(params, "upload_id")
+	if id == "" {
+		return "", fmt.Errorf("upload_id is required")
+	}
+	session, err := c.uploads.complete(id)
+	if err != nil {
+		return "", err
+	}
+
+	if got := sha256Hex(session.data); got != session.sha256 {
+		c.uploads.remove(id)
+		return "", fmt.Errorf("checksum mismatch: expected sha256 %s, got %s; the upload was discarded, start again with begin_upload", session.sha256, got)
+	}
+
+	// Keep the received file if WeCom rejects it, so finish_upload can be retried
+	result, err := c.uploadFile(session.filename, session.data)
+	if err != nil {
+		return "", err
+	}
+	c.uploads.remove(id)
+	return result, nil
+}
@@ -0,0 +1,149 @@
+package wecom
+
+import (
+	"bytes"
+	"encoding/base64"
+	"strings"
+	"testing"
+	"time"
+)
+
+func beginTestUpload(t *testing.T, c *Client, filename string, data []byte) string {
+	t.Helper()
+	result, err := handleBeginUpload(c, map[string]any{
+		"filename": filename,
+		"size":     float64(len(data)),
+		"sha256":   sha256Hex(data),
+	})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	id, _, ok := strings.Cut(strings.TrimPrefix(result, "Upload started. upload_id: "), ".")
+	if !ok || id == "" {
+		t.Fatalf("expected an upload_id, got %q", result)
+	}
+	return id
+}
+
+func uploadTestChunk(c *Client, id string, offset int, chunk []byte) (string, error) {
+	return handleUploadChunk(c, map[string]any{
+		"upload_id":   id,
+		"offset":      float64(offset),
+		"base64_data": base64.StdEncoding.EncodeToString(chunk),
+	})
+}
+
+func TestChunkedUpload(t *testing.T) {
+	bot, fake := newFakeBot(t)
+	c := NewClient(bot)
+	data := []byte("0123456789abcdefghij")
+	id := beginTestUpload(t, c, "large.bin", data)
+
+	result, err := uploadTestChunk(c, id, 0, data[:8])
+	if err != nil || !strings.Contains(result, "Next offset: 8") {
+		t.Fatalf("unexpected result %q, %v", result, err)
+	}
+	if _, err := handleFinishUpload(c, map[string]any{"upload_id": id}); err == nil || !strings.Contains(err.Error(), "at offset 8") {
+		t.Fatalf("expected incomplete upload error, got %v", err)
+	}
+	if _, err := uploadTestChunk(c, id, 12, data[12:]); err == nil || !strings.Contains(err.Error(), "between 0 and 8") {
+		t.Fatalf("expected gap to be rejected, got %v", err)
+	}
+
+	// Retrying an overlapping chunk is accepted
+	if _, err := uploadTestChunk(c, id, 4, data[4:14]); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	result, err = uploadTestChunk(c, id, 14, data[14:])
+	if err != nil || !strings.Contains(result, "All bytes received") {
+		t.Fatalf("unexpected result %q, %v", result, err)
+	}
+
+	result, err = handleFinishUpload(c, map[string]any{"upload_id": id})
+	if err != nil || !strings.Contains(result, "media_id: fake-media-1") {
+		t.Fatalf("unexpected result %q, %v", result, err)
+	}
+	uploads := fake.Uploads()
+	if len(uploads) != 1 || uploads[0].Filename != "large.bin" || !bytes.Equal(uploads[0].Data, data) {
+		t.Fatalf("expected the reassembled file to be uploaded, got %+v", uploads)
+	}
+
+	if _, err := handleFinishUpload(c, map[string]any{"upload_id": id}); err == nil {
+		t.Fatal("expected a finished upload to be closed")
+	}
+}
+
+func TestChunkedUpload_ChecksumMismatch(t *testing.T) {
+	bot, fake := newFakeBot(t)
+	c := NewClient(bot)
+	id := beginTestUpload(t, c, "file.txt", []byte("expected content"))
+
+	if _, err := uploadTestChunk(c, id, 0, []byte("tampered content")); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if _, err := handleFinishUpload(c, map[string]any{"upload_id": id}); err == nil || !strings.Contains(err.Error(), "checksum mismatch") {
+		t.Fatalf("expected checksum error, got %v", err)
+	}
+	if len(fake.Uploads()) != 0 {
+		t.Fatal("expected nothing to be uploaded")
+	}
+	if _, err := uploadTestChunk(c, id, 0, []byte("expected content")); err == nil {
+		t.Fatal("expected the upload to be discarded")
+	}
+}
+
+func TestChunkedUpload_Validation(t *testing.T) {
+	c := NewClient(newTestBot(t))
+	checksum := sha256Hex([]byte("x"))
+
+	tests := []struct {
+		name    string
+		params  map[string]any
+		wantErr string
+	}{
+		{name: "missing filename", params: map[string]any{"size": 1.0, "sha256": checksum}, wantErr: "filename is required"},
+		{name: "fractional size", params: map[string]any{"filename": "a", "size": 1.5, "sha256": checksum}, wantErr: "whole number"},
+		{name: "too large", params: map[string]any{"filename": "a", "size": float64(maxUploadFileBytes + 1), "sha256": checksum}, wantErr: "size must be between"},
+		{name: "bad checksum", params: map[string]any{"filename": "a", "size": 1.0, "sha256": "abc"}, wantErr: "sha256 must be"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := handleBeginUpload(c, tt.params); err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("expected error containing %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
+
+	id := beginTestUpload(t, c, "a", []byte("x"))
+	if _, err := uploadTestChunk(c, id, 0, []byte("xy")); err == nil || !strings.Contains(err.Error(), "beyond the declared size") {
+		t.Fatalf("expected oversized chunk error, got %v", err)
+	}
+}
+
+func TestUploadStore_LimitsAndExpiry(t *testing.T) {
+	now := time.Date(2025, 1, 6, 9, 0, 0, 0, time.UTC)
+	store := newUploadStore()
+	store.now = func() time.Time { return now }
+
+	var first string
+	for i := 0; i < maxUploadSessions; i++ {
+		id, err := store.begin("f", 10, sha256Hex(nil))
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		if i == 0 {
+			first = id
+		}
+	}
+	if _, err := store.begin("f", 10, sha256Hex(nil)); err == nil || !strings.Contains(err.Error(), "too many uploads") {
+		t.Fatalf("expected session limit error, got %v", err)
+	}
+
+	now = now.Add(uploadSessionTTL + time.Second)
+	if _, _, err := store.write(first, 0, []byte("x")); err == nil {
+		t.Fatal("expected expired upload to be gone")
+	}
+	if _, err := store.begin("f", 10, sha256Hex(nil)); err != nil {
+		t.Fatalf("expected expired uploads to free their slots, got %v", err)
+	}
+}
@@ -0,0 +1,105 @@
+package wecom
+
+import (
+	"fmt"
+	"net/url"
+	"slices"
+	"strings"
+
+	"github.com/futuretea/wecom-bot-mcp-server/pkg/core/config"
+)
+
+// allowedURLSchemes are the only schemes WeCom clients can open safely.
+var allowedURLSchemes = []string{"http", "https"}
+
+// URLPolicy validates URLs embedded in messages: links, images and card actions.
+// A nil policy still enforces URL syntax and the http/https scheme.
+type URLPolicy struct {
+	allowedDomains []string
+	deniedDomains  []string
+}
+
+// NewURLPolicy creates a URL policy from the configured domain lists.
+func NewURLPolicy(cfg config.URLPolicy) *URLPolicy {
+	return &URLPolicy{
+		allowedDomains: normalizeDomains(cfg.AllowedDomains),
+		deniedDomains:  normalizeDomains(cfg.DeniedDomains),
+	}
+}
+
+// urlField is a URL parameter along with its field path for error messages.
+type urlField struct {
+	name  string
+	value string
+}
+
+// Check validates a single URL.
+func (p *URLPolicy) Check(rawURL string) error {
+	parsed, err := url.Parse(strings.TrimSpace(rawURL))
+	if err != nil {
+		return fmt.Errorf("not a valid URL")
+	}
+
+	scheme := strings.ToLower(parsed.Scheme)
+	if scheme == "" {
+		return fmt.Errorf("missing scheme, expected http or https")
+	}
+	if !slices.Contains(allowedURLSchemes, scheme) {
+		return fmt.Errorf("scheme %q is not allowed, expected http or https", parsed.Scheme)
+	}
+
+	host := strings.TrimSuffix(strings.ToLower(parsed.Hostname()), ".")
+	if host == "" {
+		return fmt.Errorf("missing host")
+	}
+
+	if p == nil {
+		return nil
+	}
+	if matchesDomain(host, p.deniedDomains) {
+		return fmt.Errorf("domain %q is denied", host)
+	}
+	if len(p.allowedDomains) > 0 && !matchesDomain(host, p.allowedDomains) {
+		return fmt.Errorf("domain %q is not in the allowed domain list", host)
+	}
+	return nil
+}
+
+// checkURLs validates every non-empty field and reports all violations at once.
+func (p *URLPolicy) checkURLs(fields ...urlField) error {
+	var violations []string
+	for _, field := range fields {
+		if field.value == "" {
+			continue
+		}
+		if err := p.Check(field.value); err != nil {
+			violations = append(violations, fmt.Sprintf("%s: %v", field.name, err))
+		}
+	}
+	if len(violations) > 0 {
+		return fmt.Errorf("invalid URL: %s", strings.Join(violations, "; "))
+	}
+	return nil
+}
+
+// matchesDomain reports whether host equals or is a subdomain of any domain.
+func matchesDomain(host string, domains []string) bool {
+	for _, domain := range domains {
+		if host == domain || strings.HasSuffix(host, "."+domain) {
+			return true
+		}
+	}
+	return false
+}
+
+// normalizeDomains lowercases domains and drops empty entries and leading dots.
+func normalizeDomains(domains []string) []string {
+	result := make([]string, 0, len(domains))
+	for _, domain := range domains {
+		domain = strings.Trim(strings.ToLower(strings.TrimSpace(domain)), ".")
+		if domain != "" {
+			result = append(result, domain)
+		}
+	}
+	return result
+}
@@ -0,0 +1,112 @@
+package wecom
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/futuretea/wecom-bot-mcp-server/pkg/core/config"
+)
+
+func TestURLPolicyCheck_Schemes(t *testing.T) {
+	var policy *URLPolicy
+	tests := []struct {
+		url     string
+		wantErr string
+	}{
+		{url: "https://example.com/a?b=c"},
+		{url: "HTTP://Example.com"},
+		{url: "javascript:alert(1)", wantErr: `scheme "javascript" is not allowed`},
+		{url: "ftp://example.com/file", wantErr: `scheme "ftp" is not allowed`},
+		{url: "example.com/path", wantErr: "missing scheme"},
+		{url: "https:///path", wantErr: "missing host"},
+		{url: "http://%zz", wantErr: "not a valid URL"},
+	}
+	for _, tt := range tests {
+		err := policy.Check(tt.url)
+		if tt.wantErr == "" {
+			if err != nil {
+				t.Errorf("Check(%q): expected no error, got %v", tt.url, err)
+			}
+			continue
+		}
+		if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+			t.Errorf("Check(%q): expected error containing %q, got %v", tt.url, tt.wantErr, err)
+		}
+	}
+}
+
+func TestURLPolicyCheck_Domains(t *testing.T) {
+	policy := NewURLPolicy(config.URLPolicy{
+		AllowedDomains: []string{"Example.com", ".corp.example.org"},
+		DeniedDomains:  []string{"evil.example.com"},
+	})
+
+	for _, ok := range []string{"https://example.com", "https://docs.example.com/x", "https://git.corp.example.org"} {
+		if err := policy.Check(ok); err != nil {
+			t.Errorf("Check(%q): expected no error, got %v", ok, err)
+		}
+	}
+	if err := policy.Check("https://evil.example.com/login"); err == nil || !strings.Contains(err.Error(), "is denied") {
+		t.Errorf("expected denied domain error, got %v", err)
+	}
+	if err := policy.Check("https://examp1e.com"); err == nil || !strings.Contains(err.Error(), "not in the allowed domain list") {
+		t.Errorf("expected allowlist error, got %v", err)
+	}
+	if err := policy.Check("https://notexample.com"); err == nil {
+		t.Error("expected suffix without dot boundary not to match allowed domain")
+	}
+}
+
+func TestCheckURLs_ReportsEveryField(t *testing.T) {
+	var policy *URLPolicy
+	err := policy.checkURLs(
+		urlField{name: "articles[0].url", value: "javascript:alert(1)"},
+		urlField{name: "articles[0].picurl", value: ""},
+		urlField{name: "articles[1].url", value: "https://example.com"},
+		urlField{name: "articles[1].picurl", value: "data:image/png;base64,AAAA"},
+	)
+	if err == nil {
+		t.Fatal("expected error")
+	}
+	if !strings.Contains(err.Error(), "articles[0].url:") || !strings.Contains(err.Error(), "articles[1].picurl:") {
+		t.Fatalf("expected per-field errors, got %v", err)
+	}
+	if strings.Contains(err.Error(), "articles[1].url:") {
+		t.Fatalf("expected valid field not to be reported, got %v", err)
+	}
+}
+
+func TestHandleSendNews_InvalidURL(t *testing.T) {
+	bot := newTestBot(t)
+	articles := []any{map[string]any{"title": "Test", "url": "javascript:alert(1)"}}
+	_, err := handleSendNews(bot, map[string]any{"articles": articles})
+	if err == nil || !strings.Contains(err.Error(), "articles[0].url") {
+		t.Fatalf("expected articles[0].url error, got %v", err)
+	}
+}
+
+func TestHandleSendTextNoticeCard_InvalidJumpURL(t *testing.T) {
+	bot := newTestBot(t)
+	_, err := handleSendTextNoticeCard(bot, map[string]any{
+		"main_title":  "Test",
+		"jump_list":   []any{map[string]any{"title": "Go", "url": "ftp://example.com"}},
+		"card_action": map[string]any{"url": "https://example.com"},
+	})
+	if err == nil || !strings.Contains(err.Error(), "jump_list[0].url") {
+		t.Fatalf("expected jump_list[0].url error, got %v", err)
+	}
+}
+
+func TestHandleSendNewsNoticeCard_DeniedDomain(t *testing.T) {
+	c := NewClient(newTestBot(t))
+	c.URLPolicy = NewURLPolicy(config.URLPolicy{DeniedDomains: []string{"evil.example.com"}})
+	_, err := handleSendNewsNoticeCard(c, map[string]any{
+		"main_title":     "Test",
+		"card_image_url": "https://evil.example.com/banner.png",
+		"source":         map[string]any{"icon_url": "https://example.com/icon.png"},
+		"card_action":    map[string]any{"url": "https://example.com"},
+	})
+	if err == nil || !strings.Contains(err.Error(), "card_image_url: domain") {
+		t.Fatalf("expected card_image_url domain error, got %v", err)
+	}
+}
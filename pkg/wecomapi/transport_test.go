@@ -0,0 +1,107 @@
+package wecomapi
+
+import (
+	"context"
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/futuretea/wecom-bot-mcp-server/pkg/core/config"
+)
+
+func okHandler(w http.ResponseWriter, r *http.Request) {
+	_, _ = w.Write([]byte(`{"errcode":0,"errmsg":"ok"}`))
+}
+
+func TestNew_Defaults(t *testing.T) {
+	client, err := New(config.WeComAPI{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if client.BaseURL() != config.DefaultWeComBaseURL {
+		t.Fatalf("expected default base URL, got %s", client.BaseURL())
+	}
+	if client.httpClient.Timeout != DefaultTimeout {
+		t.Fatalf("expected default timeout, got %s", client.httpClient.Timeout)
+	}
+}
+
+func TestNew_PoolSettings(t *testing.T) {
+	client, err := New(config.WeComAPI{
+		Timeout:             5 * time.Second,
+		MaxIdleConns:        7,
+		MaxIdleConnsPerHost: 3,
+		MaxConnsPerHost:     4,
+		IdleConnTimeout:     time.Minute,
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	transport := client.httpClient.Transport.(*http.Transport)
+	if client.httpClient.Timeout != 5*time.Second || transport.MaxIdleConns != 7 || transport.MaxIdleConnsPerHost != 3 ||
+		transport.MaxConnsPerHost != 4 || transport.IdleConnTimeout != time.Minute {
+		t.Fatalf("settings not applied: timeout=%s transport=%+v", client.httpClient.Timeout, transport)
+	}
+}
+
+func TestNew_Proxy(t *testing.T) {
+	var proxied string
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		proxied = r.URL.String()
+		okHandler(w, r)
+	}))
+	defer proxy.Close()
+
+	client, err := New(config.WeComAPI{BaseURL: "http://wecom.invalid", Proxy: proxy.URL})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := client.Bot(testKey).Send(map[string]any{"msgtype": "text"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !strings.HasPrefix(proxied, "http://wecom.invalid"+SendPath) {
+		t.Fatalf("expected request through the proxy, got %q", proxied)
+	}
+}
+
+func TestNew_CAFile(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(okHandler))
+	defer server.Close()
+
+	// Without the CA the self-signed test certificate is rejected
+	client, err := New(config.WeComAPI{BaseURL: server.URL})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := client.Send(context.Background(), testKey, map[string]any{}); err == nil {
+		t.Fatal("expected certificate error without ca_file")
+	}
+
+	caFile := filepath.Join(t.TempDir(), "ca.pem")
+	certPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw})
+	if err := os.WriteFile(caFile, certPEM, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	client, err = New(config.WeComAPI{BaseURL: server.URL, CAFile: caFile})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := client.Send(context.Background(), testKey, map[string]any{}); err != nil {
+		t.Fatalf("expected request to succeed with ca_file, got %v", err)
+	}
+}
+
+func TestNew_InvalidCAFile(t *testing.T) {
+	caFile := filepath.Join(t.TempDir(), "ca.pem")
+	if err := os.WriteFile(caFile, []byte("not a certificate"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := New(config.WeComAPI{CAFile: caFile}); err == nil || !strings.Contains(err.Error(), "no PEM certificates") {
+		t.Fatalf("expected PEM error, got %v", err)
+	}
+}
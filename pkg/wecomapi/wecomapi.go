@@ -0,0 +1,279 @@
+package wecomapi
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+// Webhook API paths
+const (
+	SendPath   = "/cgi-bin/webhook/send"
+	UploadPath = "/cgi-bin/webhook/upload_media"
+)
+
+// Error codes returned by the webhook API
+const (
+	ErrCodeSystemBusy         = -1
+	ErrCodeOK                 = 0
+	ErrCodeInvalidMediaSize   = 40006
+	ErrCodeInvalidMediaID     = 40007
+	ErrCodeInvalidMessageType = 40008
+	ErrCodeInvalidImageSize   = 40009
+	ErrCodeInvalidParameter   = 40058
+	ErrCodeEmptyContent       = 44004
+	ErrCodeRateLimited        = 45009
+	ErrCodeInvalidWebhookKey  = 93000
+	ErrCodeBotRemoved         = 93017
+)
+
+// errCodeDescriptions explains common webhook API error codes
+var errCodeDescriptions = map[int]string{
+	ErrCodeSystemBusy:         "system busy, retry later",
+	ErrCodeOK:                 "ok",
+	ErrCodeInvalidMediaSize:   "invalid media size",
+	ErrCodeInvalidMediaID:     "invalid media_id",
+	ErrCodeInvalidMessageType: "invalid message type",
+	ErrCodeInvalidImageSize:   "invalid image size",
+	ErrCodeInvalidParameter:   "invalid or missing parameter",
+	ErrCodeEmptyContent:       "empty text content",
+	ErrCodeRateLimited:        "rate limit exceeded (20 messages per minute per bot)",
+	ErrCodeInvalidWebhookKey:  "invalid webhook key",
+	ErrCodeBotRemoved:         "bot has been removed from the group",
+}
+
+// DescribeErrCode returns a short explanation of a webhook API error code
+func DescribeErrCode(code int) string {
+	if description, ok := errCodeDescriptions[code]; ok {
+		return description
+	}
+	return "unknown error code"
+}
+
+// keyPattern matches webhook keys, which are UUIDs
+var keyPattern = regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`)
+
+// CheckKeyFormat reports whether key looks like a webhook key
+func CheckKeyFormat(key string) error {
+	switch {
+	case key == "":
+		return fmt.Errorf("key is not set")
+	case strings.Contains(key, "key="):
+		return fmt.Errorf("key looks like a webhook URL; use only the value of its key parameter")
+	case strings.TrimSpace(key) != key:
+		return fmt.Errorf("key has leading or trailing whitespace")
+	case !keyPattern.MatchString(key):
+		return fmt.Errorf("key must be a UUID of 8-4-4-4-12 hexadecimal digits, got %d characters", len(key))
+	}
+	return nil
+}
+
+// Response is the JSON body returned by every webhook API call
+type Response struct {
+	ErrCode int    `json:"errcode"`
+	ErrMsg  string `json:"errmsg"`
+}
+
+// Err converts a non-zero error code into an *APIError
+func (r *Response) Err() error {
+	if r.ErrCode == ErrCodeOK {
+		return nil
+	}
+	return &APIError{Code: r.ErrCode, Message: r.ErrMsg}
+}
+
+// APIError is a non-zero error code returned by the webhook API
+type APIError struct {
+	Code    int
+	Message string
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("wecom api error %d (%s): %s", e.Code, DescribeErrCode(e.Code), e.Message)
+}
+
+// Client calls the WeCom group bot webhook API
+type Client struct {
+	baseURL    string
+	httpClient *http.Client
+}
+
+// NewClient creates a client for the API at baseURL, e.g.
+// "https://qyapi.weixin.qq.com". A nil httpClient uses http.DefaultClient.
+func NewClient(baseURL string, httpClient *http.Client) *Client {
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+	return &Client{
+		baseURL:    strings.TrimSuffix(baseURL, "/"),
+		httpClient: httpClient,
+	}
+}
+
+// BaseURL returns the API base URL
+func (c *Client) BaseURL() string {
+	return c.baseURL
+}
+
+// Send posts a message payload to the webhook identified by key. The returned
+// error only covers transport and decoding failures; check Response.Err for
+// errors reported by WeCom.
+func (c *Client) Send(ctx context.Context, key string, payload any) (*Response, error) {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode message: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.endpoint(SendPath, key, nil), bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", redactError(err))
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	var result Response
+	if err := c.do(req, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// Media is a file uploaded with UploadMedia. The media ID is valid for three days.
+type Media struct {
+	Type      string `json:"type"`
+	MediaID   string `json:"media_id"`
+	CreatedAt string `json:"created_at"`
+}
+
+// uploadResponse is the JSON body returned by the upload endpoint
+type uploadResponse struct {
+	Response
+	Media
+}
+
+// UploadMedia uploads a file to the webhook identified by key for use in file
+// messages
+func (c *Client) UploadMedia(ctx context.Context, key, filename string, data []byte) (*Media, error) {
+	var body bytes.Buffer
+	writer := multipart.NewWriter(&body)
+	part, err := writer.CreateFormFile("media", filename)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create upload form: %w", err)
+	}
+	if _, err := part.Write(data); err != nil {
+		return nil, fmt.Errorf("failed to create upload form: %w", err)
+	}
+	if err := writer.Close(); err != nil {
+		return nil, fmt.Errorf("failed to create upload form: %w", err)
+	}
+
+	query := url.Values{"type": {"file"}}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.endpoint(UploadPath, key, query), &body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", redactError(err))
+	}
+	req.Header.Set("Content-Type", writer.FormDataContentType())
+
+	var result uploadResponse
+	if err := c.do(req, &result); err != nil {
+		return nil, err
+	}
+	if err := result.Err(); err != nil {
+		return nil, err
+	}
+	return &result.Media, nil
+}
+
+// endpoint builds the URL of an API path for key
+func (c *Client) endpoint(path, key string, query url.Values) string {
+	if query == nil {
+		query = url.Values{}
+	}
+	query.Set("key", key)
+	return c.baseURL + path + "?" + query.Encode()
+}
+
+// do sends a request and decodes the webhook API response into result
+func (c *Client) do(req *http.Request, result any) error {
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to call wecom api: %w", redactError(err))
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return fmt.Errorf("failed to read wecom api response: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("wecom api returned HTTP %d: %s", resp.StatusCode, strings.TrimSpace(string(data)))
+	}
+
+	if err := json.Unmarshal(data, result); err != nil {
+		return fmt.Errorf("failed to decode wecom api response: %w", err)
+	}
+	return nil
+}
+
+// redactedKey replaces the webhook key in request URLs shown in errors
+const redactedKey = "REDACTED"
+
+// redactError hides the webhook key in the request URL of a transport error,
+// which would otherwise reach tool results and webhook responses
+func redactError(err error) error {
+	var urlErr *url.Error
+	if !errors.As(err, &urlErr) {
+		return err
+	}
+	return &url.Error{Op: urlErr.Op, URL: redactURL(urlErr.URL), Err: urlErr.Err}
+}
+
+// redactURL replaces the key query parameter of a URL. The query string is
+// dropped when the URL cannot be parsed.
+func redactURL(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		base, _, _ := strings.Cut(rawURL, "?")
+		return base
+	}
+	query := u.Query()
+	if query.Has("key") {
+		query.Set("key", redactedKey)
+		u.RawQuery = query.Encode()
+	}
+	return u.String()
+}
+
+// Bot sends messages to a single group bot webhook
+type Bot struct {
+	client *Client
+	key    string
+}
+
+// Bot returns a bot that sends through c with the given webhook key
+func (c *Client) Bot(key string) *Bot {
+	return &Bot{client: c, key: key}
+}
+
+// Send posts a message and returns an *APIError if WeCom rejects it. msg is
+// any value that marshals to a webhook message, such as the message types of
+// the go-wecom-bot packages.
+func (b *Bot) Send(msg any) error {
+	resp, err := b.client.Send(context.Background(), b.key, msg)
+	if err != nil {
+		return err
+	}
+	return resp.Err()
+}
+
+// UploadMedia uploads a file for use in file messages
+func (b *Bot) UploadMedia(filename string, data []byte) (*Media, error) {
+	return b.client.UploadMedia(context.Background(), b.key, filename, data)
+}
@@ -0,0 +1,154 @@
+package wecomapi
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+const testKey = "693a91f6-7abc-4bc4-97a0-0ec2cafe5aaa"
+
+func TestCheckKeyFormat(t *testing.T) {
+	tests := []struct {
+		key     string
+		wantErr string
+	}{
+		{key: testKey},
+		{key: "", wantErr: "not set"},
+		{key: "https://qyapi.weixin.qq.com/cgi-bin/webhook/send?key=" + testKey, wantErr: "webhook URL"},
+		{key: testKey + " ", wantErr: "whitespace"},
+		{key: "not-a-key", wantErr: "UUID"},
+	}
+	for _, tt := range tests {
+		err := CheckKeyFormat(tt.key)
+		if tt.wantErr == "" {
+			if err != nil {
+				t.Errorf("CheckKeyFormat(%q) returned %v", tt.key, err)
+			}
+			continue
+		}
+		if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+			t.Errorf("CheckKeyFormat(%q) = %v, want error containing %q", tt.key, err, tt.wantErr)
+		}
+	}
+}
+
+func TestSend(t *testing.T) {
+	var gotKey string
+	var gotBody map[string]any
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != SendPath || r.Method != http.MethodPost {
+			http.NotFound(w, r)
+			return
+		}
+		gotKey = r.URL.Query().Get("key")
+		_ = json.NewDecoder(r.Body).Decode(&gotBody)
+		_, _ = w.Write([]byte(`{"errcode":93000,"errmsg":"invalid webhook url"}`))
+	}))
+	defer server.Close()
+
+	client := NewClient(server.URL+"/", nil)
+	resp, err := client.Send(context.Background(), testKey, map[string]any{"msgtype": "text"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if gotKey != testKey || gotBody["msgtype"] != "text" {
+		t.Fatalf("unexpected request: key=%q body=%v", gotKey, gotBody)
+	}
+
+	var apiErr *APIError
+	if !errors.As(resp.Err(), &apiErr) || apiErr.Code != ErrCodeInvalidWebhookKey {
+		t.Fatalf("expected APIError 93000, got %v", resp.Err())
+	}
+	if !strings.Contains(apiErr.Error(), "invalid webhook key") {
+		t.Fatalf("expected description in error, got %q", apiErr.Error())
+	}
+}
+
+func TestSend_HTTPError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "bad gateway", http.StatusBadGateway)
+	}))
+	defer server.Close()
+
+	_, err := NewClient(server.URL, nil).Send(context.Background(), testKey, map[string]any{})
+	if err == nil || !strings.Contains(err.Error(), "HTTP 502") {
+		t.Fatalf("expected HTTP error, got %v", err)
+	}
+}
+
+func TestSend_TransportErrorRedactsKey(t *testing.T) {
+	server := httptest.NewServer(http.NotFoundHandler())
+	server.Close()
+
+	client := NewClient(server.URL, nil)
+	_, err := client.Send(context.Background(), testKey, map[string]any{})
+	if err == nil {
+		t.Fatal("expected an error from a closed server")
+	}
+	if strings.Contains(err.Error(), testKey) || !strings.Contains(err.Error(), "key="+redactedKey) {
+		t.Fatalf("expected the key to be redacted, got %q", err)
+	}
+
+	_, err = client.UploadMedia(context.Background(), testKey, "a.txt", []byte("hi"))
+	if err == nil || strings.Contains(err.Error(), testKey) {
+		t.Fatalf("expected the key to be redacted from upload errors, got %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = NewClient("http://127.0.0.1:1", nil).Send(ctx, testKey, map[string]any{})
+	if !errors.Is(err, context.Canceled) || strings.Contains(err.Error(), testKey) {
+		t.Fatalf("expected a redacted error wrapping context.Canceled, got %v", err)
+	}
+}
+
+func TestUploadMedia(t *testing.T) {
+	var gotType, gotFilename, gotContent string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != UploadPath {
+			http.NotFound(w, r)
+			return
+		}
+		gotType = r.URL.Query().Get("type")
+		file, header, err := r.FormFile("media")
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		defer file.Close()
+		data, _ := io.ReadAll(file)
+		gotFilename, gotContent = header.Filename, string(data)
+		_, _ = w.Write([]byte(`{"errcode":0,"errmsg":"ok","type":"file","media_id":"m-1","created_at":"1380000000"}`))
+	}))
+	defer server.Close()
+
+	media, err := NewClient(server.URL, nil).Bot(testKey).UploadMedia("report.txt", []byte("hello"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if media.MediaID != "m-1" || media.Type != "file" || media.CreatedAt != "1380000000" {
+		t.Fatalf("unexpected media: %+v", media)
+	}
+	if gotType != "file" || gotFilename != "report.txt" || gotContent != "hello" {
+		t.Fatalf("unexpected upload: type=%q filename=%q content=%q", gotType, gotFilename, gotContent)
+	}
+}
+
+func TestBotSend_APIError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"errcode":45009,"errmsg":"api freq out of limit"}`))
+	}))
+	defer server.Close()
+
+	err := NewClient(server.URL, nil).Bot(testKey).Send(map[string]any{"msgtype": "text"})
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) || apiErr.Code != ErrCodeRateLimited {
+		t.Fatalf("expected rate limit error, got %v", err)
+	}
+}